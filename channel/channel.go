@@ -146,6 +146,20 @@ func (c *Channel) WatchEvent(wg *sync.WaitGroup) {
 			log.Infof("[%s / %s]: Received the done signal, channel exiting...", kind, c.Name)
 			return
 		case result := <-c.channel:
+			// if the probe is in the maintenance window, no need notify
+			if result.IsInMaintenance() {
+				log.Debugf("[%s / %s]: %s (%s) - In the maintenance window [%s], no notification.",
+					kind, c.Name, result.Name, result.Endpoint, result.Maintenance)
+				continue
+			}
+
+			// if the probe is unreachable because of the dependency, no need notify
+			if isDependencyChange(result) {
+				log.Debugf("[%s / %s]: %s (%s) - The dependency is down [%s] ==> [%s], no notification.",
+					kind, c.Name, result.Name, result.Endpoint, result.PreStatus, result.Status)
+				continue
+			}
+
 			// if it is the first time, and the status is UP, no need notify
 			if result.PreStatus == probe.StatusInit && result.Status == probe.StatusUp {
 				log.Debugf("[%s / %s]: %s (%s) - Initial Status [%s] == [%s], no notification.",
@@ -153,16 +167,17 @@ func (c *Channel) WatchEvent(wg *sync.WaitGroup) {
 				continue
 			}
 
-			// if the status has no change for UP or Init, no need notify
-			if result.PreStatus == result.Status && (result.Status == probe.StatusUp || result.Status == probe.StatusInit) {
+			// if the status has no change for UP, DEGRADED, FLAPPING or Init, no need notify
+			if result.PreStatus == result.Status && (result.Status.IsAvailable() ||
+				result.Status == probe.StatusFlapping || result.Status == probe.StatusInit) {
 				log.Debugf("[%s / %s]: %s (%s) - Status no change [%s] == [%s], no notification.",
 					kind, c.Name, result.Name, result.Endpoint, result.PreStatus, result.Status)
 				continue
 			}
 
 			nsd := &result.Stat.NotificationStrategyData
-			// if the status changed to UP, reset the notification strategy
-			if result.Status == probe.StatusUp {
+			// if the status changed to UP or DEGRADED, reset the notification strategy
+			if result.Status.IsAvailable() {
 				nsd.Reset()
 			}
 
@@ -184,6 +199,11 @@ func (c *Channel) WatchEvent(wg *sync.WaitGroup) {
 			}
 
 			for _, n := range c.Notifiers {
+				if n.IsIgnoreDegraded() && isDegradedChange(result) {
+					log.Debugf("[%s / %s]: %s (%s) - Notifier [%s] ignores the degraded status [%s] ==> [%s]",
+						kind, c.Name, result.Name, result.Endpoint, n.Name(), result.PreStatus, result.Status)
+					continue
+				}
 				if IsDryNotify() == true {
 					n.DryNotify(result)
 				} else {
@@ -193,3 +213,21 @@ func (c *Channel) WatchEvent(wg *sync.WaitGroup) {
 		}
 	}
 }
+
+// isDependencyChange returns true if the probe is unreachable because of the dependency,
+// or it recovers from the unreachable status, which is never notified.
+func isDependencyChange(result probe.Result) bool {
+	if result.Status == probe.StatusUnreachable {
+		return true
+	}
+	return result.PreStatus == probe.StatusUnreachable && result.Status.IsAvailable()
+}
+
+// isDegradedChange returns true if the status changes between UP and DEGRADED,
+// the changes from/to DOWN are not included.
+func isDegradedChange(result probe.Result) bool {
+	if result.Status == probe.StatusDegraded {
+		return result.PreStatus != probe.StatusDown && result.PreStatus != probe.StatusUnknown
+	}
+	return result.PreStatus == probe.StatusDegraded && result.Status == probe.StatusUp
+}
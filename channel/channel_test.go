@@ -63,3 +63,42 @@ func TestChannel(t *testing.T) {
 	assert.Equal(t, "http", ch.GetProber("dummy-XY").Kind())
 
 }
+
+func TestIsDegradedChange(t *testing.T) {
+	cases := []struct {
+		pre, cur probe.Status
+		expected bool
+	}{
+		{probe.StatusUp, probe.StatusDegraded, true},
+		{probe.StatusInit, probe.StatusDegraded, true},
+		{probe.StatusDegraded, probe.StatusUp, true},
+		{probe.StatusDown, probe.StatusDegraded, false},
+		{probe.StatusDegraded, probe.StatusDown, false},
+		{probe.StatusUp, probe.StatusDown, false},
+		{probe.StatusDown, probe.StatusUp, false},
+	}
+	for _, c := range cases {
+		r := probe.Result{PreStatus: c.pre, Status: c.cur}
+		assert.Equal(t, c.expected, isDegradedChange(r), "%s ==> %s", c.pre, c.cur)
+	}
+}
+
+func TestIsDependencyChange(t *testing.T) {
+	cases := []struct {
+		pre, cur probe.Status
+		expected bool
+	}{
+		{probe.StatusUp, probe.StatusUnreachable, true},
+		{probe.StatusDown, probe.StatusUnreachable, true},
+		{probe.StatusUnreachable, probe.StatusUnreachable, true},
+		{probe.StatusUnreachable, probe.StatusUp, true},
+		{probe.StatusUnreachable, probe.StatusDegraded, true},
+		{probe.StatusUnreachable, probe.StatusDown, false},
+		{probe.StatusUp, probe.StatusDown, false},
+		{probe.StatusDown, probe.StatusUp, false},
+	}
+	for _, c := range cases {
+		r := probe.Result{PreStatus: c.pre, Status: c.cur}
+		assert.Equal(t, c.expected, isDependencyChange(r), "%s ==> %s", c.pre, c.cur)
+	}
+}
@@ -56,6 +56,23 @@ func configProbers(probers []probe.Prober) []probe.Prober {
 		validProbers = append(validProbers, p)
 	}
 
+	// check the dependencies of the probers
+	errs := probe.CheckDependencies(validProbers)
+	if len(errs) <= 0 {
+		return validProbers
+	}
+	probers = validProbers
+	validProbers = []probe.Prober{}
+	for _, p := range probers {
+		if err, ok := errs[p.Name()]; ok {
+			p.Result().Status = probe.StatusBad
+			p.Result().Message = "Bad Configuration: " + err.Error()
+			log.Errorf("Bad Probe Dependency for prober %s %s: %v", p.Kind(), p.Name(), err)
+			continue
+		}
+		validProbers = append(validProbers, p)
+	}
+
 	return validProbers
 }
 
@@ -35,14 +35,23 @@ import (
 	"github.com/megaease/easeprobe/global"
 	"github.com/megaease/easeprobe/notify"
 	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/aggregate"
 	"github.com/megaease/easeprobe/probe/client"
+	"github.com/megaease/easeprobe/probe/dns"
+	"github.com/megaease/easeprobe/probe/file"
+	"github.com/megaease/easeprobe/probe/group"
+	"github.com/megaease/easeprobe/probe/grpc"
+	"github.com/megaease/easeprobe/probe/heartbeat"
 	"github.com/megaease/easeprobe/probe/host"
 	"github.com/megaease/easeprobe/probe/http"
+	"github.com/megaease/easeprobe/probe/maintenance"
 	"github.com/megaease/easeprobe/probe/ping"
+	"github.com/megaease/easeprobe/probe/process"
 	"github.com/megaease/easeprobe/probe/shell"
 	"github.com/megaease/easeprobe/probe/ssh"
 	"github.com/megaease/easeprobe/probe/tcp"
 	"github.com/megaease/easeprobe/probe/tls"
+	"github.com/megaease/easeprobe/probe/udp"
 	"github.com/megaease/easeprobe/probe/websocket"
 
 	"github.com/invopop/jsonschema"
@@ -139,18 +148,27 @@ type Settings struct {
 
 // Conf is Probe configuration
 type Conf struct {
-	Version   string                `yaml:"version" json:"version,omitempty" jsonschema:"title=Version,description=Version of the EaseProbe configuration"`
-	HTTP      []http.HTTP           `yaml:"http" json:"http,omitempty" jsonschema:"title=HTTP Probe,description=HTTP Probe Configuration"`
-	TCP       []tcp.TCP             `yaml:"tcp" json:"tcp,omitempty" jsonschema:"title=TCP Probe,description=TCP Probe Configuration"`
-	Shell     []shell.Shell         `yaml:"shell" json:"shell,omitempty" jsonschema:"title=Shell Probe,description=Shell Probe Configuration"`
-	Client    []client.Client       `yaml:"client" json:"client,omitempty" jsonschema:"title=Native Client Probe,description=Native Client Probe Configuration"`
-	SSH       ssh.SSH               `yaml:"ssh" json:"ssh,omitempty" jsonschema:"title=SSH Probe,description=SSH Probe Configuration"`
-	TLS       []tls.TLS             `yaml:"tls" json:"tls,omitempty" jsonschema:"title=TLS Probe,description=TLS Probe Configuration"`
-	Host      host.Host             `yaml:"host" json:"host,omitempty" jsonschema:"title=Host Probe,description=Host Probe Configuration"`
-	Ping      []ping.Ping           `yaml:"ping" json:"ping,omitempty" jsonschema:"title=Ping Probe,description=Ping Probe Configuration"`
-	WebSocket []websocket.WebSocket `yaml:"websocket" json:"websocket,omitempty" jsonschema:"title=WebSocket Probe,description=WebSocket Probe Configuration"`
-	Notify    notify.Config         `yaml:"notify" json:"notify,omitempty" jsonschema:"title=Notification,description=Notification Configuration"`
-	Settings  Settings              `yaml:"settings" json:"settings,omitempty" jsonschema:"title=Global Settings,description=EaseProbe Global configuration"`
+	Version     string                `yaml:"version" json:"version,omitempty" jsonschema:"title=Version,description=Version of the EaseProbe configuration"`
+	HTTP        []http.HTTP           `yaml:"http" json:"http,omitempty" jsonschema:"title=HTTP Probe,description=HTTP Probe Configuration"`
+	TCP         []tcp.TCP             `yaml:"tcp" json:"tcp,omitempty" jsonschema:"title=TCP Probe,description=TCP Probe Configuration"`
+	Shell       []shell.Shell         `yaml:"shell" json:"shell,omitempty" jsonschema:"title=Shell Probe,description=Shell Probe Configuration"`
+	Client      []client.Client       `yaml:"client" json:"client,omitempty" jsonschema:"title=Native Client Probe,description=Native Client Probe Configuration"`
+	SSH         ssh.SSH               `yaml:"ssh" json:"ssh,omitempty" jsonschema:"title=SSH Probe,description=SSH Probe Configuration"`
+	TLS         []tls.TLS             `yaml:"tls" json:"tls,omitempty" jsonschema:"title=TLS Probe,description=TLS Probe Configuration"`
+	Host        host.Host             `yaml:"host" json:"host,omitempty" jsonschema:"title=Host Probe,description=Host Probe Configuration"`
+	Ping        []ping.Ping           `yaml:"ping" json:"ping,omitempty" jsonschema:"title=Ping Probe,description=Ping Probe Configuration"`
+	WebSocket   []websocket.WebSocket `yaml:"websocket" json:"websocket,omitempty" jsonschema:"title=WebSocket Probe,description=WebSocket Probe Configuration"`
+	DNS         []dns.DNS             `yaml:"dns" json:"dns,omitempty" jsonschema:"title=DNS Probe,description=DNS Probe Configuration"`
+	GRPC        []grpc.GRPC           `yaml:"grpc" json:"grpc,omitempty" jsonschema:"title=gRPC Probe,description=gRPC Health Checking Probe Configuration"`
+	UDP         []udp.UDP             `yaml:"udp" json:"udp,omitempty" jsonschema:"title=UDP Probe,description=UDP Probe Configuration"`
+	Aggregate   []aggregate.Aggregate `yaml:"aggregate" json:"aggregate,omitempty" jsonschema:"title=Aggregate Probe,description=Aggregate Probe Configuration"`
+	Groups      []group.Group         `yaml:"groups" json:"groups,omitempty" jsonschema:"title=Host Groups,description=The host groups, each of them declares several checks against one target"`
+	Process     []process.Process     `yaml:"process" json:"process,omitempty" jsonschema:"title=Process Probe,description=Process Probe Configuration"`
+	File        []file.File           `yaml:"file" json:"file,omitempty" jsonschema:"title=File Probe,description=File Probe Configuration"`
+	Heartbeat   []heartbeat.Heartbeat `yaml:"heartbeat" json:"heartbeat,omitempty" jsonschema:"title=Heartbeat Probe,description=Passive Heartbeat Probe Configuration"`
+	Maintenance []maintenance.Window  `yaml:"maintenance" json:"maintenance,omitempty" jsonschema:"title=Maintenance Windows,description=The scheduled maintenance windows which silence the alerts"`
+	Notify      notify.Config         `yaml:"notify" json:"notify,omitempty" jsonschema:"title=Notification,description=Notification Configuration"`
+	Settings    Settings              `yaml:"settings" json:"settings,omitempty" jsonschema:"title=Global Settings,description=EaseProbe Global configuration"`
 }
 
 // JSONSchema return the json schema of the configuration
@@ -357,6 +375,7 @@ func New(conf *string) (*Conf, error) {
 	global.InitEaseProbeWithTime(c.Settings.Name, c.Settings.IconURL,
 		c.Settings.TimeFormat, c.Settings.TimeZone)
 	c.initData()
+	maintenance.SetWindows(c.Maintenance)
 
 	ssh.BastionMap.ParseAllBastionHost()
 	host.BastionMap.ParseAllBastionHost()
@@ -438,7 +457,13 @@ func isProbe(t reflect.Type) bool {
 // AllProbers return all probers
 func (conf *Conf) AllProbers() []probe.Prober {
 	log.Debugf("--------- Process the probers settings ---------")
-	return allProbersHelper(*conf)
+	probers := allProbersHelper(*conf)
+
+	// expand the checks of the host groups into the individual probers
+	for i := range conf.Groups {
+		probers = append(probers, conf.Groups[i].Probers()...)
+	}
+	return probers
 }
 
 func allProbersHelper(i interface{}) []probe.Prober {
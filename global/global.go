@@ -68,6 +68,8 @@ const (
 	DefaultChannelName = "__EaseProbe_Channel__"
 	// DefaultStatusChangeThresholdSetting is the threshold of status change
 	DefaultStatusChangeThresholdSetting = 1
+	// DefaultFlapWindow is the default number of the latest probes to detect the flapping
+	DefaultFlapWindow = 10
 	// DefaultNotificationStrategy is the default notify strategy
 	DefaultNotificationStrategy = RegularStrategy
 	// DefaultMaxNotificationTimes is the default max notification times
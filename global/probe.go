@@ -28,6 +28,10 @@ type StatusChangeThresholdSettings struct {
 	Failure int `yaml:"failure,omitempty" json:"failure,omitempty" jsonschema:"title=Failure Threshold,description=the failures threshold to change the status such as 3,default=1"`
 	// the success threshold such as 2, 5
 	Success int `yaml:"success,omitempty" json:"success,omitempty" jsonschema:"title=Success Threshold,description=the success threshold to change the status such as 2,default=1"`
+	// the status changes threshold to detect the flapping, 0 means disabled
+	FlapThreshold int `yaml:"flap_threshold,omitempty" json:"flap_threshold,omitempty" jsonschema:"title=Flapping Threshold,description=the number of status changes in the flapping window to mark the probe as flapping (0 means disabled),default=0"`
+	// the number of the latest probes to detect the flapping
+	FlapWindow int `yaml:"flap_window,omitempty" json:"flap_window,omitempty" jsonschema:"title=Flapping Window,description=the number of the latest probes to count the status changes,default=10"`
 }
 
 // ProbeSettings is the global probe setting
@@ -50,10 +54,16 @@ func (p *ProbeSettings) NormalizeInterval(t time.Duration) time.Duration {
 
 // NormalizeThreshold return a normalized threshold value
 func (p *ProbeSettings) NormalizeThreshold(t StatusChangeThresholdSettings) StatusChangeThresholdSettings {
-	return StatusChangeThresholdSettings{
-		Failure: normalize(p.Failure, t.Failure, 0, DefaultStatusChangeThresholdSetting),
-		Success: normalize(p.Success, t.Success, 0, DefaultStatusChangeThresholdSetting),
+	s := StatusChangeThresholdSettings{
+		Failure:       normalize(p.Failure, t.Failure, 0, DefaultStatusChangeThresholdSetting),
+		Success:       normalize(p.Success, t.Success, 0, DefaultStatusChangeThresholdSetting),
+		FlapThreshold: normalize(p.FlapThreshold, t.FlapThreshold, 0, 0),
 	}
+	// the flapping window only makes sense if the flapping detection is enabled
+	if s.FlapThreshold > 0 {
+		s.FlapWindow = normalize(p.FlapWindow, t.FlapWindow, 0, DefaultFlapWindow)
+	}
+	return s
 }
 
 // NormalizeNotificationStrategy return a normalized notification strategy value
@@ -217,3 +217,26 @@ func TestNotificationIntervalStrategy(t *testing.T) {
 	testNotifyYamlJSON(t, "increment", IncrementStrategy, true)
 	testNotifyYamlJSON(t, "exponent", ExponentialStrategy, true)
 }
+
+func TestFlapSettings(t *testing.T) {
+	p := ProbeSettings{}
+
+	// disabled by default
+	r := p.NormalizeThreshold(StatusChangeThresholdSettings{FlapWindow: 5})
+	assert.Equal(t, 0, r.FlapThreshold)
+	assert.Equal(t, 0, r.FlapWindow)
+
+	r = p.NormalizeThreshold(StatusChangeThresholdSettings{FlapThreshold: 4})
+	assert.Equal(t, 4, r.FlapThreshold)
+	assert.Equal(t, DefaultFlapWindow, r.FlapWindow)
+
+	p.FlapThreshold = 3
+	p.FlapWindow = 6
+	r = p.NormalizeThreshold(StatusChangeThresholdSettings{})
+	assert.Equal(t, 3, r.FlapThreshold)
+	assert.Equal(t, 6, r.FlapWindow)
+
+	r = p.NormalizeThreshold(StatusChangeThresholdSettings{FlapThreshold: 5, FlapWindow: 20})
+	assert.Equal(t, 5, r.FlapThreshold)
+	assert.Equal(t, 20, r.FlapWindow)
+}
@@ -37,6 +37,7 @@ type DefaultNotify struct {
 	Dry            bool                       `yaml:"dry,omitempty" json:"dry,omitempty" jsonschema:"title=Dry Run,description=If true the notification will not send the message"`
 	Timeout        time.Duration              `yaml:"timeout,omitempty" json:"timeout,omitempty" jsonschema:"format=duration,title=Timeout,description=The timeout of the notification"`
 	Retry          global.Retry               `yaml:"retry,omitempty" json:"retry,omitempty" jsonschema:"title=Retry,description=The retry of the notification"`
+	IgnoreDegraded bool                       `yaml:"ignore_degraded,omitempty" json:"ignore_degraded,omitempty" jsonschema:"title=Ignore Degraded,description=If true the notification will not be sent when the probe becomes degraded or recovers from degraded"`
 }
 
 // Kind returns the kind of the notification
@@ -67,6 +68,11 @@ func (c *DefaultNotify) Name() string {
 	return c.NotifyName
 }
 
+// IsIgnoreDegraded returns true if the degraded status notification need to be ignored
+func (c *DefaultNotify) IsIgnoreDegraded() bool {
+	return c.IgnoreDegraded
+}
+
 // Channels returns the channels of the notification
 func (c *DefaultNotify) Channels() []string {
 	return c.NotifyChannels
@@ -143,7 +143,9 @@ func (c *NotifyConfig) NewDiscord(result probe.Result) Discord {
 
 	// using https://www.spycolor.com/ to pick color
 	color := 1091331 //"#10a703" - green
-	if result.Status != probe.StatusUp {
+	if result.Status == probe.StatusDegraded || result.Status == probe.StatusFlapping {
+		color = 16753920 // "#ffa500" - orange
+	} else if result.Status != probe.StatusUp {
 		color = 10945283 // "#a70303" - red
 	}
 
@@ -60,6 +60,7 @@ type Notify interface {
 	Kind() string
 	Name() string
 	Channels() []string
+	IsIgnoreDegraded() bool
 	Config(global.NotifySettings) error
 	Notify(probe.Result)
 	NotifyStat([]probe.Prober)
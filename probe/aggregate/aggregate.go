@@ -0,0 +1,279 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package aggregate is the aggregate probe package, its status is computed from other probes' results
+package aggregate
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Knetic/govaluate"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/megaease/easeprobe/eval"
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+// Aggregate implements a config for the aggregate probe
+type Aggregate struct {
+	base.DefaultProbe `yaml:",inline"`
+	Probes            []string `yaml:"probes,omitempty" json:"probes,omitempty" jsonschema:"title=Probes,description=The names or the glob patterns of the probes to aggregate,example=api-*"`
+	Quorum            int      `yaml:"quorum,omitempty" json:"quorum,omitempty" jsonschema:"title=Quorum,description=The minimum number of the probes must be up (0 means all of them),default=0"`
+	Expression        string   `yaml:"expression,omitempty" json:"expression,omitempty" jsonschema:"title=Expression,description=The boolean expression over the probes status,example=up('db') && count_up('api-*') >= 2"`
+
+	evaluator  *eval.Evaluator          `yaml:"-" json:"-"`
+	referenced map[string]*probe.Result `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// Config Aggregate Config Object
+func (a *Aggregate) Config(gConf global.ProbeSettings) error {
+	kind := "aggregate"
+	tag := ""
+	name := a.ProbeName
+	endpoint := a.Expression
+	if len(endpoint) <= 0 {
+		endpoint = strings.Join(a.Probes, ", ")
+	}
+	a.DefaultProbe.Config(gConf, kind, tag, name, endpoint, a.DoProbe)
+
+	if len(a.Expression) > 0 && len(a.Probes) > 0 {
+		log.Errorf("[%s / %s] the probes and the expression cannot be configured at the same time", a.ProbeKind, a.ProbeName)
+		return fmt.Errorf("the probes and the expression cannot be configured at the same time")
+	}
+
+	if len(a.Expression) > 0 {
+		a.evaluator = eval.NewEvaluator("", eval.TEXT, a.Expression)
+		a.configEvalFunctions()
+		if _, err := govaluate.NewEvaluableExpressionWithFunctions(a.Expression, a.evaluator.EvalFuncs); err != nil {
+			log.Errorf("[%s / %s] Invalid expression: %s - %v", a.ProbeKind, a.ProbeName, a.Expression, err)
+			return fmt.Errorf("Invalid expression: %s. %v", a.Expression, err)
+		}
+	} else {
+		if len(a.Probes) <= 0 {
+			log.Errorf("[%s / %s] either the probes or the expression must be configured", a.ProbeKind, a.ProbeName)
+			return fmt.Errorf("either the probes or the expression must be configured")
+		}
+		for _, p := range a.Probes {
+			if _, err := path.Match(p, ""); err != nil {
+				log.Errorf("[%s / %s] Invalid probe pattern: %s - %v", a.ProbeKind, a.ProbeName, p, err)
+				return fmt.Errorf("Invalid probe pattern: %s. %v", p, err)
+			}
+		}
+		if a.Quorum < 0 {
+			log.Errorf("[%s / %s] Invalid quorum: %d", a.ProbeKind, a.ProbeName, a.Quorum)
+			return fmt.Errorf("Invalid quorum: %d", a.Quorum)
+		}
+	}
+
+	a.metrics = newMetrics(kind, tag, a.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", a.ProbeKind, a.ProbeName, *a)
+	return nil
+}
+
+// DoProbe return the checking result
+func (a *Aggregate) DoProbe() (bool, string) {
+	if a.evaluator != nil {
+		return a.evaluate()
+	}
+	return a.quorum()
+}
+
+// quorum checks at least `quorum` probes of the matched probes are up.
+// The probes which have no result yet (e.g. just started) are pending, they are not counted as down,
+// so the aggregate probe is down only if the quorum cannot be reached even if all of them are up.
+func (a *Aggregate) quorum() (bool, string) {
+	results := a.match(a.Probes...)
+	up := 0
+	pending := 0
+	members := []string{}
+	for _, r := range results {
+		if r.Status.IsAvailable() {
+			up++
+		} else if r.Status == probe.StatusInit {
+			pending++
+		}
+		members = append(members, r.Name+" "+r.Status.Emoji())
+	}
+	a.ExportMetrics(up, len(results))
+
+	if len(results) <= 0 {
+		return false, fmt.Sprintf("Error: no probe matches %v", a.Probes)
+	}
+
+	quorum := a.Quorum
+	if quorum <= 0 || quorum > len(results) {
+		quorum = len(results)
+	}
+	message := fmt.Sprintf("%d of %d probes are up", up, len(results))
+	if pending > 0 {
+		message += fmt.Sprintf(", %d pending", pending)
+	}
+	message += fmt.Sprintf(", quorum is %d [%s]", quorum, strings.Join(members, ", "))
+	if up+pending < quorum {
+		return false, "Error: " + message
+	}
+	return true, message
+}
+
+// evaluate the expression with the status of the probes.
+// If the expression is false but some of the referenced probes have no result yet (e.g. just started),
+// the result is pending and the probe is not down.
+func (a *Aggregate) evaluate() (bool, string) {
+	a.referenced = map[string]*probe.Result{}
+	result, err := a.evaluator.Evaluate()
+
+	members := []*probe.Result{}
+	pending := []string{}
+	for _, r := range a.referenced {
+		members = append(members, r)
+		if r.Status == probe.StatusInit {
+			pending = append(pending, r.Name)
+		}
+	}
+	sort.Strings(pending)
+	a.ExportMetrics(a.countUp(members), len(members))
+
+	if err != nil {
+		log.Errorf("[%s / %s] Expression [%s] error: %v", a.ProbeKind, a.ProbeName, a.Expression, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+	if !result && len(pending) > 0 {
+		return true, fmt.Sprintf("The expression [%s] is pending, waiting for the probes [%s]",
+			a.Expression, strings.Join(pending, ", "))
+	}
+	if !result {
+		return false, fmt.Sprintf("Error: the expression [%s] is false", a.Expression)
+	}
+	return true, fmt.Sprintf("The expression [%s] is true", a.Expression)
+}
+
+// reference records the probes referenced by the expression
+func (a *Aggregate) reference(results ...*probe.Result) []*probe.Result {
+	if a.referenced != nil {
+		for _, r := range results {
+			a.referenced[r.Name] = r
+		}
+	}
+	return results
+}
+
+// match returns the results of the probes whose names match the patterns, the aggregate probe itself is excluded
+func (a *Aggregate) match(patterns ...string) []*probe.Result {
+	results := []*probe.Result{}
+	for _, name := range probe.GetResultNames() {
+		if name == a.ProbeName {
+			continue
+		}
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok {
+				if r := probe.GetResultData(name); r != nil {
+					results = append(results, r)
+				}
+				break
+			}
+		}
+	}
+	return results
+}
+
+func (a *Aggregate) countUp(results []*probe.Result) int {
+	up := 0
+	for _, r := range results {
+		if r.Status.IsAvailable() {
+			up++
+		}
+	}
+	return up
+}
+
+// configEvalFunctions adds the probe status functions into the evaluator
+func (a *Aggregate) configEvalFunctions() {
+	result := func(args ...interface{}) (*probe.Result, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("the function requires one probe name")
+		}
+		name := fmt.Sprintf("%v", args[0])
+		r := probe.GetResultData(name)
+		if r == nil {
+			return nil, fmt.Errorf("the probe [%s] is not found", name)
+		}
+		a.reference(r)
+		return r, nil
+	}
+	pattern := func(args ...interface{}) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("the function requires one probe name pattern")
+		}
+		return fmt.Sprintf("%v", args[0]), nil
+	}
+
+	// up('name') - whether the probe is up (or degraded)
+	a.evaluator.EvalFuncs["up"] = func(args ...interface{}) (interface{}, error) {
+		r, err := result(args...)
+		if err != nil {
+			return false, err
+		}
+		return r.Status.IsAvailable(), nil
+	}
+	// status('name') - the status string of the probe, such as "up", "down", "degraded"
+	a.evaluator.EvalFuncs["status"] = func(args ...interface{}) (interface{}, error) {
+		r, err := result(args...)
+		if err != nil {
+			return "", err
+		}
+		return r.Status.String(), nil
+	}
+	// count('pattern') - the number of the probes match the pattern
+	a.evaluator.EvalFuncs["count"] = func(args ...interface{}) (interface{}, error) {
+		p, err := pattern(args...)
+		if err != nil {
+			return 0.0, err
+		}
+		return float64(len(a.reference(a.match(p)...))), nil
+	}
+	// count_up('pattern') - the number of the up (or degraded) probes match the pattern
+	a.evaluator.EvalFuncs["count_up"] = func(args ...interface{}) (interface{}, error) {
+		p, err := pattern(args...)
+		if err != nil {
+			return 0.0, err
+		}
+		return float64(a.countUp(a.reference(a.match(p)...))), nil
+	}
+}
+
+// ExportMetrics export aggregate metrics
+func (a *Aggregate) ExportMetrics(up, total int) {
+	a.metrics.UpMembers.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     a.ProbeName,
+		"endpoint": a.ProbeResult.Endpoint,
+	}, a.Labels)).Set(float64(up))
+
+	a.metrics.Members.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     a.ProbeName,
+		"endpoint": a.ProbeResult.Endpoint,
+	}, a.Labels)).Set(float64(total))
+}
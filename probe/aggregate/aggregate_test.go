@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregate
+
+import (
+	"testing"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/base"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func setResult(name string, s probe.Status) {
+	r := probe.NewResult()
+	r.Name = name
+	r.Status = s
+	probe.SetResultData(name, r)
+}
+
+func newAggregate(name string, probes []string, quorum int, exp string) *Aggregate {
+	return &Aggregate{
+		DefaultProbe: base.DefaultProbe{ProbeName: name},
+		Probes:       probes,
+		Quorum:       quorum,
+		Expression:   exp,
+	}
+}
+
+func TestAggregateConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	a := newAggregate("agg", []string{"api-*"}, 2, "")
+	assert.Nil(t, a.Config(global.ProbeSettings{}))
+	assert.Equal(t, "aggregate", a.ProbeKind)
+	assert.Equal(t, "api-*", a.ProbeResult.Endpoint)
+
+	a = newAggregate("agg", nil, 0, "")
+	assert.NotNil(t, a.Config(global.ProbeSettings{}))
+
+	a = newAggregate("agg", []string{"api-*"}, 0, "up('db')")
+	assert.NotNil(t, a.Config(global.ProbeSettings{}))
+
+	a = newAggregate("agg", []string{"[api"}, 0, "")
+	assert.NotNil(t, a.Config(global.ProbeSettings{}))
+
+	a = newAggregate("agg", []string{"api-*"}, -1, "")
+	assert.NotNil(t, a.Config(global.ProbeSettings{}))
+
+	a = newAggregate("agg", nil, 0, "up('db') &&")
+	assert.NotNil(t, a.Config(global.ProbeSettings{}))
+
+	a = newAggregate("agg", nil, 0, "up('db') && count_up('api-*') >= 2")
+	assert.Nil(t, a.Config(global.ProbeSettings{}))
+	assert.Equal(t, "up('db') && count_up('api-*') >= 2", a.ProbeResult.Endpoint)
+}
+
+func TestAggregateQuorum(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	setResult("api-1", probe.StatusUp)
+	setResult("api-2", probe.StatusDegraded)
+	setResult("api-3", probe.StatusDown)
+	setResult("db", probe.StatusUp)
+
+	a := newAggregate("api-all", []string{"api-*"}, 2, "")
+	assert.Nil(t, a.Config(global.ProbeSettings{}))
+	setResult("api-all", probe.StatusDown) // itself is excluded
+
+	s, m := a.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "2 of 3 probes are up, quorum is 2")
+
+	a.Quorum = 0
+	s, m = a.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "2 of 3 probes are up, quorum is 3")
+
+	a.Quorum = 1
+	a.Probes = []string{"db", "api-3"}
+	s, m = a.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "1 of 2 probes are up")
+
+	a.Probes = []string{"none-*"}
+	s, m = a.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "no probe matches")
+
+	// the status is computed by the probe pipeline
+	a.Probes = []string{"api-*"}
+	a.Quorum = 2
+	a.Probe()
+	assert.Equal(t, probe.StatusUp, a.Result().Status)
+	setResult("api-2", probe.StatusDown)
+	a.Probe()
+	assert.Equal(t, probe.StatusDown, a.Result().Status)
+}
+
+func TestAggregateExpression(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	setResult("web-1", probe.StatusUp)
+	setResult("web-2", probe.StatusDown)
+	setResult("cache", probe.StatusDegraded)
+
+	a := newAggregate("web-agg", nil, 0, "up('cache') && count_up('web-*') >= 1")
+	assert.Nil(t, a.Config(global.ProbeSettings{}))
+	s, m := a.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "is true")
+
+	a.evaluator.Expression = "count_up('web-*') == count('web-*')"
+	s, m = a.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "is false")
+
+	a.evaluator.Expression = "status('web-2') == 'down' && status('cache') == 'degraded'"
+	s, _ = a.DoProbe()
+	assert.True(t, s)
+
+	a.evaluator.Expression = "up('not-exist')"
+	s, m = a.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "the probe [not-exist] is not found")
+}
+
+func TestAggregatePending(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	setResult("job-1", probe.StatusInit)
+	setResult("job-2", probe.StatusInit)
+	setResult("job-3", probe.StatusInit)
+
+	a := newAggregate("jobs", []string{"job-*"}, 0, "")
+	assert.Nil(t, a.Config(global.ProbeSettings{}))
+
+	// no probe has the result after restart
+	s, m := a.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "0 of 3 probes are up, 3 pending, quorum is 3")
+	a.Probe()
+	assert.Equal(t, probe.StatusUp, a.Result().Status)
+
+	setResult("job-1", probe.StatusUp)
+	s, m = a.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "1 of 3 probes are up, 2 pending")
+
+	// the quorum cannot be reached even if the pending probes are up
+	setResult("job-2", probe.StatusDown)
+	s, m = a.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error: 1 of 3 probes are up, 1 pending, quorum is 3")
+
+	a.Quorum = 2
+	s, _ = a.DoProbe()
+	assert.True(t, s)
+
+	// expression
+	e := newAggregate("job-exp", nil, 0, "up('job-1') && count_up('job-*') == 3")
+	assert.Nil(t, e.Config(global.ProbeSettings{}))
+	s, m = e.DoProbe()
+	assert.True(t, s)
+	assert.Equal(t, "The expression [up('job-1') && count_up('job-*') == 3] is pending, waiting for the probes [job-3]", m)
+
+	setResult("job-3", probe.StatusUp)
+	s, m = e.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "is false")
+}
+
+func TestAggregateExpressionMetrics(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	setResult("svc-a", probe.StatusUp)
+	setResult("svc-b", probe.StatusDown)
+	setResult("other", probe.StatusUp)
+
+	a := newAggregate("svc-exp", nil, 0, "up('svc-a') || count_up('svc-*') > 0")
+	assert.Nil(t, a.Config(global.ProbeSettings{}))
+	a.evaluator.Expression = "count_up('svc-*') >= 1"
+	s, _ := a.DoProbe()
+	assert.True(t, s)
+
+	// only the referenced probes are exported
+	labels := prometheus.Labels{"name": "svc-exp", "endpoint": a.ProbeResult.Endpoint}
+	assert.Equal(t, 1.0, testutil.ToFloat64(a.metrics.UpMembers.With(labels)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(a.metrics.Members.With(labels)))
+}
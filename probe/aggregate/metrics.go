@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aggregate
+
+import (
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics is the metrics for aggregate probe
+type metrics struct {
+	UpMembers *prometheus.GaugeVec
+	Members   *prometheus.GaugeVec
+}
+
+// newMetrics create the aggregate metrics
+func newMetrics(subsystem, name string, constLabels prometheus.Labels) *metrics {
+	namespace := global.GetEaseProbe().Name
+	return &metrics{
+		UpMembers: metric.NewGauge(namespace, subsystem, name, "up_members",
+			"The number of the aggregated probes which are up", []string{"name", "endpoint"}, constLabels),
+		Members: metric.NewGauge(namespace, subsystem, name, "members",
+			"The number of the aggregated probes", []string{"name", "endpoint"}, constLabels),
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -33,12 +34,14 @@ import (
 	"github.com/megaease/easeprobe/global"
 	"github.com/megaease/easeprobe/metric"
 	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/maintenance"
 )
 
 // Probe Simple Status
 const (
-	ServiceUp   int = 1
-	ServiceDown int = 0
+	ServiceUp       int = 1
+	ServiceDown     int = 0
+	ServiceDegraded int = 2
 )
 
 // ProbeFuncType is the probe function type
@@ -53,11 +56,13 @@ type DefaultProbe struct {
 	ProbeTimeout                         time.Duration     `yaml:"timeout,omitempty" json:"timeout,omitempty" jsonschema:"type=string,format=duration,title=Probe Timeout,description=the timeout of probe"`
 	ProbeTimeInterval                    time.Duration     `yaml:"interval,omitempty" json:"interval,omitempty" jsonschema:"type=string,format=duration,title=Probe Interval,description=the interval of probe"`
 	Labels                               prometheus.Labels `yaml:"labels,omitempty" json:"labels,omitempty" jsonschema:"title=Probe LabelMap,description=the labels of probe"`
+	DependsOn                            []string          `yaml:"depends_on,omitempty" json:"depends_on,omitempty" jsonschema:"title=Depends On,description=the names of the probes this probe depends on"`
 	global.StatusChangeThresholdSettings `yaml:",inline" json:",inline"`
 	global.NotificationStrategySettings  `yaml:"alert" json:"alert" jsonschema:"title=Probe Alert,description=the alert strategy of probe"`
 	ProbeFunc                            ProbeFuncType `yaml:"-" json:"-"`
 	ProbeResult                          *probe.Result `yaml:"-" json:"-"`
 	metrics                              *metrics      `yaml:"-" json:"-"`
+	degraded                             []string      `yaml:"-" json:"-"`
 }
 
 // LabelMap return the const metric labels  for a probe in the configuration.
@@ -110,6 +115,27 @@ func (d *DefaultProbe) LogTitle() string {
 	return fmt.Sprintf("[%s / %s]", d.ProbeKind, d.ProbeName)
 }
 
+// Dependencies return the names of the probes this probe depends on
+func (d *DefaultProbe) Dependencies() []string {
+	return d.DependsOn
+}
+
+// DownDependency return the first dependency which is down, empty if all of the dependencies are fine
+func (d *DefaultProbe) DownDependency() (string, probe.Status) {
+	for _, name := range d.DependsOn {
+		if r := probe.GetResultData(name); r != nil && r.Status.IsDown() {
+			return name, r.Status
+		}
+	}
+	return "", probe.StatusInit
+}
+
+// Degrade marks the current probe round as degraded with the reason.
+// The probe function calls it when the probe succeeds but breaches a soft threshold.
+func (d *DefaultProbe) Degrade(reason string) {
+	d.degraded = append(d.degraded, reason)
+}
+
 // CheckStatusThreshold check the status threshold
 func (d *DefaultProbe) CheckStatusThreshold() probe.Status {
 	s := d.StatusChangeThresholdSettings
@@ -142,6 +168,28 @@ func (d *DefaultProbe) CheckStatusThreshold() probe.Status {
 	return d.ProbeResult.PreStatus
 }
 
+// CheckFlapping check the status changes in the flapping window.
+// The probe starts flapping if the changes reach the threshold,
+// and it keeps flapping until the changes drop below the half of the threshold.
+func (d *DefaultProbe) CheckFlapping() (int, bool) {
+	if d.FlapThreshold <= 0 {
+		return 0, false
+	}
+	changes := d.ProbeResult.Stat.StatusCounter.StatusChanges(d.FlapWindow)
+	if d.ProbeResult.Status == probe.StatusFlapping {
+		if changes*2 >= d.FlapThreshold {
+			return changes, true
+		}
+		log.Infof("%s - Status is settled! [%d] status changes in the last [%d] probes", d.LogTitle(), changes, d.FlapWindow)
+		return changes, false
+	}
+	if changes >= d.FlapThreshold {
+		log.Infof("%s - Status is FLAPPING! [%d] status changes in the last [%d] probes", d.LogTitle(), changes, d.FlapWindow)
+		return changes, true
+	}
+	return changes, false
+}
+
 // Config default config
 func (d *DefaultProbe) Config(gConf global.ProbeSettings,
 	kind, tag, name, endpoint string, fn ProbeFuncType) error {
@@ -165,11 +213,21 @@ func (d *DefaultProbe) Config(gConf global.ProbeSettings,
 	d.ProbeResult.Stat.NotificationStrategyData.Factor = d.NotificationStrategySettings.Factor
 	d.ProbeResult.Stat.NotificationStrategyData.MaxTimes = d.NotificationStrategySettings.MaxTimes
 
+	// the status changes in the window are always less than the window size
+	if d.FlapThreshold > 0 && d.FlapWindow <= d.FlapThreshold {
+		log.Warnf("Probe %s flapping window [%d] must be greater than the flapping threshold [%d], use [%d] instead",
+			d.LogTitle(), d.FlapWindow, d.FlapThreshold, d.FlapThreshold+1)
+		d.FlapWindow = d.FlapThreshold + 1
+	}
+
 	// Set the new length of the status counter
 	maxLen := d.StatusChangeThresholdSettings.Failure
 	if d.StatusChangeThresholdSettings.Success > maxLen {
 		maxLen = d.StatusChangeThresholdSettings.Success
 	}
+	if d.StatusChangeThresholdSettings.FlapWindow > maxLen {
+		maxLen = d.StatusChangeThresholdSettings.FlapWindow
+	}
 	d.ProbeResult.Stat.StatusCounter.SetMaxLen(maxLen)
 
 	// if there no channels, use the default channel
@@ -182,6 +240,9 @@ func (d *DefaultProbe) Config(gConf global.ProbeSettings,
 	if d.Failure > 1 || d.Success > 1 {
 		log.Infof("Probe %s Status Threshold are configured! failure[%d], success[%d]", d.LogTitle(), d.Failure, d.Success)
 	}
+	if d.FlapThreshold > 0 {
+		log.Infof("Probe %s Flapping Detection is configured! threshold[%d], window[%d]", d.LogTitle(), d.FlapThreshold, d.FlapWindow)
+	}
 
 	d.metrics = newMetrics(kind, tag, d.Labels)
 
@@ -198,6 +259,7 @@ func (d *DefaultProbe) Probe() probe.Result {
 	d.ProbeResult.StartTime = now
 	d.ProbeResult.StartTimestamp = now.UnixMilli()
 
+	d.degraded = nil
 	stat, msg := d.ProbeFunc()
 
 	d.ProbeResult.RoundTripTime = time.Since(now)
@@ -205,11 +267,51 @@ func (d *DefaultProbe) Probe() probe.Result {
 	// check the status threshold
 	d.ProbeResult.Stat.StatusCounter.AppendStatus(stat, msg)
 	status := d.CheckStatusThreshold()
+
+	// check the flapping, it overrides the status threshold
+	if changes, flapping := d.CheckFlapping(); flapping {
+		status = probe.StatusFlapping
+		msg = fmt.Sprintf("%s (Flapping: %d status changes in the last %d probes)", msg, changes, d.FlapWindow)
+	} else if status == probe.StatusFlapping {
+		// the flapping is settled, but the status threshold is not reached yet
+		status = probe.StatusDown
+		if d.ProbeResult.Stat.StatusCounter.CurrentStatus {
+			status = probe.StatusUp
+		}
+	}
+
+	// the probe succeeded but breached the soft thresholds
+	if stat && status == probe.StatusUp && len(d.degraded) > 0 {
+		log.Infof("%s - Status is DEGRADED! %s", d.LogTitle(), strings.Join(d.degraded, ", "))
+		status = probe.StatusDegraded
+		msg = fmt.Sprintf("%s (Degraded: %s)", msg, strings.Join(d.degraded, ", "))
+	}
+
+	// the probe failed because the dependency is down
+	if status == probe.StatusDown || status == probe.StatusUnreachable {
+		status = probe.StatusDown
+		if dep, s := d.DownDependency(); len(dep) > 0 {
+			log.Infof("%s - Status is UNREACHABLE! The dependency [%s] is %s", d.LogTitle(), dep, s)
+			status = probe.StatusUnreachable
+			msg = fmt.Sprintf("%s (Unreachable: the dependency [%s] is %s)", msg, dep, s)
+		}
+	}
 	title := status.Title()
 
+	// check the maintenance window
+	d.ProbeResult.Maintenance = ""
+	if w := maintenance.Find(d.ProbeName, d.Labels, now); w != nil {
+		log.Debugf("%s - In the maintenance window [%s]", d.LogTitle(), w.Name)
+		d.ProbeResult.Maintenance = w.Name
+	}
+
 	// process the notification strategy
-	if status != probe.StatusInit {
-		d.ProbeResult.Stat.NotificationStrategyData.ProcessStatus(status == probe.StatusUp)
+	if d.ProbeResult.IsInMaintenance() || status == probe.StatusUnreachable || status == probe.StatusFlapping {
+		// the failures in the maintenance window, caused by the dependency or flapping are not counted,
+		// so the alert would be sent if the service is still down after that
+		d.ProbeResult.Stat.NotificationStrategyData.Reset()
+	} else if status != probe.StatusInit {
+		d.ProbeResult.Stat.NotificationStrategyData.ProcessStatus(status.IsAvailable())
 	}
 
 	if len(d.ProbeTag) > 0 {
@@ -238,10 +340,15 @@ func (d *DefaultProbe) ExportMetrics() {
 	cnt := int64(0)
 	time := time.Duration(0)
 
-	if d.ProbeResult.Status == probe.StatusUp {
+	// the degraded time is a part of the uptime, so the up and the degraded are exported separately
+	switch d.ProbeResult.Status {
+	case probe.StatusUp:
 		cnt = d.ProbeResult.Stat.Status[probe.StatusUp]
-		time = d.ProbeResult.Stat.UpTime
-	} else {
+		time = d.ProbeResult.Stat.UpTime - d.ProbeResult.Stat.DegradedTime
+	case probe.StatusDegraded:
+		cnt = d.ProbeResult.Stat.Status[probe.StatusDegraded]
+		time = d.ProbeResult.Stat.DegradedTime
+	default:
 		cnt = d.ProbeResult.Stat.Status[probe.StatusDown]
 		time = d.ProbeResult.Stat.DownTime
 	}
@@ -265,9 +372,12 @@ func (d *DefaultProbe) ExportMetrics() {
 		"endpoint": d.ProbeResult.Endpoint,
 	}, d.Labels)).Set(float64(d.ProbeResult.RoundTripTime.Milliseconds()))
 
-	status := ServiceUp // up
-	if d.ProbeResult.Status != probe.StatusUp {
-		status = ServiceDown // down
+	status := ServiceDown // down
+	switch d.ProbeResult.Status {
+	case probe.StatusUp:
+		status = ServiceUp // up
+	case probe.StatusDegraded:
+		status = ServiceDegraded // degraded
 	}
 	d.metrics.Status.With(metric.AddConstLabels(prometheus.Labels{
 		"name":     d.ProbeName,
@@ -288,8 +398,8 @@ func (d *DefaultProbe) DownTimeCalculation(status probe.Status) {
 		d.ProbeResult.LatestDownTime = time.Now().UTC()
 	}
 
-	// Status from DOWN to UP - Recovery
-	if d.ProbeResult.PreStatus == probe.StatusDown && status == probe.StatusUp {
+	// Status from DOWN to UP or DEGRADED - Recovery
+	if d.ProbeResult.PreStatus == probe.StatusDown && status.IsAvailable() {
 		d.ProbeResult.RecoveryDuration = time.Since(d.ProbeResult.LatestDownTime)
 	}
 }
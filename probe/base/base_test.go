@@ -27,11 +27,14 @@ import (
 	"time"
 
 	"github.com/megaease/easeprobe/monkey"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/proxy"
 
 	"github.com/megaease/easeprobe/global"
 	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/maintenance"
 )
 
 var (
@@ -240,3 +243,208 @@ func TestStatusThreshold2(t *testing.T) {
 	p.Probe()
 	assert.Equal(t, probe.StatusUp, p.Result().Status)
 }
+
+func TestDegraded(t *testing.T) {
+	p := newDummyProber("probe")
+	p.Config(global.ProbeSettings{})
+
+	degraded := true
+	p.ProbeFunc = func() (bool, string) {
+		if degraded {
+			p.Degrade("latency 2s > 1s")
+		}
+		return true, "success"
+	}
+	p.Probe()
+	assert.Equal(t, probe.StatusDegraded, p.Result().Status)
+	assert.Contains(t, p.Result().Message, "Degraded (dummy/tag): success (Degraded: latency 2s > 1s)")
+	assert.Equal(t, int64(1), p.Result().Stat.Status[probe.StatusDegraded])
+	assert.Equal(t, global.DefaultProbeInterval, p.Result().Stat.UpTime)
+	assert.Equal(t, float64(100), p.Result().SLAPercent())
+
+	// the reasons are not accumulated
+	p.Probe()
+	assert.Equal(t, probe.StatusDegraded, p.Result().Status)
+	assert.Equal(t, probe.StatusDegraded, p.Result().PreStatus)
+	assert.NotContains(t, p.Result().Message, "1s, latency")
+
+	// the count and the time of the degraded status are on the same basis
+	interval := global.DefaultProbeInterval.Seconds()
+	labels := prometheus.Labels{"name": "probe", "status": "degraded", "endpoint": "endpoint"}
+	assert.Equal(t, float64(2), testutil.ToFloat64(p.metrics.TotalCnt.With(labels)))
+	assert.Equal(t, 2*interval, testutil.ToFloat64(p.metrics.TotalTime.With(labels)))
+
+	degraded = false
+	p.Probe()
+	assert.Equal(t, probe.StatusUp, p.Result().Status)
+	assert.Equal(t, probe.StatusDegraded, p.Result().PreStatus)
+	assert.Equal(t, 3*global.DefaultProbeInterval, p.Result().Stat.UpTime)
+	assert.Equal(t, 2*global.DefaultProbeInterval, p.Result().Stat.DegradedTime)
+	labels["status"] = "up"
+	assert.Equal(t, float64(1), testutil.ToFloat64(p.metrics.TotalCnt.With(labels)))
+	assert.Equal(t, interval, testutil.ToFloat64(p.metrics.TotalTime.With(labels)))
+
+	// the failed probe is down even if it is degraded
+	p.ProbeFunc = func() (bool, string) {
+		p.Degrade("slow")
+		return false, "failure"
+	}
+	p.Probe()
+	assert.Equal(t, probe.StatusDown, p.Result().Status)
+
+	// recover from down to degraded
+	degraded = true
+	p.ProbeFunc = func() (bool, string) {
+		p.Degrade("slow")
+		return true, "success"
+	}
+	p.Probe()
+	assert.Equal(t, probe.StatusDegraded, p.Result().Status)
+	assert.NotZero(t, p.Result().RecoveryDuration)
+	assert.Equal(t, 0, p.Result().Stat.NotificationStrategyData.Failed)
+}
+
+func TestMaintenance(t *testing.T) {
+	global.InitEaseProbeWithTime("easeprobe", "icon", global.DefaultTimeFormat, "UTC")
+	maintenance.SetWindows([]maintenance.Window{{
+		Name:   "upgrade",
+		Start:  time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+		End:    time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		Probes: []string{"maintenance"},
+	}})
+	defer maintenance.SetWindows(nil)
+
+	p := newDummyProber("maintenance")
+	p.Config(global.ProbeSettings{})
+	p.ProbeFunc = func() (bool, string) {
+		return false, "failure"
+	}
+	for i := 0; i < 3; i++ {
+		p.Probe()
+		assert.Equal(t, probe.StatusDown, p.Result().Status)
+		assert.Equal(t, "upgrade", p.Result().Maintenance)
+		assert.False(t, p.Result().Stat.NotificationStrategyData.NeedToSendNotification())
+	}
+	assert.Equal(t, time.Duration(0), p.Result().Stat.DownTime)
+	assert.Equal(t, 3*global.DefaultProbeInterval, p.Result().Stat.MaintTime)
+
+	// the window is over, the alert is sent if it is still down
+	maintenance.SetWindows(nil)
+	p.Probe()
+	assert.Empty(t, p.Result().Maintenance)
+	assert.True(t, p.Result().Stat.NotificationStrategyData.NeedToSendNotification())
+	assert.Equal(t, global.DefaultProbeInterval, p.Result().Stat.DownTime)
+
+	// the other probe is not in the window
+	q := newDummyProber("other")
+	q.Config(global.ProbeSettings{})
+	q.Probe()
+	assert.Empty(t, q.Result().Maintenance)
+}
+
+func TestDependency(t *testing.T) {
+	parent := newDummyProber("parent")
+	parent.Config(global.ProbeSettings{})
+	child := newDummyProber("child")
+	child.DependsOn = []string{"parent"}
+	child.Config(global.ProbeSettings{})
+	assert.Equal(t, []string{"parent"}, child.Dependencies())
+
+	parentUp, childUp := true, true
+	parent.ProbeFunc = func() (bool, string) { return parentUp, "parent" }
+	// the result data is updated by the saving goroutine after each probe
+	probeParent := func() {
+		parent.Probe()
+		probe.SetResultData(parent.Name(), parent.Result())
+	}
+	child.ProbeFunc = func() (bool, string) { return childUp, "child" }
+
+	probeParent()
+	child.Probe()
+	assert.Equal(t, probe.StatusUp, child.Result().Status)
+
+	// the child is down, but the parent is up
+	childUp = false
+	child.Probe()
+	assert.Equal(t, probe.StatusDown, child.Result().Status)
+	assert.True(t, child.Result().Stat.NotificationStrategyData.NeedToSendNotification())
+
+	// both of them are down
+	parentUp = false
+	probeParent()
+	child.Probe()
+	assert.Equal(t, probe.StatusUnreachable, child.Result().Status)
+	assert.Contains(t, child.Result().Message, "Unreachable (dummy/tag): child (Unreachable: the dependency [parent] is down)")
+	assert.False(t, child.Result().Stat.NotificationStrategyData.NeedToSendNotification())
+
+	// the parent recovers, but the child is still down
+	parentUp = true
+	probeParent()
+	child.Probe()
+	assert.Equal(t, probe.StatusDown, child.Result().Status)
+	assert.Equal(t, probe.StatusUnreachable, child.Result().PreStatus)
+	assert.True(t, child.Result().Stat.NotificationStrategyData.NeedToSendNotification())
+
+	// the success probe is up even if the parent is down
+	parentUp = false
+	childUp = true
+	probeParent()
+	child.Probe()
+	assert.Equal(t, probe.StatusUp, child.Result().Status)
+
+	// unknown dependency is ignored
+	child.DependsOn = []string{"not-exist"}
+	dep, _ := child.DownDependency()
+	assert.Empty(t, dep)
+}
+
+func TestFlapping(t *testing.T) {
+	p := newDummyProber("flapping")
+	p.FlapThreshold = 4
+	p.FlapWindow = 3 // less than the threshold, would be fixed
+	p.Config(global.ProbeSettings{})
+	assert.Equal(t, 5, p.FlapWindow)
+	assert.Equal(t, 5, p.ProbeResult.Stat.MaxLen)
+
+	up := true
+	p.ProbeFunc = func() (bool, string) {
+		return up, "bouncing"
+	}
+	expected := []probe.Status{
+		probe.StatusUp,       // 0 change
+		probe.StatusDown,     // 1 change
+		probe.StatusUp,       // 2 changes
+		probe.StatusDown,     // 3 changes
+		probe.StatusFlapping, // 4 changes
+		probe.StatusFlapping, // 4 changes
+	}
+	for i, s := range expected {
+		p.Probe()
+		assert.Equal(t, s, p.Result().Status, "probe #%d", i+1)
+		up = !up
+	}
+	assert.Contains(t, p.Result().Message, "Flapping (dummy/tag): bouncing (Flapping: 4 status changes in the last 5 probes)")
+	assert.False(t, p.Result().Stat.NotificationStrategyData.NeedToSendNotification())
+
+	// keep flapping until the changes drop below the half of the threshold
+	up = true
+	p.Probe() // T F T F T - 4 changes
+	assert.Equal(t, probe.StatusFlapping, p.Result().Status)
+	p.Probe() // F T F T T - 3 changes
+	assert.Equal(t, probe.StatusFlapping, p.Result().Status)
+	p.Probe() // T F T T T - 2 changes
+	assert.Equal(t, probe.StatusFlapping, p.Result().Status)
+	p.Probe() // F T T T T - 1 change
+	assert.Equal(t, probe.StatusUp, p.Result().Status)
+	assert.Equal(t, probe.StatusFlapping, p.Result().PreStatus)
+
+	// disabled
+	q := newDummyProber("not-flapping")
+	q.Config(global.ProbeSettings{})
+	q.ProbeFunc = p.ProbeFunc
+	for i := 0; i < 10; i++ {
+		up = !up
+		q.Probe()
+		assert.NotEqual(t, probe.StatusFlapping, q.Result().Status)
+	}
+}
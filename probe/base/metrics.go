@@ -43,7 +43,7 @@ func newMetrics(subsystem, name string, constLabels prometheus.Labels) *metrics
 		Duration: metric.NewGauge(namespace, subsystem, name, "duration",
 			"Probe Duration", []string{"name", "status", "endpoint"}, constLabels),
 		Status: metric.NewGauge(namespace, subsystem, name, "status",
-			"Probe Status", []string{"name", "endpoint"}, constLabels),
+			"Probe Status (1: up, 2: degraded, 0: others)", []string{"name", "endpoint"}, constLabels),
 		SLA: metric.NewGauge(namespace, subsystem, name, "sla",
 			"Probe SLA", []string{"name", "endpoint"}, constLabels),
 	}
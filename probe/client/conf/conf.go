@@ -22,7 +22,9 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 
+	"github.com/megaease/easeprobe/eval"
 	"github.com/megaease/easeprobe/global"
 	"github.com/megaease/easeprobe/probe/base"
 )
@@ -70,6 +72,23 @@ type Options struct {
 	Password   string            `yaml:"password,omitempty" json:"password,omitempty" jsonschema:"title=Password,description=The password of the client,example=123456"`
 	Data       map[string]string `yaml:"data,omitempty" json:"data,omitempty" jsonschema:"title=Data,description=The data of the client,example={\"key\":\"value\"}"`
 
+	// Query - the read-only query whose result is evaluated
+	Database  string         `yaml:"database,omitempty" json:"database,omitempty" jsonschema:"title=Database,description=The database which the query runs on,example=test"`
+	Query     string         `yaml:"query,omitempty" json:"query,omitempty" jsonschema:"title=Query,description=The read-only query whose result is evaluated,example=SELECT COUNT(*) AS n FROM failed_jobs"`
+	Evaluator eval.Evaluator `yaml:"eval,omitempty" json:"eval,omitempty" jsonschema:"title=Query Evaluator,description=The expression to evaluate the query result"`
+
+	// Replication - check the replication health of the database
+	Replication Replication `yaml:"replication,omitempty" json:"replication,omitempty" jsonschema:"title=Replication,description=Check the replication health of the database"`
+
+	// Redis - the Redis specific configuration
+	Redis RedisOptions `yaml:"redis,omitempty" json:"redis,omitempty" jsonschema:"title=Redis,description=The Redis specific configuration"`
+
+	// Kafka - the Kafka specific configuration
+	Kafka KafkaOptions `yaml:"kafka,omitempty" json:"kafka,omitempty" jsonschema:"title=Kafka,description=The Kafka specific configuration"`
+
+	// Mongo - the MongoDB specific configuration
+	Mongo MongoOptions `yaml:"mongo,omitempty" json:"mongo,omitempty" jsonschema:"title=MongoDB,description=The MongoDB specific configuration"`
+
 	//TLS
 	global.TLS `yaml:",inline"`
 }
@@ -86,6 +105,45 @@ func (d *Options) Check() error {
 	if d.DriverType == Unknown {
 		return fmt.Errorf("Unknown driver")
 	}
+	return d.checkDriverOptions()
+}
+
+// driverOptions is the options which are only supported by some of the drivers
+var driverOptions = []struct {
+	name    string
+	drivers []DriverType
+	isSet   func(d *Options) bool
+}{
+	{"database", []DriverType{MySQL, PostgreSQL, Mongo}, func(d *Options) bool { return len(strings.TrimSpace(d.Database)) > 0 }},
+	{"query", []DriverType{MySQL, PostgreSQL, Mongo}, func(d *Options) bool { return d.HasQuery() }},
+	{"eval", []DriverType{MySQL, PostgreSQL, Mongo}, func(d *Options) bool { return len(strings.TrimSpace(d.Evaluator.Expression)) > 0 }},
+	{"replication", []DriverType{MySQL, PostgreSQL, Mongo}, func(d *Options) bool { return d.Replication != Replication{} }},
+	{"redis", []DriverType{Redis}, func(d *Options) bool { return d.Redis != RedisOptions{} }},
+	{"kafka", []DriverType{Kafka}, func(d *Options) bool {
+		k := d.Kafka
+		return len(k.Mechanism) > 0 || len(k.Topics) > 0 || len(k.ConsumerGroups) > 0 || k.MaxLag != 0
+	}},
+	{"mongo", []DriverType{Mongo}, func(d *Options) bool { return d.Mongo != MongoOptions{} }},
+}
+
+// checkDriverOptions checks the driver specific options are not set for the other drivers,
+// otherwise they are ignored silently
+func (d *Options) checkDriverOptions() error {
+	for _, o := range driverOptions {
+		if !o.isSet(d) {
+			continue
+		}
+		supported := false
+		for _, driver := range o.drivers {
+			if d.DriverType == driver {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("the %s option is not supported by the %s driver", o.name, d.DriverType)
+		}
+	}
 	return nil
 }
 
@@ -142,3 +142,34 @@ func TestOptionsCheck(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Invalid Port")
 }
+
+func TestDriverOptionsCheck(t *testing.T) {
+	opts := Options{Host: "localhost:6379", DriverType: Redis, Query: "SELECT 1"}
+	assert.ErrorContains(t, opts.Check(), "the query option is not supported by the redis driver")
+
+	opts = Options{Host: "localhost:11211", DriverType: Memcache, Replication: Replication{Enable: true}}
+	assert.ErrorContains(t, opts.Check(), "the replication option is not supported by the memcache driver")
+
+	opts = Options{Host: "localhost:2181", DriverType: Zookeeper, Database: "test"}
+	assert.ErrorContains(t, opts.Check(), "the database option is not supported by the zookeeper driver")
+
+	opts = Options{Host: "localhost:9092", DriverType: Kafka}
+	opts.Evaluator.Expression = "x_int('//count') == 0"
+	assert.ErrorContains(t, opts.Check(), "the eval option is not supported by the kafka driver")
+
+	opts = Options{Host: "localhost:3306", DriverType: MySQL, Kafka: KafkaOptions{Topics: map[string]int{"orders": 0}}}
+	assert.ErrorContains(t, opts.Check(), "the kafka option is not supported by the mysql driver")
+
+	opts = Options{Host: "localhost:5432", DriverType: PostgreSQL, Redis: RedisOptions{Mode: RedisCluster}}
+	assert.ErrorContains(t, opts.Check(), "the redis option is not supported by the postgres driver")
+
+	opts = Options{Host: "localhost:3306", DriverType: MySQL, Mongo: MongoOptions{Collection: "jobs"}}
+	assert.ErrorContains(t, opts.Check(), "the mongo option is not supported by the mysql driver")
+
+	opts = Options{Host: "localhost:27017", DriverType: Mongo, Database: "test", Query: "{}",
+		Replication: Replication{Enable: true}, Mongo: MongoOptions{Collection: "jobs"}}
+	assert.Nil(t, opts.Check())
+
+	opts = Options{Host: "localhost:9092", DriverType: Kafka, Kafka: KafkaOptions{MaxLag: 10}}
+	assert.Nil(t, opts.Check())
+}
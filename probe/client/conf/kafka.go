@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The SASL mechanisms of Kafka
+const (
+	KafkaPlain       = "PLAIN"
+	KafkaScramSHA256 = "SCRAM-SHA-256"
+	KafkaScramSHA512 = "SCRAM-SHA-512"
+)
+
+// KafkaOptions is the Kafka specific configuration
+type KafkaOptions struct {
+	Mechanism      string         `yaml:"mechanism,omitempty" json:"mechanism,omitempty" jsonschema:"enum=PLAIN,enum=SCRAM-SHA-256,enum=SCRAM-SHA-512,title=SASL Mechanism,description=The SASL mechanism if the password is set,default=PLAIN"`
+	Topics         map[string]int `yaml:"topics,omitempty" json:"topics,omitempty" jsonschema:"title=Topics,description=The topics which must exist and their expected partition counts (0 means any),example={\"orders\":12}"`
+	ConsumerGroups []string       `yaml:"consumer_groups,omitempty" json:"consumer_groups,omitempty" jsonschema:"title=Consumer Groups,description=The consumer groups whose lag is checked"`
+	MaxLag         int64          `yaml:"max_lag,omitempty" json:"max_lag,omitempty" jsonschema:"title=Max Lag,description=The max lag (messages) of a consumer group on a topic (0 means no checking)"`
+}
+
+// Check checks the Kafka configuration and sets the default values
+func (k *KafkaOptions) Check() error {
+	k.Mechanism = strings.ToUpper(strings.TrimSpace(k.Mechanism))
+	switch k.Mechanism {
+	case "":
+		k.Mechanism = KafkaPlain
+	case KafkaPlain, KafkaScramSHA256, KafkaScramSHA512:
+	default:
+		return fmt.Errorf("Invalid Kafka SASL mechanism - [%s]", k.Mechanism)
+	}
+	for t, n := range k.Topics {
+		if len(strings.TrimSpace(t)) <= 0 {
+			return fmt.Errorf("the Kafka topic name is empty")
+		}
+		if n < 0 {
+			return fmt.Errorf("Invalid partition count of the Kafka topic [%s] - [%d]", t, n)
+		}
+	}
+	for _, g := range k.ConsumerGroups {
+		if len(strings.TrimSpace(g)) <= 0 {
+			return fmt.Errorf("the Kafka consumer group is empty")
+		}
+	}
+	if k.MaxLag < 0 {
+		return fmt.Errorf("Invalid Kafka max lag - [%d]", k.MaxLag)
+	}
+	return nil
+}
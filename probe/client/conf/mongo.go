@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The operations of the MongoDB query
+const (
+	MongoFind  = "find"
+	MongoCount = "count"
+)
+
+// MongoOptions is the MongoDB specific configuration
+type MongoOptions struct {
+	Collection string `yaml:"collection,omitempty" json:"collection,omitempty" jsonschema:"title=Collection,description=The collection which the query runs on,example=jobs"`
+	Operation  string `yaml:"operation,omitempty" json:"operation,omitempty" jsonschema:"enum=find,enum=count,title=Operation,description=The operation of the query,default=find"`
+}
+
+// Check checks the MongoDB configuration and sets the default values
+func (m *MongoOptions) Check() error {
+	m.Operation = strings.ToLower(strings.TrimSpace(m.Operation))
+	switch m.Operation {
+	case "":
+		m.Operation = MongoFind
+	case MongoFind, MongoCount:
+	default:
+		return fmt.Errorf("Invalid MongoDB operation - [%s], it must be %s or %s", m.Operation, MongoFind, MongoCount)
+	}
+	return nil
+}
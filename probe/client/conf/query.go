@@ -0,0 +1,256 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/megaease/easeprobe/eval"
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxQueryRows is the max number of the rows of the query result which could be evaluated
+const MaxQueryRows = 100
+
+// the statements which are allowed for the query
+var readOnlyStatements = []string{"SELECT", "WITH", "SHOW", "EXPLAIN", "DESCRIBE", "DESC", "VALUES", "TABLE"}
+
+// the keywords which modify the data, they are not allowed anywhere in the query,
+// e.g. the data-modifying CTE `WITH x AS (DELETE ... RETURNING *) SELECT ...` or `SELECT ... INTO`
+var dataModifyingKeywords = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE|TRUNCATE|INTO)\b`)
+
+// the dollar-quoted string tag of PostgreSQL, e.g. `$$` or `$body$`
+var dollarQuoteTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// QueryResult is the result of the query, it is evaluated as a JSON document. e.g.
+//
+//	{"count": 1, "columns": ["lag_seconds"], "rows": [{"lag_seconds": 3}]}
+//
+// so the expression could be `x_int('//count') == 0` or `x_int('//rows/*[1]/lag_seconds') < 30`
+type QueryResult struct {
+	Count   int                      `json:"count"`
+	Columns []string                 `json:"columns"`
+	Rows    []map[string]interface{} `json:"rows"`
+}
+
+// HasQuery returns true if the query is configured
+func (d *Options) HasQuery() bool {
+	return len(strings.TrimSpace(d.Query)) > 0
+}
+
+// CheckQuery checks the query and configures the evaluator
+func (d *Options) CheckQuery() error {
+	if !d.HasQuery() {
+		if len(strings.TrimSpace(d.Evaluator.Expression)) > 0 {
+			return fmt.Errorf("the eval expression requires the query")
+		}
+		return nil
+	}
+
+	d.Query = strings.TrimRight(strings.TrimSpace(d.Query), "; \t\n")
+	// the string literals, the quoted identifiers and the comments are not checked
+	code := strings.TrimRight(stripSQL(d.Query), "; \t\n")
+	fields := strings.Fields(code)
+	readOnly := false
+	for _, s := range readOnlyStatements {
+		if len(fields) > 0 && strings.EqualFold(fields[0], s) {
+			readOnly = true
+			break
+		}
+	}
+	if !readOnly {
+		return fmt.Errorf("Invalid Query - [%s], only the read-only statement is allowed", d.Query)
+	}
+	if strings.Contains(code, ";") {
+		return fmt.Errorf("Invalid Query - [%s], multiple statements are not allowed", d.Query)
+	}
+	if k := dataModifyingKeywords.FindString(code); len(k) > 0 {
+		return fmt.Errorf("Invalid Query - [%s], the data-modifying keyword [%s] is not allowed", d.Query, k)
+	}
+	return d.ConfigEvaluator()
+}
+
+// stripSQL replaces the string literals, the quoted identifiers and the comments of the SQL with spaces
+func stripSQL(query string) string {
+	var sb strings.Builder
+	for i := 0; i < len(query); {
+		switch {
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 4
+			}
+			i += end + 4
+		case query[i] == '\'' || query[i] == '"' || query[i] == '`':
+			i = skipQuoted(query, i)
+		case query[i] == '$' && dollarQuoteTag.MatchString(query[i:]):
+			tag := dollarQuoteTag.FindString(query[i:])
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				end = len(query) - i - 2*len(tag)
+			}
+			i += end + 2*len(tag)
+		default:
+			sb.WriteByte(query[i])
+			i++
+			continue
+		}
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+// skipQuoted returns the position after the quoted string which starts at `start`,
+// the quote is escaped by doubling it or by the backslash
+func skipQuoted(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// ConfigEvaluator configures the evaluator of the query result if the expression is set
+func (d *Options) ConfigEvaluator() error {
+	if len(strings.TrimSpace(d.Evaluator.Expression)) <= 0 {
+		return nil
+	}
+	// the query result is always a JSON document
+	if d.Evaluator.DocType == eval.Unsupported {
+		d.Evaluator.DocType = eval.JSON
+	}
+	if d.Evaluator.DocType != eval.JSON {
+		return fmt.Errorf("Invalid Query Evaluator - the document type must be json, but got %s", d.Evaluator.DocType)
+	}
+	return d.Evaluator.Config()
+}
+
+// RunQuery runs the query in a read-only transaction and evaluates the result
+func (d *Options) RunQuery(db *sql.DB) (bool, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout())
+	defer cancel()
+
+	log.Debugf("[%s / %s / %s] - Query - [%s]", d.ProbeKind, d.ProbeName, d.ProbeTag, d.Query)
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return false, fmt.Sprintf("Begin transaction error - %v", err)
+	}
+	// nothing need to be committed
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, d.Query)
+	if err != nil {
+		return false, fmt.Sprintf("Query error - [%s], %v", d.Query, err)
+	}
+	defer rows.Close()
+
+	result, err := ScanRows(rows)
+	if err != nil {
+		return false, fmt.Sprintf("Query error - [%s], %v", d.Query, err)
+	}
+	return d.EvaluateResult(result)
+}
+
+// EvaluateResult evaluates the query result with the expression if the evaluator is configured
+func (d *Options) EvaluateResult(result *QueryResult) (bool, string) {
+	message := fmt.Sprintf("Query returned %d row(s)", result.Count)
+
+	if d.Evaluator.Extractor == nil {
+		return true, message
+	}
+	doc, err := json.Marshal(result)
+	if err != nil {
+		return false, fmt.Sprintf("%s. Evaluation Error: %v", message, err)
+	}
+	log.Debugf("[%s / %s / %s] - Evaluator expression: %s", d.ProbeKind, d.ProbeName, d.ProbeTag, d.Evaluator.Expression)
+	d.Evaluator.SetDocument(eval.JSON, string(doc))
+	ok, err := d.Evaluator.Evaluate()
+	if err != nil {
+		log.Errorf("[%s / %s / %s] - %v", d.ProbeKind, d.ProbeName, d.ProbeTag, err)
+		return false, fmt.Sprintf("%s. Evaluation Error: %v", message, err)
+	}
+	if !ok {
+		log.Errorf("[%s / %s / %s] - expression is evaluated to false!", d.ProbeKind, d.ProbeName, d.ProbeTag)
+		message += ". Expression is evaluated to false!"
+		for k, v := range d.Evaluator.ExtractedValues {
+			message += fmt.Sprintf(" [%s = %v]", k, v)
+		}
+		return false, message
+	}
+	log.Debugf("[%s / %s / %s] - expression is evaluated to true!", d.ProbeKind, d.ProbeName, d.ProbeTag)
+	return true, message
+}
+
+// ScanRows reads the rows into the query result, only the first MaxQueryRows rows are kept
+func ScanRows(rows *sql.Rows) (*QueryResult, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{Columns: columns, Rows: []map[string]interface{}{}}
+	for rows.Next() {
+		result.Count++
+		if result.Count > MaxQueryRows {
+			continue
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, c := range columns {
+			switch v := values[i].(type) {
+			case []byte:
+				row[c] = string(v)
+			case time.Time:
+				row[c] = v.Format(time.RFC3339)
+			default:
+				row[c] = v
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, rows.Err()
+}
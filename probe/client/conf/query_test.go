@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/megaease/easeprobe/eval"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDB is a database/sql driver which returns the fixed result set
+type fakeDB struct {
+	columns  []string
+	rows     [][]driver.Value
+	readOnly bool
+	err      error
+}
+
+type fakeRows struct {
+	db  *fakeDB
+	idx int
+}
+
+func (f *fakeDB) Open(name string) (driver.Conn, error)        { return f, nil }
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return f, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return f }
+func (f *fakeDB) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+func (f *fakeDB) Close() error              { return nil }
+func (f *fakeDB) Begin() (driver.Tx, error) { return f, nil }
+func (f *fakeDB) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	f.readOnly = opts.ReadOnly
+	return f, nil
+}
+func (f *fakeDB) Commit() error   { return nil }
+func (f *fakeDB) Rollback() error { return nil }
+func (f *fakeDB) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &fakeRows{db: f}, nil
+}
+
+func (r *fakeRows) Columns() []string { return r.db.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.db.rows) {
+		return io.EOF
+	}
+	copy(dest, r.db.rows[r.idx])
+	r.idx++
+	return nil
+}
+
+func TestCheckQuery(t *testing.T) {
+	opts := Options{}
+	assert.False(t, opts.HasQuery())
+	assert.Nil(t, opts.CheckQuery())
+
+	opts.Evaluator.Expression = "x_int('//count') == 0"
+	assert.ErrorContains(t, opts.CheckQuery(), "requires the query")
+
+	opts.Query = "DELETE FROM jobs"
+	assert.True(t, opts.HasQuery())
+	assert.ErrorContains(t, opts.CheckQuery(), "only the read-only statement is allowed")
+
+	opts.Query = "  select count(*) as n from jobs; \n"
+	assert.Nil(t, opts.CheckQuery())
+	assert.Equal(t, "select count(*) as n from jobs", opts.Query)
+	assert.Equal(t, eval.JSON, opts.Evaluator.DocType)
+	assert.NotNil(t, opts.Evaluator.Extractor)
+
+	opts.Evaluator.DocType = eval.XML
+	assert.ErrorContains(t, opts.CheckQuery(), "must be json")
+
+	for _, q := range []string{"SHOW SLAVE STATUS", "WITH t AS (SELECT 1) SELECT * FROM t", "EXPLAIN SELECT 1",
+		"SELECT 'a;b', \"update\", `delete` FROM t -- insert; delete\n",
+		"/* delete */ SELECT 'it''s; delete' AS s", "SELECT 'it\\'s; delete' AS s",
+		"SELECT $$; DELETE FROM t$$, $body$ insert $body$, $1", "SELECT data #> '{a,b}' FROM t",
+		"SELECT updated_at, deleted FROM t;"} {
+		opts := Options{Query: q}
+		assert.Nil(t, opts.CheckQuery(), q)
+	}
+
+	// multiple statements
+	for _, q := range []string{"SELECT 1; DROP TABLE t", "SELECT 1;DELETE FROM t;", "SELECT ';' ; DROP TABLE t",
+		"SELECT 1 /* x */; DROP TABLE t", "SELECT $$x$$; DROP TABLE t"} {
+		opts := Options{Query: q}
+		assert.ErrorContains(t, opts.CheckQuery(), "multiple statements are not allowed", q)
+	}
+
+	// data-modifying statements
+	for _, q := range []string{"WITH x AS (DELETE FROM jobs RETURNING *) SELECT * FROM x",
+		"with x as (update jobs set done = true returning id) select count(*) from x",
+		"WITH x AS (INSERT INTO t VALUES (1) RETURNING *) SELECT 1", "SELECT * INTO backup FROM jobs",
+		"SELECT * FROM t FOR UPDATE"} {
+		opts := Options{Query: q}
+		assert.ErrorContains(t, opts.CheckQuery(), "the data-modifying keyword", q)
+	}
+}
+
+func TestRunQuery(t *testing.T) {
+	f := &fakeDB{
+		columns: []string{"name", "lag_seconds", "updated"},
+		rows: [][]driver.Value{
+			{[]byte("replica1"), int64(3), time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{[]byte("replica2"), int64(45), nil},
+		},
+	}
+	db := sql.OpenDB(f)
+	defer db.Close()
+
+	opts := Options{Query: "SELECT name, lag_seconds, updated FROM replicas"}
+	opts.ProbeTimeout = time.Second
+	assert.Nil(t, opts.CheckQuery())
+	s, m := opts.RunQuery(db)
+	assert.True(t, s)
+	assert.Contains(t, m, "2 row(s)")
+	assert.True(t, f.readOnly)
+
+	opts.Evaluator.Expression = "x_int('//count') == 2 && x_str('//rows/*[1]/name') == 'replica1' && x_int('//rows/*[1]/lag_seconds') < 30"
+	assert.Nil(t, opts.CheckQuery())
+	s, m = opts.RunQuery(db)
+	assert.True(t, s, m)
+
+	opts.Evaluator.Expression = "x_time('//rows/*[1]/updated') < now()"
+	assert.Nil(t, opts.CheckQuery())
+	s, m = opts.RunQuery(db)
+	assert.True(t, s, m)
+
+	opts.Evaluator.Expression = "x_int('//rows/*[2]/lag_seconds') < 30"
+	assert.Nil(t, opts.CheckQuery())
+	s, m = opts.RunQuery(db)
+	assert.False(t, s)
+	assert.Contains(t, m, "Expression is evaluated to false!")
+	assert.Contains(t, m, "[//rows/*[2]/lag_seconds = 45]")
+
+	opts.Evaluator.Expression = "x_int('//rows/*[1]/name') < 30"
+	assert.Nil(t, opts.CheckQuery())
+	s, m = opts.RunQuery(db)
+	assert.False(t, s)
+	assert.Contains(t, m, "Evaluation Error")
+
+	// no rows
+	f.rows = nil
+	opts.Evaluator.Expression = "x_int('//count') == 0"
+	assert.Nil(t, opts.CheckQuery())
+	s, m = opts.RunQuery(db)
+	assert.True(t, s, m)
+	assert.Contains(t, m, "0 row(s)")
+
+	// only the first rows are kept
+	for i := 0; i < MaxQueryRows+10; i++ {
+		f.rows = append(f.rows, []driver.Value{[]byte("r"), int64(i), nil})
+	}
+	opts.Evaluator.Expression = fmt.Sprintf("x_int('//count') == %d && x_str('//rows/*[%d]/name') == ''", MaxQueryRows+10, MaxQueryRows+1)
+	assert.Nil(t, opts.CheckQuery())
+	s, m = opts.RunQuery(db)
+	assert.True(t, s, m)
+
+	// query error
+	f.err = fmt.Errorf("query error")
+	s, m = opts.RunQuery(db)
+	assert.False(t, s)
+	assert.Contains(t, m, "query error")
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The modes of the Redis server
+const (
+	RedisStandalone = "standalone"
+	RedisCluster    = "cluster"
+	RedisSentinel   = "sentinel"
+)
+
+// RedisOptions is the Redis specific configuration
+type RedisOptions struct {
+	Mode       string     `yaml:"mode,omitempty" json:"mode,omitempty" jsonschema:"enum=standalone,enum=cluster,enum=sentinel,title=Mode,description=The mode of the Redis server,default=standalone"`
+	MasterName string     `yaml:"master_name,omitempty" json:"master_name,omitempty" jsonschema:"title=Master Name,description=The master name monitored by the Sentinel,example=mymaster"`
+	Info       *RedisInfo `yaml:"info,omitempty" json:"info,omitempty" jsonschema:"title=INFO Checking,description=Check the fields of the INFO command"`
+}
+
+// RedisInfo is the checking of the fields of the Redis INFO command
+type RedisInfo struct {
+	Role                   string  `yaml:"role,omitempty" json:"role,omitempty" jsonschema:"enum=master,enum=slave,title=Role,description=The expected role of the server"`
+	MinConnectedSlaves     int     `yaml:"min_connected_slaves,omitempty" json:"min_connected_slaves,omitempty" jsonschema:"title=Min Connected Slaves,description=The min number of the connected slaves"`
+	MaxMemoryUsage         float64 `yaml:"max_memory_usage,omitempty" json:"max_memory_usage,omitempty" jsonschema:"title=Max Memory Usage,description=The max percentage of used_memory to maxmemory,example=90"`
+	MaxRejectedConnections *int64  `yaml:"max_rejected_connections,omitempty" json:"max_rejected_connections,omitempty" jsonschema:"title=Max Rejected Connections,description=The max increase of rejected_connections since the last probe (no checking if it is not set)"`
+}
+
+// Check checks the Redis configuration and sets the default values
+func (r *RedisOptions) Check() error {
+	r.Mode = strings.ToLower(strings.TrimSpace(r.Mode))
+	switch r.Mode {
+	case "":
+		r.Mode = RedisStandalone
+	case RedisStandalone, RedisCluster:
+	case RedisSentinel:
+		if len(strings.TrimSpace(r.MasterName)) <= 0 {
+			return fmt.Errorf("the master name is required for the Redis Sentinel")
+		}
+		if r.Info != nil {
+			return fmt.Errorf("the INFO checking is not supported for the Redis Sentinel")
+		}
+	default:
+		return fmt.Errorf("Invalid Redis mode - [%s]", r.Mode)
+	}
+
+	if r.Info == nil {
+		return nil
+	}
+	r.Info.Role = strings.ToLower(strings.TrimSpace(r.Info.Role))
+	if r.Info.Role != "" && r.Info.Role != "master" && r.Info.Role != "slave" {
+		return fmt.Errorf("Invalid Redis role - [%s], it must be master or slave", r.Info.Role)
+	}
+	if r.Info.MinConnectedSlaves < 0 {
+		return fmt.Errorf("Invalid Redis min connected slaves - [%d]", r.Info.MinConnectedSlaves)
+	}
+	if r.Info.MaxMemoryUsage < 0 || r.Info.MaxMemoryUsage > 100 {
+		return fmt.Errorf("Invalid Redis max memory usage - [%v], it must be in [0, 100]", r.Info.MaxMemoryUsage)
+	}
+	if r.Info.MaxRejectedConnections != nil && *r.Info.MaxRejectedConnections < 0 {
+		return fmt.Errorf("Invalid Redis max rejected connections - [%d]", *r.Info.MaxRejectedConnections)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxReplicationLag is the default max replication lag
+const DefaultMaxReplicationLag = 30 * time.Second
+
+// The roles of the database server in the replication
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+// Replication is the configuration of the replication health checking
+type Replication struct {
+	Enable      bool          `yaml:"enable" json:"enable" jsonschema:"title=Enable,description=Enable the replication health checking,default=false"`
+	Role        string        `yaml:"role,omitempty" json:"role,omitempty" jsonschema:"enum=replica,enum=primary,title=Role,description=The role of the database server in the replication,default=replica"`
+	MaxLag      time.Duration `yaml:"max_lag,omitempty" json:"max_lag,omitempty" jsonschema:"type=string,format=duration,title=Max Lag,description=The max replication lag,default=30s"`
+	MinReplicas int           `yaml:"min_replicas,omitempty" json:"min_replicas,omitempty" jsonschema:"title=Min Replicas,description=The min number of the connected replicas for the primary,default=1"`
+}
+
+// Check checks the replication configuration and sets the default values
+func (r *Replication) Check() error {
+	if !r.Enable {
+		return nil
+	}
+	r.Role = strings.ToLower(strings.TrimSpace(r.Role))
+	if r.Role == "" {
+		r.Role = RoleReplica
+	}
+	if r.Role != RoleReplica && r.Role != RolePrimary {
+		return fmt.Errorf("Invalid replication role - [%s], it must be %s or %s", r.Role, RoleReplica, RolePrimary)
+	}
+	if r.MaxLag < 0 {
+		return fmt.Errorf("Invalid replication max lag - [%s]", r.MaxLag)
+	}
+	if r.MaxLag == 0 {
+		r.MaxLag = DefaultMaxReplicationLag
+	}
+	if r.MinReplicas < 0 {
+		return fmt.Errorf("Invalid replication min replicas - [%d]", r.MinReplicas)
+	}
+	if r.MinReplicas == 0 {
+		r.MinReplicas = 1
+	}
+	return nil
+}
+
+// CheckLag checks the replication lag (seconds) of the source with the max lag
+func (r *Replication) CheckLag(source string, lag float64) (bool, string) {
+	d := time.Duration(lag * float64(time.Second)).Round(time.Millisecond)
+	if d > r.MaxLag {
+		return false, fmt.Sprintf("[%s] replication lag %s > %s", source, d, r.MaxLag)
+	}
+	return true, fmt.Sprintf("[%s] replication lag %s", source, d)
+}
+
+// ValueString converts the value of the query result to string
+func ValueString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// ValueFloat converts the value of the query result to float, it returns false if the value is NULL or not a number
+func ValueFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case nil:
+		return 0, false
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(ValueString(v)), 64)
+	return f, err == nil
+}
+
+// ValueBool converts the value of the query result to bool, e.g. `true`, `t`, `yes`, `1`
+func ValueBool(v interface{}) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	switch strings.ToLower(strings.TrimSpace(ValueString(v))) {
+	case "true", "t", "yes", "y", "on", "1":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicationCheck(t *testing.T) {
+	r := Replication{}
+	assert.Nil(t, r.Check())
+	assert.Equal(t, "", r.Role)
+
+	r.Enable = true
+	assert.Nil(t, r.Check())
+	assert.Equal(t, RoleReplica, r.Role)
+	assert.Equal(t, DefaultMaxReplicationLag, r.MaxLag)
+	assert.Equal(t, 1, r.MinReplicas)
+
+	r = Replication{Enable: true, Role: " Primary ", MaxLag: time.Minute, MinReplicas: 2}
+	assert.Nil(t, r.Check())
+	assert.Equal(t, RolePrimary, r.Role)
+	assert.Equal(t, time.Minute, r.MaxLag)
+	assert.Equal(t, 2, r.MinReplicas)
+
+	r = Replication{Enable: true, Role: "master"}
+	assert.ErrorContains(t, r.Check(), "Invalid replication role")
+	r = Replication{Enable: true, MaxLag: -time.Second}
+	assert.ErrorContains(t, r.Check(), "Invalid replication max lag")
+	r = Replication{Enable: true, MinReplicas: -1}
+	assert.ErrorContains(t, r.Check(), "Invalid replication min replicas")
+}
+
+func TestReplicationCheckLag(t *testing.T) {
+	r := Replication{Enable: true}
+	assert.Nil(t, r.Check())
+
+	ok, msg := r.CheckLag("replica1", 1.2345)
+	assert.True(t, ok)
+	assert.Equal(t, "[replica1] replication lag 1.235s", msg)
+
+	ok, msg = r.CheckLag("replica1", 30)
+	assert.True(t, ok)
+
+	ok, msg = r.CheckLag("replica1", 45)
+	assert.False(t, ok)
+	assert.Equal(t, "[replica1] replication lag 45s > 30s", msg)
+}
+
+func TestValues(t *testing.T) {
+	assert.Equal(t, "", ValueString(nil))
+	assert.Equal(t, "Yes", ValueString([]byte("Yes")))
+	assert.Equal(t, "Yes", ValueString("Yes"))
+	assert.Equal(t, "10", ValueString(int64(10)))
+
+	for _, v := range []interface{}{int64(3), int32(3), 3, uint64(3), 3.0, float32(3), "3", []byte(" 3 ")} {
+		f, ok := ValueFloat(v)
+		assert.True(t, ok)
+		assert.Equal(t, 3.0, f)
+	}
+	for _, v := range []interface{}{nil, "", "abc", []byte("NULL")} {
+		_, ok := ValueFloat(v)
+		assert.False(t, ok)
+	}
+
+	for _, v := range []interface{}{true, "t", "TRUE", []byte("Yes"), "1", int64(1)} {
+		assert.True(t, ValueBool(v))
+	}
+	for _, v := range []interface{}{false, nil, "f", "No", "0", int64(0)} {
+		assert.False(t, ValueBool(v))
+	}
+}
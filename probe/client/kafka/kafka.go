@@ -26,7 +26,9 @@ import (
 
 	"github.com/megaease/easeprobe/probe/client/conf"
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
 	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -38,6 +40,10 @@ type Kafka struct {
 	conf.Options `yaml:",inline"`
 	tls          *tls.Config     `yaml:"-" json:"-"`
 	Context      context.Context `yaml:"-" json:"-"`
+
+	mechanism sasl.Mechanism             `yaml:"-" json:"-"`
+	metrics   *metrics                   `yaml:"-" json:"-"`
+	lagTopics map[string]map[string]bool `yaml:"-" json:"-"` // the exported topics of the consumer groups
 }
 
 // New create a Kafka client
@@ -47,14 +53,45 @@ func New(opt conf.Options) (*Kafka, error) {
 		log.Errorf("[%s / %s / %s] - TLS Config Error - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
 		return nil, fmt.Errorf("TLS Config Error - %v", err)
 	}
+	if err := opt.Kafka.Check(); err != nil {
+		log.Errorf("[%s / %s / %s] - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
+		return nil, err
+	}
+	mechanism, err := newMechanism(opt)
+	if err != nil {
+		log.Errorf("[%s / %s / %s] - SASL Config Error - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
+		return nil, fmt.Errorf("SASL Config Error - %v", err)
+	}
+
 	k := &Kafka{
-		Options: opt,
-		tls:     tls,
-		Context: context.Background(),
+		Options:   opt,
+		tls:       tls,
+		Context:   context.Background(),
+		mechanism: mechanism,
+	}
+	if len(opt.Kafka.ConsumerGroups) > 0 {
+		k.metrics = newMetrics(opt.ProbeKind, opt.ProbeTag, opt.Labels)
 	}
 	return k, nil
 }
 
+// newMechanism creates the SASL mechanism, it is nil if the password is not set
+func newMechanism(opt conf.Options) (sasl.Mechanism, error) {
+	if len(opt.Password) <= 0 {
+		return nil, nil
+	}
+	switch opt.Kafka.Mechanism {
+	case conf.KafkaScramSHA256:
+		return scram.Mechanism(scram.SHA256, opt.Username, opt.Password)
+	case conf.KafkaScramSHA512:
+		return scram.Mechanism(scram.SHA512, opt.Username, opt.Password)
+	}
+	return plain.Mechanism{
+		Username: opt.Username,
+		Password: opt.Password,
+	}, nil
+}
+
 // Kind return the name of client
 func (k *Kafka) Kind() string {
 	return Kind
@@ -63,23 +100,10 @@ func (k *Kafka) Kind() string {
 // Probe do the health check
 func (k *Kafka) Probe() (bool, string) {
 
-	var dialer *kafka.Dialer
-
-	if len(k.Password) > 0 {
-		dialer = &kafka.Dialer{
-			Timeout: k.Timeout(),
-			TLS:     k.tls,
-			SASLMechanism: plain.Mechanism{
-				Username: k.Username,
-				Password: k.Password,
-			},
-		}
-	} else {
-		dialer = &kafka.Dialer{
-			Timeout:       k.Timeout(),
-			TLS:           k.tls,
-			SASLMechanism: nil,
-		}
+	dialer := &kafka.Dialer{
+		Timeout:       k.Timeout(),
+		TLS:           k.tls,
+		SASLMechanism: k.mechanism,
 	}
 
 	ctx, cancel := context.WithTimeout(k.Context, k.Timeout())
@@ -87,6 +111,7 @@ func (k *Kafka) Probe() (bool, string) {
 
 	conn, err := dialer.DialContext(ctx, "tcp", k.Host)
 	if err != nil {
+		k.ExportMetrics(k.unknownLags(k.Kafka.ConsumerGroups...))
 		return false, err.Error()
 	}
 	defer conn.Close()
@@ -94,6 +119,7 @@ func (k *Kafka) Probe() (bool, string) {
 
 	partitions, err := conn.ReadPartitions()
 	if err != nil {
+		k.ExportMetrics(k.unknownLags(k.Kafka.ConsumerGroups...))
 		return false, err.Error()
 	}
 
@@ -106,6 +132,22 @@ func (k *Kafka) Probe() (bool, string) {
 		log.Debugf("[%s / %s / %s] Topic Name - %s", k.ProbeKind, k.ProbeName, k.ProbeTag, t)
 	}
 
-	return true, "Check Kafka Server Successfully!"
+	message := "Check Kafka Server Successfully!"
+	if len(k.Kafka.Topics) > 0 {
+		ok, msg := k.checkTopics(partitions)
+		if !ok {
+			k.ExportMetrics(k.unknownLags(k.Kafka.ConsumerGroups...))
+			return false, msg
+		}
+		message += " " + msg
+	}
+	if len(k.Kafka.ConsumerGroups) > 0 {
+		ok, msg := k.checkConsumerLag(ctx)
+		if !ok {
+			return false, msg
+		}
+		message += " " + msg
+	}
+	return true, message
 
 }
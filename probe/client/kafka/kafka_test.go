@@ -20,13 +20,17 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
 
 	"github.com/megaease/easeprobe/global"
 	"github.com/megaease/easeprobe/monkey"
 	"github.com/megaease/easeprobe/probe/client/conf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -113,3 +117,195 @@ func TestKafkaFailed(t *testing.T) {
 	assert.False(t, s)
 	assert.Contains(t, m, "connection error")
 }
+
+func TestMechanism(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com:9092",
+		DriverType: conf.Kafka,
+		Username:   "user",
+	}
+	kaf, err := New(opt)
+	assert.Nil(t, err)
+	assert.Nil(t, kaf.mechanism)
+
+	opt.Password = "pass"
+	kaf, err = New(opt)
+	assert.Nil(t, err)
+	assert.Equal(t, plain.Mechanism{Username: "user", Password: "pass"}, kaf.mechanism)
+
+	opt.Kafka.Mechanism = "scram-sha-256"
+	kaf, err = New(opt)
+	assert.Nil(t, err)
+	assert.Equal(t, "SCRAM-SHA-256", kaf.mechanism.Name())
+
+	opt.Kafka.Mechanism = "SCRAM-SHA-512"
+	kaf, err = New(opt)
+	assert.Nil(t, err)
+	assert.Equal(t, "SCRAM-SHA-512", kaf.mechanism.Name())
+
+	opt.Kafka.Mechanism = "GSSAPI"
+	kaf, err = New(opt)
+	assert.Nil(t, kaf)
+	assert.ErrorContains(t, err, "Invalid Kafka SASL mechanism")
+
+	opt.Kafka = conf.KafkaOptions{Topics: map[string]int{"orders": -1}}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "Invalid partition count")
+
+	opt.Kafka = conf.KafkaOptions{Topics: map[string]int{" ": 1}}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "topic name is empty")
+
+	opt.Kafka = conf.KafkaOptions{ConsumerGroups: []string{""}}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "consumer group is empty")
+
+	opt.Kafka = conf.KafkaOptions{MaxLag: -1}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "Invalid Kafka max lag")
+}
+
+func TestTopics(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com:9092",
+		DriverType: conf.Kafka,
+		Kafka: conf.KafkaOptions{
+			Topics: map[string]int{"orders": 2, "events": 0},
+		},
+	}
+	kaf, err := New(opt)
+	assert.Nil(t, err)
+
+	b1 := kafka.Broker{Host: "broker1", Port: 9092, ID: 1}
+	b2 := kafka.Broker{Host: "broker2", Port: 9092, ID: 2}
+	partitions := []kafka.Partition{
+		{Topic: "orders", ID: 0, Leader: b1, Replicas: []kafka.Broker{b1, b2}, Isr: []kafka.Broker{b1, b2}},
+		{Topic: "orders", ID: 1, Leader: b2, Replicas: []kafka.Broker{b1, b2}, Isr: []kafka.Broker{b1, b2}},
+		{Topic: "events", ID: 0, Leader: b1, Replicas: []kafka.Broker{b1}, Isr: []kafka.Broker{b1}},
+		{Topic: "other", ID: 0},
+	}
+
+	var dialer *kafka.Dialer
+	monkey.PatchInstanceMethod(reflect.TypeOf(dialer), "DialContext", func(_ *kafka.Dialer, _ context.Context, _ string, _ string) (*kafka.Conn, error) {
+		return &kafka.Conn{}, nil
+	})
+	var conn *kafka.Conn
+	monkey.PatchInstanceMethod(reflect.TypeOf(conn), "ReadPartitions", func(k *kafka.Conn, topics ...string) ([]kafka.Partition, error) {
+		return partitions, nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(conn), "Close", func(_ *kafka.Conn) error {
+		return nil
+	})
+
+	s, m := kaf.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Successfully! Topics: 2 topic(s) with 3 partition(s) are healthy")
+
+	// under-replicated and no leader
+	partitions[1].Isr = []kafka.Broker{b2}
+	partitions[2].Leader = kafka.Broker{}
+	s, m = kaf.Probe()
+	assert.False(t, s)
+	assert.Equal(t, "Topic Error: topic [events] partitions [0] have no leader; topic [orders] partitions [1] are under-replicated", m)
+
+	// partition count and missing topic
+	partitions = partitions[:1]
+	s, m = kaf.Probe()
+	assert.False(t, s)
+	assert.Equal(t, "Topic Error: topic [events] is not found; topic [orders] has 1 partitions, expected 2", m)
+
+	monkey.UnpatchAll()
+}
+
+func TestConsumerLag(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com:9092",
+		DriverType: conf.Kafka,
+		Kafka: conf.KafkaOptions{
+			ConsumerGroups: []string{"billing", "audit"},
+			MaxLag:         100,
+		},
+	}
+	opt.ProbeKind = "client"
+	opt.ProbeTag = "kafka"
+	opt.ProbeName = "kafka"
+	kaf, err := New(opt)
+	assert.Nil(t, err)
+	assert.NotNil(t, kaf.metrics)
+
+	var dialer *kafka.Dialer
+	monkey.PatchInstanceMethod(reflect.TypeOf(dialer), "DialContext", func(_ *kafka.Dialer, _ context.Context, _ string, _ string) (*kafka.Conn, error) {
+		return &kafka.Conn{}, nil
+	})
+	var conn *kafka.Conn
+	monkey.PatchInstanceMethod(reflect.TypeOf(conn), "ReadPartitions", func(k *kafka.Conn, topics ...string) ([]kafka.Partition, error) {
+		return []kafka.Partition{{Topic: "orders", ID: 0}, {Topic: "orders", ID: 1}}, nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(conn), "Close", func(_ *kafka.Conn) error {
+		return nil
+	})
+
+	committed := map[string]map[string][]kafka.OffsetFetchPartition{
+		"billing": {"orders": {{Partition: 0, CommittedOffset: 90}, {Partition: 1, CommittedOffset: 180}}},
+		"audit":   {"orders": {{Partition: 0, CommittedOffset: 100}, {Partition: 1, CommittedOffset: -1}}},
+	}
+	var fetchErr error
+	var client *kafka.Client
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "OffsetFetch", func(_ *kafka.Client, _ context.Context, req *kafka.OffsetFetchRequest) (*kafka.OffsetFetchResponse, error) {
+		return &kafka.OffsetFetchResponse{Topics: committed[req.GroupID], Error: fetchErr}, nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "ListOffsets", func(_ *kafka.Client, _ context.Context, req *kafka.ListOffsetsRequest) (*kafka.ListOffsetsResponse, error) {
+		last := map[int]int64{0: 100, 1: 200}
+		resp := &kafka.ListOffsetsResponse{Topics: map[string][]kafka.PartitionOffsets{}}
+		for t, ps := range req.Topics {
+			for _, p := range ps {
+				resp.Topics[t] = append(resp.Topics[t], kafka.PartitionOffsets{Partition: p.Partition, LastOffset: last[p.Partition]})
+			}
+		}
+		return resp, nil
+	})
+
+	lag := func(group string) float64 {
+		return testutil.ToFloat64(kaf.metrics.ConsumerLag.With(prometheus.Labels{"name": "kafka", "group": group, "topic": "orders"}))
+	}
+
+	s, m := kaf.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Successfully! Consumer Lag: [billing / orders] lag 30; [audit / orders] lag 0")
+	assert.Equal(t, 30.0, lag("billing"))
+	assert.Equal(t, 2, testutil.CollectAndCount(kaf.metrics.ConsumerLag))
+
+	committed["billing"]["orders"][1].CommittedOffset = 50
+	s, m = kaf.Probe()
+	assert.False(t, s)
+	assert.Equal(t, "Consumer Lag Error: [billing / orders] lag 160 > 100; [audit / orders] lag 0", m)
+
+	delete(committed, "audit")
+	committed["billing"]["orders"][1].CommittedOffset = 180
+	s, m = kaf.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "[audit] has no committed offsets")
+	// the series of the topic which is not consumed any more is removed
+	assert.Equal(t, 1, testutil.CollectAndCount(kaf.metrics.ConsumerLag))
+
+	fetchErr = kafka.GroupCoordinatorNotAvailable
+	s, m = kaf.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "[billing] [15] Group Coordinator Not Available")
+	assert.True(t, math.IsNaN(lag("billing")))
+
+	fetchErr = nil
+	s, _ = kaf.Probe()
+	assert.Equal(t, 30.0, lag("billing"))
+
+	// the lag is unknown if the server is not connected
+	monkey.PatchInstanceMethod(reflect.TypeOf(dialer), "DialContext", func(_ *kafka.Dialer, _ context.Context, _ string, _ string) (*kafka.Conn, error) {
+		return nil, fmt.Errorf("connection refused")
+	})
+	s, _ = kaf.Probe()
+	assert.False(t, s)
+	assert.True(t, math.IsNaN(lag("billing")))
+	assert.Equal(t, 1, testutil.CollectAndCount(kaf.metrics.ConsumerLag))
+
+	monkey.UnpatchAll()
+}
@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/megaease/easeprobe/metric"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// checkTopics checks the topics exist with the expected partition counts,
+// and all of their partitions have a leader and the full ISR
+func (k *Kafka) checkTopics(partitions []kafka.Partition) (bool, string) {
+	topics := map[string][]kafka.Partition{}
+	for _, p := range partitions {
+		topics[p.Topic] = append(topics[p.Topic], p)
+	}
+
+	names := make([]string, 0, len(k.Kafka.Topics))
+	for t := range k.Kafka.Topics {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+
+	failed := []string{}
+	total := 0
+	for _, t := range names {
+		ps, ok := topics[t]
+		if !ok {
+			failed = append(failed, fmt.Sprintf("topic [%s] is not found", t))
+			continue
+		}
+		total += len(ps)
+		if n := k.Kafka.Topics[t]; n > 0 && len(ps) != n {
+			failed = append(failed, fmt.Sprintf("topic [%s] has %d partitions, expected %d", t, len(ps), n))
+		}
+
+		noLeader, underReplicated := []int{}, []int{}
+		for _, p := range ps {
+			// the host of the leader is unknown if the partition has no leader
+			if p.Error != nil || len(p.Leader.Host) <= 0 {
+				noLeader = append(noLeader, p.ID)
+			} else if len(p.Isr) < len(p.Replicas) {
+				underReplicated = append(underReplicated, p.ID)
+			}
+		}
+		sort.Ints(noLeader)
+		sort.Ints(underReplicated)
+		if len(noLeader) > 0 {
+			failed = append(failed, fmt.Sprintf("topic [%s] partitions %v have no leader", t, noLeader))
+		}
+		if len(underReplicated) > 0 {
+			failed = append(failed, fmt.Sprintf("topic [%s] partitions %v are under-replicated", t, underReplicated))
+		}
+	}
+
+	if len(failed) > 0 {
+		return false, "Topic Error: " + strings.Join(failed, "; ")
+	}
+	return true, fmt.Sprintf("Topics: %d topic(s) with %d partition(s) are healthy", len(names), total)
+}
+
+// newClient creates the Kafka client for the consumer group APIs
+func (k *Kafka) newClient() *kafka.Client {
+	return &kafka.Client{
+		Addr:    kafka.TCP(k.Host),
+		Timeout: k.Timeout(),
+		Transport: &kafka.Transport{
+			DialTimeout: k.Timeout(),
+			TLS:         k.tls,
+			SASL:        k.mechanism,
+		},
+	}
+}
+
+// checkConsumerLag checks the lag of the consumer groups on every topic they consume
+func (k *Kafka) checkConsumerLag(ctx context.Context) (bool, string) {
+	client := k.newClient()
+
+	status := true
+	messages := []string{}
+	exported := map[string]map[string]float64{}
+	for _, group := range k.Kafka.ConsumerGroups {
+		lags, err := consumerLag(ctx, client, group)
+		if err != nil {
+			status = false
+			messages = append(messages, fmt.Sprintf("[%s] %v", group, err))
+			for g, topics := range k.unknownLags(group) {
+				exported[g] = topics
+			}
+			continue
+		}
+		if len(lags) <= 0 {
+			status = false
+			messages = append(messages, fmt.Sprintf("[%s] has no committed offsets", group))
+			continue
+		}
+
+		topics := make([]string, 0, len(lags))
+		for t := range lags {
+			topics = append(topics, t)
+		}
+		sort.Strings(topics)
+		exported[group] = map[string]float64{}
+		for _, t := range topics {
+			lag := lags[t]
+			exported[group][t] = float64(lag)
+			log.Debugf("[%s / %s / %s] Consumer Group [%s] Topic [%s] - lag %d", k.ProbeKind, k.ProbeName, k.ProbeTag, group, t, lag)
+			if k.Kafka.MaxLag > 0 && lag > k.Kafka.MaxLag {
+				status = false
+				messages = append(messages, fmt.Sprintf("[%s / %s] lag %d > %d", group, t, lag, k.Kafka.MaxLag))
+			} else {
+				messages = append(messages, fmt.Sprintf("[%s / %s] lag %d", group, t, lag))
+			}
+		}
+	}
+
+	k.ExportMetrics(exported)
+
+	if !status {
+		return false, "Consumer Lag Error: " + strings.Join(messages, "; ")
+	}
+	return true, "Consumer Lag: " + strings.Join(messages, "; ")
+}
+
+// consumerLag returns the lag of the consumer group on every topic, which is the sum of
+// the differences between the last offset and the committed offset of the partitions
+func consumerLag(ctx context.Context, client *kafka.Client, group string) (map[string]int64, error) {
+	offsets, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: group})
+	if err != nil {
+		return nil, err
+	}
+	if offsets.Error != nil {
+		return nil, offsets.Error
+	}
+
+	requests := map[string][]kafka.OffsetRequest{}
+	for t, ps := range offsets.Topics {
+		for _, p := range ps {
+			// the offset is -1 if the consumer group has not committed on the partition
+			if p.Error == nil && p.CommittedOffset >= 0 {
+				requests[t] = append(requests[t], kafka.LastOffsetOf(p.Partition))
+			}
+		}
+	}
+	lags := map[string]int64{}
+	if len(requests) <= 0 {
+		return lags, nil
+	}
+
+	last, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: requests})
+	if err != nil {
+		return nil, err
+	}
+	for t := range requests {
+		lastOffsets := map[int]int64{}
+		for _, p := range last.Topics[t] {
+			if p.Error != nil {
+				return nil, fmt.Errorf("topic [%s] partition [%d] - %v", t, p.Partition, p.Error)
+			}
+			lastOffsets[p.Partition] = p.LastOffset
+		}
+		lags[t] = 0
+		for _, p := range offsets.Topics[t] {
+			if l, ok := lastOffsets[p.Partition]; ok && p.Error == nil && p.CommittedOffset >= 0 && l > p.CommittedOffset {
+				lags[t] += l - p.CommittedOffset
+			}
+		}
+	}
+	return lags, nil
+}
+
+// unknownLags returns the exported topics of the consumer groups with the NaN lag,
+// it is used if the lag could not be checked
+func (k *Kafka) unknownLags(groups ...string) map[string]map[string]float64 {
+	lags := map[string]map[string]float64{}
+	for _, group := range groups {
+		for t := range k.lagTopics[group] {
+			if lags[group] == nil {
+				lags[group] = map[string]float64{}
+			}
+			lags[group][t] = math.NaN()
+		}
+	}
+	return lags
+}
+
+// ExportMetrics export the lag of the consumer groups on the topics.
+// The lag is NaN if it is unknown, and the topics which are not consumed any more are removed.
+func (k *Kafka) ExportMetrics(lags map[string]map[string]float64) {
+	if k.metrics == nil {
+		return
+	}
+	labels := func(group, topic string) prometheus.Labels {
+		return metric.AddConstLabels(prometheus.Labels{
+			"name":  k.ProbeName,
+			"group": group,
+			"topic": topic,
+		}, k.Labels)
+	}
+	for group, topics := range k.lagTopics {
+		for t := range topics {
+			if _, ok := lags[group][t]; !ok {
+				k.metrics.ConsumerLag.Delete(labels(group, t))
+			}
+		}
+	}
+	k.lagTopics = map[string]map[string]bool{}
+	for group, topics := range lags {
+		k.lagTopics[group] = map[string]bool{}
+		for t, lag := range topics {
+			k.metrics.ConsumerLag.With(labels(group, t)).Set(lag)
+			k.lagTopics[group][t] = true
+		}
+	}
+}
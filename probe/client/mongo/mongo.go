@@ -40,6 +40,9 @@ type Mongo struct {
 	ConnStr      string                 `yaml:"conn_str,omitempty" json:"conn_str,omitempty"`
 	ClientOpt    *options.ClientOptions `yaml:"-" json:"-"`
 	Context      context.Context        `yaml:"-" json:"-"`
+
+	metrics    *metrics        `yaml:"-" json:"-"`
+	lagMembers map[string]bool `yaml:"-" json:"-"`
 }
 
 // New create a Mongo client
@@ -82,6 +85,17 @@ func New(opt conf.Options) (*Mongo, error) {
 	if err := mongo.checkData(); err != nil {
 		return nil, err
 	}
+	if err := mongo.checkQuery(); err != nil {
+		log.Errorf("[%s / %s / %s] - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
+		return nil, err
+	}
+	if err := mongo.Replication.Check(); err != nil {
+		log.Errorf("[%s / %s / %s] - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
+		return nil, err
+	}
+	if mongo.Replication.Enable {
+		mongo.metrics = newMetrics(opt.ProbeKind, opt.ProbeTag, opt.Labels)
+	}
 
 	return mongo, nil
 }
@@ -153,7 +167,22 @@ func (r *Mongo) Probe() (bool, string) {
 		}
 	}
 
-	return true, "Check MongoDB Server Successfully!"
+	message := "Check MongoDB Server Successfully!"
+	if r.Replication.Enable {
+		ok, msg := r.ProbeReplicaSet(ctx, db)
+		if !ok {
+			return false, msg
+		}
+		message += " " + msg
+	}
+	if r.HasQuery() {
+		ok, msg := r.ProbeQuery(ctx, db)
+		if !ok {
+			return false, msg
+		}
+		message += " " + msg
+	}
+	return true, message
 
 }
 
@@ -21,14 +21,19 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/megaease/easeprobe/global"
 	"github.com/megaease/easeprobe/monkey"
 	"github.com/megaease/easeprobe/probe/client/conf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -200,3 +205,192 @@ func TestDta(t *testing.T) {
 
 	monkey.UnpatchAll()
 }
+
+func TestReplicaSet(t *testing.T) {
+	opt := conf.Options{
+		Host:        "example.com:27017",
+		DriverType:  conf.Mongo,
+		Replication: conf.Replication{Enable: true, MinReplicas: 2},
+	}
+	opt.ProbeKind = "client"
+	opt.ProbeTag = "mongo"
+	opt.ProbeName = "mongo"
+	mg, err := New(opt)
+	assert.Nil(t, err)
+	assert.NotNil(t, mg.metrics)
+
+	monkey.Patch(mongo.Connect, func(ctx context.Context, opts ...*options.ClientOptions) (*mongo.Client, error) {
+		return &mongo.Client{}, nil
+	})
+	var client *mongo.Client
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "Disconnect", func(_ *mongo.Client, _ context.Context) error {
+		return nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "Ping", func(_ *mongo.Client, ctx context.Context, rp *readpref.ReadPref) error {
+		return nil
+	})
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	member := func(name string, health int, state string, optime time.Time) bson.M {
+		return bson.M{"name": name, "health": float64(health), "stateStr": state, "optimeDate": optime}
+	}
+	members := []bson.M{
+		member("mongo1:27017", 1, "PRIMARY", now),
+		member("mongo2:27017", 1, "SECONDARY", now.Add(-2*time.Second)),
+		member("mongo3:27017", 1, "SECONDARY", now),
+	}
+	var cmdErr error
+	var db *mongo.Database
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "RunCommand", func(_ *mongo.Database, _ context.Context, cmd interface{}, _ ...*options.RunCmdOptions) *mongo.SingleResult {
+		return mongo.NewSingleResultFromDocument(bson.M{"set": "rs0", "members": members}, cmdErr, nil)
+	})
+
+	lag := func(member string) float64 {
+		return testutil.ToFloat64(mg.metrics.ReplicationLag.With(prometheus.Labels{"name": "mongo", "member": member}))
+	}
+
+	s, m := mg.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Successfully! Replica Set [rs0]: primary [mongo1:27017], 2 secondary(s) - [mongo2:27017] replication lag 2s; [mongo3:27017] replication lag 0s")
+
+	// lag
+	members[1]["optimeDate"] = now.Add(-time.Minute)
+	s, m = mg.Probe()
+	assert.False(t, s)
+	assert.Equal(t, "Replica Set [rs0] Error: [mongo2:27017] replication lag 1m0s > 30s", m)
+	assert.Equal(t, 60.0, lag("mongo2:27017"))
+	assert.Equal(t, 0.0, lag("mongo3:27017"))
+
+	// unhealthy member
+	members[1] = member("mongo2:27017", 0, "(not reachable/healthy)", time.Time{})
+	members[2]["stateStr"] = "RECOVERING"
+	s, m = mg.Probe()
+	assert.False(t, s)
+	assert.Equal(t, "Replica Set [rs0] Error: [mongo2:27017] is down ((not reachable/healthy)); [mongo3:27017] is RECOVERING; 0 secondary(s) healthy, expected at least 2", m)
+	assert.True(t, math.IsNaN(lag("mongo2:27017")))
+	assert.True(t, math.IsNaN(lag("mongo3:27017")))
+
+	// the series of the member which is removed from the replica set is removed
+	members = members[:2]
+	mg.Probe()
+	assert.Equal(t, 1, testutil.CollectAndCount(mg.metrics.ReplicationLag))
+	members = append(members, member("mongo3:27017", 1, "RECOVERING", now))
+
+	// no primary
+	members[0]["stateStr"] = "SECONDARY"
+	s, m = mg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Replica Set [rs0] Error: no primary")
+
+	// not a replica set
+	cmdErr = fmt.Errorf("not running with --replSet")
+	s, m = mg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Replica Set Error - not running with --replSet")
+
+	monkey.UnpatchAll()
+}
+
+func TestQuery(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com:27017",
+		DriverType: conf.Mongo,
+		Query:      `{"status": "failed"}`,
+		Mongo:      conf.MongoOptions{Operation: "aggregate"},
+	}
+	opt.ProbeTimeout = time.Second
+
+	_, err := New(opt)
+	assert.ErrorContains(t, err, "Invalid MongoDB operation")
+
+	opt.Mongo.Operation = ""
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "the database and the collection are required")
+
+	opt.Database = "test"
+	opt.Mongo.Collection = "jobs"
+	opt.Query = "{status: "
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "Invalid Query")
+
+	opt.Query = ""
+	opt.Evaluator.Expression = "x_int('//count') == 0"
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "requires the query")
+
+	opt.Query = `{"status": "failed"}`
+	opt.Evaluator.Expression = "x_int('//count') == 1 && x_str('//rows/*[1]/name') == 'job1' && x_int('//rows/*[1]/retries') < 3"
+	mg, err := New(opt)
+	assert.Nil(t, err)
+	assert.Equal(t, conf.MongoFind, mg.Mongo.Operation)
+
+	monkey.Patch(mongo.Connect, func(ctx context.Context, opts ...*options.ClientOptions) (*mongo.Client, error) {
+		return &mongo.Client{}, nil
+	})
+	var client *mongo.Client
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "Disconnect", func(_ *mongo.Client, _ context.Context) error {
+		return nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "Ping", func(_ *mongo.Client, ctx context.Context, rp *readpref.ReadPref) error {
+		return nil
+	})
+
+	docs := []interface{}{bson.M{"name": "job1", "status": "failed", "retries": 2}}
+	var findErr error
+	var collection *mongo.Collection
+	monkey.PatchInstanceMethod(reflect.TypeOf(collection), "Find", func(c *mongo.Collection, _ context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+		assert.Equal(t, "jobs", c.Name())
+		assert.Equal(t, "test", c.Database().Name())
+		assert.Equal(t, int64(conf.MaxQueryRows), *opts[0].Limit)
+		if findErr != nil {
+			return nil, findErr
+		}
+		return mongo.NewCursorFromDocuments(docs, nil, nil)
+	})
+	total := int64(5)
+	monkey.PatchInstanceMethod(reflect.TypeOf(collection), "CountDocuments", func(_ *mongo.Collection, _ context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+		return total, findErr
+	})
+
+	s, m := mg.Probe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "Successfully! Query returned 1 row(s)")
+
+	docs[0].(bson.M)["retries"] = 3
+	s, m = mg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Expression is evaluated to false!")
+
+	findErr = fmt.Errorf("unauthorized")
+	s, m = mg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Find [{\"status\": \"failed\"}] Error - unauthorized")
+
+	// the count is of all documents even if the find is limited
+	findErr = nil
+	docs = docs[:0]
+	for i := 0; i < conf.MaxQueryRows; i++ {
+		docs = append(docs, bson.M{"name": fmt.Sprintf("job%d", i+1), "status": "failed", "retries": 1})
+	}
+	total = 150
+	s, m = mg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Query returned 150 row(s)")
+	total = 5
+
+	// count
+	opt.Mongo.Operation = "count"
+	opt.Evaluator.Expression = "x_int('//count') > 3"
+	mg, err = New(opt)
+	assert.Nil(t, err)
+	s, m = mg.Probe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "Query returned 5 row(s)")
+
+	findErr = fmt.Errorf("unauthorized")
+	s, m = mg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Count [{\"status\": \"failed\"}] Error - unauthorized")
+
+	monkey.UnpatchAll()
+}
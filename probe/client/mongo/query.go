@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/megaease/easeprobe/probe/client/conf"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// checkQuery checks the filter of the query and configures the evaluator
+func (r *Mongo) checkQuery() error {
+	if err := r.Mongo.Check(); err != nil {
+		return err
+	}
+	if !r.HasQuery() {
+		if len(strings.TrimSpace(r.Evaluator.Expression)) > 0 {
+			return fmt.Errorf("the eval expression requires the query")
+		}
+		return nil
+	}
+	if len(strings.TrimSpace(r.Database)) <= 0 || len(strings.TrimSpace(r.Mongo.Collection)) <= 0 {
+		return fmt.Errorf("the database and the collection are required for the query")
+	}
+	var filter interface{}
+	if err := bson.UnmarshalExtJSON([]byte(r.Query), true, &filter); err != nil {
+		return fmt.Errorf("Invalid Query - [%s], %v", r.Query, err)
+	}
+	return r.ConfigEvaluator()
+}
+
+// ProbeQuery runs the find or count query and evaluates the result.
+// The documents are converted to the relaxed MongoDB Extended JSON,
+// only the first conf.MaxQueryRows documents are found, but the count is of all documents.
+func (r *Mongo) ProbeQuery(ctx context.Context, db *mongo.Client) (bool, string) {
+	var filter interface{}
+	if err := bson.UnmarshalExtJSON([]byte(r.Query), true, &filter); err != nil {
+		return false, fmt.Sprintf("Invalid Query - [%s], %v", r.Query, err)
+	}
+	collection := db.Database(r.Database).Collection(r.Mongo.Collection)
+	log.Debugf("[%s / %s / %s] - Query - %s %s.%s [%s]", r.ProbeKind, r.ProbeName, r.ProbeTag,
+		r.Mongo.Operation, r.Database, r.Mongo.Collection, r.Query)
+
+	result := &conf.QueryResult{Columns: []string{}, Rows: []map[string]interface{}{}}
+	if r.Mongo.Operation == conf.MongoCount {
+		n, err := collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return false, fmt.Sprintf("Count [%s] Error - %v", r.Query, err)
+		}
+		result.Count = int(n)
+		return r.EvaluateResult(result)
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetLimit(conf.MaxQueryRows))
+	if err != nil {
+		return false, fmt.Sprintf("Find [%s] Error - %v", r.Query, err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		doc, err := bson.MarshalExtJSON(cursor.Current, false, false)
+		if err != nil {
+			return false, fmt.Sprintf("Find [%s] Error - %v", r.Query, err)
+		}
+		row := map[string]interface{}{}
+		if err := json.Unmarshal(doc, &row); err != nil {
+			return false, fmt.Sprintf("Find [%s] Error - %v", r.Query, err)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := cursor.Err(); err != nil {
+		return false, fmt.Sprintf("Find [%s] Error - %v", r.Query, err)
+	}
+	result.Count = len(result.Rows)
+	if result.Count >= conf.MaxQueryRows {
+		// the find is limited, so the documents are counted with the same filter
+		n, err := collection.CountDocuments(ctx, filter)
+		if err != nil {
+			return false, fmt.Sprintf("Count [%s] Error - %v", r.Query, err)
+		}
+		result.Count = int(n)
+	}
+	return r.EvaluateResult(result)
+}
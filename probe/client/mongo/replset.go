@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/megaease/easeprobe/metric"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// the states of the healthy replica set members
+const (
+	statePrimary   = "PRIMARY"
+	stateSecondary = "SECONDARY"
+	stateArbiter   = "ARBITER"
+)
+
+// replSetStatus is the result of the `replSetGetStatus` command
+type replSetStatus struct {
+	Set     string          `bson:"set"`
+	Members []replSetMember `bson:"members"`
+}
+
+// replSetMember is the status of the replica set member
+type replSetMember struct {
+	Name       string    `bson:"name"`
+	Health     float64   `bson:"health"`
+	StateStr   string    `bson:"stateStr"`
+	OptimeDate time.Time `bson:"optimeDate"`
+}
+
+// ProbeReplicaSet checks the member states and the optime lag of the replica set
+func (r *Mongo) ProbeReplicaSet(ctx context.Context, db *mongo.Client) (bool, string) {
+	var status replSetStatus
+	result := db.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetGetStatus", Value: 1}})
+	if err := result.Decode(&status); err != nil {
+		r.ExportMetrics(nil)
+		return false, fmt.Sprintf("Replica Set Error - %v", err)
+	}
+	return r.checkReplicaSet(&status)
+}
+
+// checkReplicaSet checks there is a primary, all of the members are healthy,
+// and the optime lag of the secondaries is less than the max lag
+func (r *Mongo) checkReplicaSet(status *replSetStatus) (bool, string) {
+	var primary *replSetMember
+	failed := []string{}
+	for i, m := range status.Members {
+		switch {
+		case m.Health != 1:
+			failed = append(failed, fmt.Sprintf("[%s] is down (%s)", m.Name, m.StateStr))
+		case m.StateStr == statePrimary:
+			primary = &status.Members[i]
+		case m.StateStr != stateSecondary && m.StateStr != stateArbiter:
+			failed = append(failed, fmt.Sprintf("[%s] is %s", m.Name, m.StateStr))
+		}
+	}
+	// the lag of the members is unknown unless they are healthy secondaries and there is a primary
+	lags := map[string]float64{}
+	for _, m := range status.Members {
+		if m.StateStr != statePrimary && m.StateStr != stateArbiter {
+			lags[m.Name] = math.NaN()
+		}
+	}
+	if primary == nil {
+		r.ExportMetrics(lags)
+		failed = append([]string{"no primary"}, failed...)
+		return false, fmt.Sprintf("Replica Set [%s] Error: %s", status.Set, strings.Join(failed, "; "))
+	}
+
+	secondaries := 0
+	messages := []string{}
+	for _, m := range status.Members {
+		if m.Health != 1 || m.StateStr != stateSecondary {
+			continue
+		}
+		secondaries++
+		lag := primary.OptimeDate.Sub(m.OptimeDate)
+		if lag < 0 {
+			lag = 0
+		}
+		lags[m.Name] = lag.Seconds()
+		log.Debugf("[%s / %s / %s] - Replica Set [%s] - member [%s] lag %s",
+			r.ProbeKind, r.ProbeName, r.ProbeTag, status.Set, m.Name, lag)
+		ok, msg := r.Replication.CheckLag(m.Name, lag.Seconds())
+		if !ok {
+			failed = append(failed, msg)
+		} else {
+			messages = append(messages, msg)
+		}
+	}
+	r.ExportMetrics(lags)
+	if secondaries < r.Replication.MinReplicas {
+		failed = append(failed, fmt.Sprintf("%d secondary(s) healthy, expected at least %d",
+			secondaries, r.Replication.MinReplicas))
+	}
+
+	if len(failed) > 0 {
+		return false, fmt.Sprintf("Replica Set [%s] Error: %s", status.Set, strings.Join(failed, "; "))
+	}
+	return true, fmt.Sprintf("Replica Set [%s]: primary [%s], %d secondary(s) - %s",
+		status.Set, primary.Name, secondaries, strings.Join(messages, "; "))
+}
+
+// ExportMetrics export the optime lag (seconds) of the replica set members.
+// The lag is NaN if it is unknown, and the members which are gone are removed.
+func (r *Mongo) ExportMetrics(lags map[string]float64) {
+	if r.metrics == nil {
+		return
+	}
+	labels := func(member string) prometheus.Labels {
+		return metric.AddConstLabels(prometheus.Labels{
+			"name":   r.ProbeName,
+			"member": member,
+		}, r.Labels)
+	}
+	for member := range r.lagMembers {
+		if _, ok := lags[member]; !ok {
+			r.metrics.ReplicationLag.Delete(labels(member))
+		}
+	}
+	r.lagMembers = map[string]bool{}
+	for member, lag := range lags {
+		r.metrics.ReplicationLag.With(labels(member)).Set(lag)
+		r.lagMembers[member] = true
+	}
+}
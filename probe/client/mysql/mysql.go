@@ -40,6 +40,9 @@ type MySQL struct {
 	conf.Options `yaml:",inline"`
 	tls          *tls.Config `yaml:"-" json:"-"`
 	ConnStr      string      `yaml:"conn_str,omitempty" json:"conn_str,omitempty"`
+
+	metrics     *metrics        `yaml:"-" json:"-"`
+	lagChannels map[string]bool `yaml:"-" json:"-"`
 }
 
 // New create a Mysql client
@@ -47,11 +50,11 @@ func New(opt conf.Options) (*MySQL, error) {
 
 	var conn string
 	if len(opt.Password) > 0 {
-		conn = fmt.Sprintf("%s:%s@tcp(%s)/?timeout=%s",
-			opt.Username, opt.Password, opt.Host, opt.Timeout().Round(time.Second))
+		conn = fmt.Sprintf("%s:%s@tcp(%s)/%s?timeout=%s",
+			opt.Username, opt.Password, opt.Host, opt.Database, opt.Timeout().Round(time.Second))
 	} else {
-		conn = fmt.Sprintf("%s@tcp(%s)/?timeout=%s",
-			opt.Username, opt.Host, opt.Timeout().Round(time.Second))
+		conn = fmt.Sprintf("%s@tcp(%s)/%s?timeout=%s",
+			opt.Username, opt.Host, opt.Database, opt.Timeout().Round(time.Second))
 	}
 
 	tls, err := opt.TLS.Config()
@@ -71,6 +74,17 @@ func New(opt conf.Options) (*MySQL, error) {
 	if err := m.checkData(); err != nil {
 		return nil, err
 	}
+	if err := m.CheckQuery(); err != nil {
+		log.Errorf("[%s / %s / %s] - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
+		return nil, err
+	}
+	if err := m.Replication.Check(); err != nil {
+		log.Errorf("[%s / %s / %s] - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
+		return nil, err
+	}
+	if m.Replication.Enable {
+		m.metrics = newMetrics(opt.ProbeKind, opt.ProbeTag, opt.Labels)
+	}
 	return m, nil
 }
 
@@ -115,7 +129,27 @@ func (r *MySQL) Probe() (bool, string) {
 		}
 	}
 
-	return true, "Check MySQL Server Successfully!"
+	message := "Check MySQL Server Successfully!"
+
+	// Check the replication health
+	if r.Replication.Enable {
+		ok, msg := r.ProbeWithReplication(db)
+		if !ok {
+			return false, msg
+		}
+		message += " " + msg
+	}
+
+	// Check the result of the free-form query
+	if r.HasQuery() {
+		ok, msg := r.RunQuery(db)
+		if !ok {
+			return false, msg
+		}
+		message += " " + msg
+	}
+
+	return true, message
 
 }
 
@@ -235,3 +235,57 @@ func TestData(t *testing.T) {
 	monkey.UnpatchAll()
 
 }
+
+func TestQuery(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com",
+		DriverType: conf.MySQL,
+		Username:   "username",
+		Database:   "test",
+		Query:      "DELETE FROM failed_jobs",
+	}
+	my, err := New(opt)
+	assert.Nil(t, my)
+	assert.Contains(t, err.Error(), "only the read-only statement is allowed")
+
+	opt.Query = "SELECT COUNT(*) AS n FROM failed_jobs"
+	opt.Evaluator.Expression = "x_int('//rows/*[1]/n') == 0"
+	my, err = New(opt)
+	assert.Nil(t, err)
+	assert.Equal(t, fmt.Sprintf("username@tcp(example.com)/test?timeout=%s", opt.Timeout().Round(time.Second)), my.ConnStr)
+
+	monkey.Patch(sql.Open, func(driverName, dataSourceName string) (*sql.DB, error) {
+		return &sql.DB{}, nil
+	})
+	var db *sql.DB
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Close", func(_ *sql.DB) error {
+		return nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Ping", func(_ *sql.DB) error {
+		return nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Query", func(_ *sql.DB, query string, args ...interface{}) (*sql.Rows, error) {
+		return &sql.Rows{}, nil
+	})
+	var r *sql.Rows
+	monkey.PatchInstanceMethod(reflect.TypeOf(r), "Close", func(_ *sql.Rows) error {
+		return nil
+	})
+	var o *conf.Options
+	monkey.PatchInstanceMethod(reflect.TypeOf(o), "RunQuery", func(_ *conf.Options, _ *sql.DB) (bool, string) {
+		return true, "Query returned 1 row(s)"
+	})
+
+	s, m := my.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Successfully! Query returned 1 row(s)")
+
+	monkey.PatchInstanceMethod(reflect.TypeOf(o), "RunQuery", func(_ *conf.Options, _ *sql.DB) (bool, string) {
+		return false, "Query returned 1 row(s). Expression is evaluated to false!"
+	})
+	s, m = my.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Expression is evaluated to false!")
+
+	monkey.UnpatchAll()
+}
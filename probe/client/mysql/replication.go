@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe/client/conf"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// the statements of the replication status, the former is for MySQL 8.0.22+,
+// the latter is for the old versions and MariaDB
+var (
+	replicaStatusSQL = []string{"SHOW REPLICA STATUS", "SHOW SLAVE STATUS"}
+	replicasSQL      = []string{"SHOW REPLICAS", "SHOW SLAVE HOSTS"}
+)
+
+// queryFallback runs the statements in order until one of them succeeds
+func queryFallback(db *sql.DB, statements []string) (*conf.QueryResult, error) {
+	var err error
+	for _, s := range statements {
+		var rows *sql.Rows
+		if rows, err = db.Query(s); err != nil {
+			continue
+		}
+		defer rows.Close()
+		return conf.ScanRows(rows)
+	}
+	return nil, err
+}
+
+// column returns the value of the first column which exists in the row
+func column(row map[string]interface{}, names ...string) interface{} {
+	for _, n := range names {
+		if v, ok := row[n]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// ProbeWithReplication do the health check of the replication
+func (r *MySQL) ProbeWithReplication(db *sql.DB) (bool, string) {
+	if r.Replication.Role == conf.RolePrimary {
+		return r.checkReplicas(db)
+	}
+	return r.checkReplicaStatus(db)
+}
+
+// checkReplicaStatus checks the IO/SQL threads and the lag of every replication channel
+func (r *MySQL) checkReplicaStatus(db *sql.DB) (bool, string) {
+	result, err := queryFallback(db, replicaStatusSQL)
+	if err != nil {
+		r.ExportMetrics(nil)
+		return false, fmt.Sprintf("Replica status error - %v", err)
+	}
+	if result.Count <= 0 {
+		r.ExportMetrics(nil)
+		return false, "Replication Error: the server is not a replica"
+	}
+
+	status := true
+	messages := []string{}
+	lags := map[string]float64{}
+	for _, row := range result.Rows {
+		channel := conf.ValueString(column(row, "Channel_Name"))
+		if len(channel) <= 0 {
+			channel = fmt.Sprintf("%s:%s", conf.ValueString(column(row, "Source_Host", "Master_Host")),
+				conf.ValueString(column(row, "Source_Port", "Master_Port")))
+		}
+		ioRunning := conf.ValueString(column(row, "Replica_IO_Running", "Slave_IO_Running"))
+		sqlRunning := conf.ValueString(column(row, "Replica_SQL_Running", "Slave_SQL_Running"))
+		lag, hasLag := conf.ValueFloat(column(row, "Seconds_Behind_Source", "Seconds_Behind_Master"))
+		// the lag is unknown if the replica doesn't apply the changes
+		lags[channel] = math.NaN()
+		if hasLag && ioRunning == "Yes" && sqlRunning == "Yes" {
+			lags[channel] = lag
+		}
+		log.Debugf("[%s / %s / %s] - Replication [%s] - IO: %s, SQL: %s, Lag: %v",
+			r.ProbeKind, r.ProbeName, r.ProbeTag, channel, ioRunning, sqlRunning, lag)
+
+		switch {
+		case ioRunning != "Yes":
+			status = false
+			messages = append(messages, strings.TrimSpace(fmt.Sprintf("[%s] IO thread is not running (%s) %s",
+				channel, ioRunning, conf.ValueString(column(row, "Last_IO_Error")))))
+		case sqlRunning != "Yes":
+			status = false
+			messages = append(messages, strings.TrimSpace(fmt.Sprintf("[%s] SQL thread is not running (%s) %s",
+				channel, sqlRunning, conf.ValueString(column(row, "Last_SQL_Error")))))
+		case !hasLag:
+			status = false
+			messages = append(messages, fmt.Sprintf("[%s] replication lag is unknown", channel))
+		default:
+			ok, msg := r.Replication.CheckLag(channel, lag)
+			status = status && ok
+			messages = append(messages, msg)
+		}
+	}
+	r.ExportMetrics(lags)
+	if !status {
+		return false, "Replication Error: " + strings.Join(messages, "; ")
+	}
+	return true, "Replication: " + strings.Join(messages, "; ")
+}
+
+// checkReplicas checks the number of the replicas which connect to the primary,
+// no lag is exported because `SHOW REPLICAS` does not report it
+func (r *MySQL) checkReplicas(db *sql.DB) (bool, string) {
+	result, err := queryFallback(db, replicasSQL)
+	if err != nil {
+		return false, fmt.Sprintf("Replicas error - %v", err)
+	}
+	if result.Count < r.Replication.MinReplicas {
+		return false, fmt.Sprintf("Replication Error: %d replica(s) connected, expected at least %d",
+			result.Count, r.Replication.MinReplicas)
+	}
+	return true, fmt.Sprintf("Replication: %d replica(s) connected", result.Count)
+}
+
+// ExportMetrics export the replication lag (seconds) of the channels.
+// The lag is NaN if it is unknown, and the channels which are gone are removed.
+func (r *MySQL) ExportMetrics(lags map[string]float64) {
+	if r.metrics == nil {
+		return
+	}
+	labels := func(channel string) prometheus.Labels {
+		return metric.AddConstLabels(prometheus.Labels{
+			"name":    r.ProbeName,
+			"channel": channel,
+		}, r.Labels)
+	}
+	for channel := range r.lagChannels {
+		if _, ok := lags[channel]; !ok {
+			r.metrics.ReplicationLag.Delete(labels(channel))
+		}
+	}
+	r.lagChannels = map[string]bool{}
+	for channel, lag := range lags {
+		r.metrics.ReplicationLag.With(labels(channel)).Set(lag)
+		r.lagChannels[channel] = true
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/megaease/easeprobe/monkey"
+	"github.com/megaease/easeprobe/probe/client/conf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplication(t *testing.T) {
+	opt := conf.Options{
+		Host:        "example.com:3306",
+		DriverType:  conf.MySQL,
+		Username:    "username",
+		Replication: conf.Replication{Enable: true, Role: "leader"},
+	}
+	opt.ProbeKind = "client"
+	opt.ProbeTag = "mysql"
+	opt.ProbeName = "replica"
+
+	my, err := New(opt)
+	assert.Nil(t, my)
+	assert.ErrorContains(t, err, "Invalid replication role")
+
+	opt.Replication.Role = ""
+	my, err = New(opt)
+	assert.Nil(t, err)
+	assert.Equal(t, conf.RoleReplica, my.Replication.Role)
+	assert.NotNil(t, my.metrics)
+
+	monkey.Patch(sql.Open, func(driverName, dataSourceName string) (*sql.DB, error) {
+		return &sql.DB{}, nil
+	})
+	var db *sql.DB
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Close", func(_ *sql.DB) error {
+		return nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Ping", func(_ *sql.DB) error {
+		return nil
+	})
+	statements := []string{}
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Query", func(_ *sql.DB, query string, args ...interface{}) (*sql.Rows, error) {
+		statements = append(statements, query)
+		if query == "SHOW REPLICA STATUS" || query == "SHOW REPLICAS" {
+			return nil, fmt.Errorf("syntax error")
+		}
+		return &sql.Rows{}, nil
+	})
+	var r *sql.Rows
+	monkey.PatchInstanceMethod(reflect.TypeOf(r), "Close", func(_ *sql.Rows) error {
+		return nil
+	})
+
+	rows := []map[string]interface{}{}
+	monkey.Patch(conf.ScanRows, func(_ *sql.Rows) (*conf.QueryResult, error) {
+		return &conf.QueryResult{Count: len(rows), Rows: rows}, nil
+	})
+
+	lag := func(channel string) float64 {
+		return testutil.ToFloat64(my.metrics.ReplicationLag.With(prometheus.Labels{"name": "replica", "channel": channel}))
+	}
+
+	// not a replica
+	s, m := my.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "not a replica")
+	assert.Contains(t, statements, "SHOW SLAVE STATUS")
+
+	// healthy
+	rows = []map[string]interface{}{{
+		"Master_Host":           "primary",
+		"Master_Port":           "3306",
+		"Slave_IO_Running":      "Yes",
+		"Slave_SQL_Running":     "Yes",
+		"Seconds_Behind_Master": "3",
+	}}
+	s, m = my.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Successfully! Replication: [primary:3306] replication lag 3s")
+	assert.Equal(t, 3.0, lag("primary:3306"))
+
+	// lag
+	rows[0]["Seconds_Behind_Master"] = "45"
+	s, m = my.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Replication Error: [primary:3306] replication lag 45s > 30s")
+
+	// SQL thread stopped
+	rows = append(rows, map[string]interface{}{
+		"Channel_Name":          "analytics",
+		"Replica_IO_Running":    "Yes",
+		"Replica_SQL_Running":   "No",
+		"Seconds_Behind_Source": nil,
+		"Last_SQL_Error":        "Duplicate entry",
+	})
+	rows[0]["Seconds_Behind_Master"] = "1"
+	s, m = my.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "[analytics] SQL thread is not running (No) Duplicate entry")
+	assert.True(t, math.IsNaN(lag("analytics")))
+	assert.Equal(t, 1.0, lag("primary:3306"))
+
+	// IO thread connecting
+	rows = rows[:1]
+	rows[0]["Slave_IO_Running"] = "Connecting"
+	rows[0]["Seconds_Behind_Master"] = nil
+	s, m = my.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "[primary:3306] IO thread is not running (Connecting)")
+	assert.True(t, math.IsNaN(lag("primary:3306")))
+	// the series of the channel which is gone is removed
+	assert.Equal(t, 1, testutil.CollectAndCount(my.metrics.ReplicationLag))
+
+	// lag unknown
+	rows[0]["Slave_IO_Running"] = "Yes"
+	s, m = my.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "replication lag is unknown")
+
+	// primary
+	my.Replication.Role = conf.RolePrimary
+	my.Replication.MinReplicas = 2
+	s, m = my.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "1 replica(s) connected, expected at least 2")
+	assert.Contains(t, statements, "SHOW SLAVE HOSTS")
+
+	rows = append(rows, rows[0])
+	s, m = my.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Replication: 2 replica(s) connected")
+
+	// query error
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Query", func(_ *sql.DB, query string, args ...interface{}) (*sql.Rows, error) {
+		return nil, fmt.Errorf("access denied")
+	})
+	s, m = my.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "access denied")
+
+	monkey.UnpatchAll()
+}
@@ -39,6 +39,9 @@ const Kind string = "PostgreSQL"
 type PostgreSQL struct {
 	conf.Options  `yaml:",inline"`
 	ClientOptions []pgdriver.Option `yaml:"-" json:"-"`
+
+	metrics     *metrics        `yaml:"-" json:"-"`
+	lagReplicas map[string]bool `yaml:"-" json:"-"`
 }
 
 // revive:enable
@@ -73,6 +76,17 @@ func New(opt conf.Options) (*PostgreSQL, error) {
 	if err := pg.checkData(); err != nil {
 		return nil, err
 	}
+	if err := pg.CheckQuery(); err != nil {
+		log.Errorf("[%s / %s / %s] - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
+		return nil, err
+	}
+	if err := pg.Replication.Check(); err != nil {
+		log.Errorf("[%s / %s / %s] - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
+		return nil, err
+	}
+	if pg.Replication.Enable {
+		pg.metrics = newMetrics(opt.ProbeKind, opt.ProbeTag, opt.Labels)
+	}
 	return pg, nil
 }
 
@@ -97,16 +111,43 @@ func (r *PostgreSQL) checkData() error {
 // Probe do the health check
 func (r *PostgreSQL) Probe() (bool, string) {
 
+	if len(r.Data) <= 0 && !r.Replication.Enable && !r.HasQuery() {
+		return r.ProbeWithPing()
+	}
+
+	message := "Check PostgreSQL Server Successfully!"
 	if len(r.Data) > 0 {
-		return r.ProbeWithDataChecking()
+		if ok, msg := r.ProbeWithDataChecking(); !ok {
+			return ok, msg
+		}
+	}
+	if r.Replication.Enable {
+		ok, msg := r.ProbeWithReplication()
+		if !ok {
+			return false, msg
+		}
+		message += " " + msg
 	}
-	return r.ProbeWithPing()
+	if r.HasQuery() {
+		ok, msg := r.ProbeWithQuery()
+		if !ok {
+			return false, msg
+		}
+		message += " " + msg
+	}
+	return true, message
+}
+
+// connector returns the connector of the database, the client options are copied,
+// so they are not shared by the connectors of the different databases
+func (r *PostgreSQL) connector(dbName string) *pgdriver.Connector {
+	clientOptions := append(append([]pgdriver.Option{}, r.ClientOptions...), pgdriver.WithDatabase(dbName))
+	return pgdriver.NewConnector(clientOptions...)
 }
 
 // ProbeWithPing do the health check with ping & Select 1;
 func (r *PostgreSQL) ProbeWithPing() (bool, string) {
-	r.ClientOptions = append(r.ClientOptions, pgdriver.WithDatabase("template1"))
-	db := sql.OpenDB(pgdriver.NewConnector(r.ClientOptions...))
+	db := sql.OpenDB(r.connector("template1"))
 	if db == nil {
 		return false, "OpenDB error"
 	}
@@ -125,6 +166,21 @@ func (r *PostgreSQL) ProbeWithPing() (bool, string) {
 	return true, "Check PostgreSQL Server Successfully!"
 }
 
+// ProbeWithQuery do the health check with the free-form query
+func (r *PostgreSQL) ProbeWithQuery() (bool, string) {
+	dbName := r.Database
+	if len(dbName) <= 0 {
+		dbName = "template1"
+	}
+	db := sql.OpenDB(r.connector(dbName))
+	if db == nil {
+		return false, "OpenDB error"
+	}
+	defer db.Close()
+
+	return r.RunQuery(db)
+}
+
 // ProbeWithDataChecking do the health check with data checking
 func (r *PostgreSQL) ProbeWithDataChecking() (bool, string) {
 	if len(r.Data) == 0 {
@@ -148,8 +204,7 @@ func (r *PostgreSQL) verifyData(k, v string) (bool, string) {
 	if err != nil {
 		return false, fmt.Sprintf("Invalid SQL data - [%s], %v", v, err)
 	}
-	clientOptions := append(r.ClientOptions, pgdriver.WithDatabase(dbName))
-	db := sql.OpenDB(pgdriver.NewConnector(clientOptions...))
+	db := sql.OpenDB(r.connector(dbName))
 	if db == nil {
 		return false, "OpenDB error"
 	}
@@ -85,6 +85,10 @@ func TestPostgreSQL(t *testing.T) {
 	s, m := pg.Probe()
 	assert.True(t, s)
 	assert.Contains(t, m, "Successfully")
+	// the client options are not changed by probing
+	n := len(pg.ClientOptions)
+	pg.Probe()
+	assert.Equal(t, n, len(pg.ClientOptions))
 
 	s, m = pg.ProbeWithDataChecking()
 	assert.True(t, s)
@@ -241,3 +245,53 @@ func TestData(t *testing.T) {
 
 	monkey.UnpatchAll()
 }
+
+func TestQuery(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com",
+		DriverType: conf.PostgreSQL,
+		Username:   "username",
+		Query:      "UPDATE jobs SET done = true",
+	}
+	pg, err := New(opt)
+	assert.Nil(t, pg)
+	assert.Contains(t, err.Error(), "only the read-only statement is allowed")
+
+	opt.Query = "SELECT EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) AS lag_seconds"
+	opt.Evaluator.Expression = "x_int('//rows/*[1]/lag_seconds') < 30"
+	pg, err = New(opt)
+	assert.Nil(t, err)
+
+	database := ""
+	monkey.Patch(pgdriver.WithDatabase, func(db string) pgdriver.Option {
+		database = db
+		return func(*pgdriver.Config) {}
+	})
+	monkey.Patch(sql.OpenDB, func(c driver.Connector) *sql.DB {
+		return &sql.DB{}
+	})
+	var db *sql.DB
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Close", func(_ *sql.DB) error {
+		return nil
+	})
+	var o *conf.Options
+	monkey.PatchInstanceMethod(reflect.TypeOf(o), "RunQuery", func(_ *conf.Options, _ *sql.DB) (bool, string) {
+		return true, "Query returned 1 row(s)"
+	})
+
+	s, m := pg.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Successfully! Query returned 1 row(s)")
+	assert.Equal(t, "template1", database)
+
+	pg.Database = "test"
+	monkey.PatchInstanceMethod(reflect.TypeOf(o), "RunQuery", func(_ *conf.Options, _ *sql.DB) (bool, string) {
+		return false, "Query returned 1 row(s). Expression is evaluated to false!"
+	})
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Expression is evaluated to false!")
+	assert.Equal(t, "test", database)
+
+	monkey.UnpatchAll()
+}
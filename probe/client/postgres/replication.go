@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe/client/conf"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// replicaStatusSQL is the SQL of the replica status (PostgreSQL 10+)
+//   - receiver: the status of the WAL receiver, it is `streaming` if the replica connects to the primary,
+//     it is NULL if the WAL receiver is running but the user has no privilege to read it (needs pg_monitor)
+//   - has_receiver: the WAL receiver is running or not
+//   - paused: the WAL replay is paused or not
+//   - lag_seconds: it is 0 if all of the received WAL has been replayed,
+//     otherwise it is the time since the last replayed transaction
+const replicaStatusSQL = `SELECT pg_is_in_recovery() AS in_recovery,
+	(SELECT status FROM pg_stat_wal_receiver LIMIT 1) AS receiver,
+	EXISTS (SELECT 1 FROM pg_stat_wal_receiver) AS has_receiver,
+	CASE WHEN pg_is_in_recovery() THEN pg_is_wal_replay_paused() ELSE false END AS paused,
+	CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END AS lag_seconds`
+
+// replicasSQL is the SQL of the replicas which connect to the primary (PostgreSQL 10+),
+// all of the columns except the pid are NULL if the user has no privilege to read them (needs pg_monitor)
+const replicasSQL = `SELECT pid, application_name, COALESCE(client_addr::text, '') AS client_addr, state,
+	COALESCE(EXTRACT(EPOCH FROM replay_lag), 0) AS lag_seconds FROM pg_stat_replication`
+
+// errPrivilege is the error of reading the replication status without the privilege
+const errPrivilege = "insufficient privilege (needs pg_monitor)"
+
+// ProbeWithReplication do the health check of the replication
+func (r *PostgreSQL) ProbeWithReplication() (bool, string) {
+	dbName := r.Database
+	if len(dbName) <= 0 {
+		dbName = "template1"
+	}
+	db := sql.OpenDB(r.connector(dbName))
+	if db == nil {
+		return false, "OpenDB error"
+	}
+	defer db.Close()
+
+	if r.Replication.Role == conf.RolePrimary {
+		return r.checkReplicas(db)
+	}
+	return r.checkReplicaStatus(db)
+}
+
+// query runs the SQL and reads all of the rows
+func query(db *sql.DB, sqlstr string) (*conf.QueryResult, error) {
+	rows, err := db.Query(sqlstr)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return conf.ScanRows(rows)
+}
+
+// checkReplicaStatus checks the WAL receiver, the WAL replay and the lag of the replica
+func (r *PostgreSQL) checkReplicaStatus(db *sql.DB) (bool, string) {
+	result, err := query(db, replicaStatusSQL)
+	if err != nil {
+		r.ExportMetrics(nil)
+		return false, fmt.Sprintf("Replica status error - %v", err)
+	}
+	if result.Count <= 0 {
+		r.ExportMetrics(nil)
+		return false, "Replica status error - no data found"
+	}
+	row := result.Rows[0]
+	receiver := conf.ValueString(row["receiver"])
+	lag, hasLag := conf.ValueFloat(row["lag_seconds"])
+	// the lag is unknown if the replica doesn't receive or replay the WAL
+	if hasLag && receiver == "streaming" && !conf.ValueBool(row["paused"]) {
+		r.ExportMetrics(map[string]float64{r.Host: lag})
+	} else {
+		r.ExportMetrics(map[string]float64{r.Host: math.NaN()})
+	}
+	log.Debugf("[%s / %s / %s] - Replication - in recovery: %v, receiver: %s, paused: %v, lag: %v",
+		r.ProbeKind, r.ProbeName, r.ProbeTag, row["in_recovery"], receiver, row["paused"], lag)
+
+	switch {
+	case !conf.ValueBool(row["in_recovery"]):
+		return false, "Replication Error: the server is not a replica"
+	case conf.ValueBool(row["has_receiver"]) && row["receiver"] == nil:
+		return false, "Replica status error - " + errPrivilege
+	case receiver != "streaming":
+		return false, fmt.Sprintf("Replication Error: WAL receiver is not streaming (%s)", receiver)
+	case conf.ValueBool(row["paused"]):
+		return false, "Replication Error: WAL replay is paused"
+	case !hasLag:
+		return false, "Replication Error: replication lag is unknown"
+	}
+	ok, msg := r.Replication.CheckLag(r.Host, lag)
+	if !ok {
+		return false, "Replication Error: " + msg
+	}
+	return true, "Replication: " + msg
+}
+
+// checkReplicas checks the state and the replay lag of the replicas which connect to the primary
+func (r *PostgreSQL) checkReplicas(db *sql.DB) (bool, string) {
+	result, err := query(db, replicasSQL)
+	if err != nil {
+		r.ExportMetrics(nil)
+		return false, fmt.Sprintf("Replicas error - %v", err)
+	}
+
+	for _, row := range result.Rows {
+		if row["state"] == nil {
+			r.ExportMetrics(nil)
+			return false, "Replicas error - " + errPrivilege
+		}
+	}
+
+	status := true
+	streaming := 0
+	messages := []string{}
+	lags := map[string]float64{}
+	for _, row := range result.Rows {
+		// the replicas without application name are distinguished by the pid
+		replica := conf.ValueString(row["application_name"])
+		if len(replica) <= 0 {
+			replica = "pid:" + conf.ValueString(row["pid"])
+		}
+		if addr := conf.ValueString(row["client_addr"]); len(addr) > 0 {
+			replica += "@" + addr
+		}
+		state := conf.ValueString(row["state"])
+		lag, _ := conf.ValueFloat(row["lag_seconds"])
+
+		if state != "streaming" {
+			lags[replica] = math.NaN()
+			messages = append(messages, fmt.Sprintf("[%s] state is %s", replica, state))
+			continue
+		}
+		streaming++
+		lags[replica] = lag
+		ok, msg := r.Replication.CheckLag(replica, lag)
+		status = status && ok
+		messages = append(messages, msg)
+	}
+	r.ExportMetrics(lags)
+	if streaming < r.Replication.MinReplicas {
+		status = false
+		messages = append([]string{fmt.Sprintf("%d replica(s) streaming, expected at least %d",
+			streaming, r.Replication.MinReplicas)}, messages...)
+	}
+	if !status {
+		return false, "Replication Error: " + strings.Join(messages, "; ")
+	}
+	return true, fmt.Sprintf("Replication: %d replica(s) streaming - %s", streaming, strings.Join(messages, "; "))
+}
+
+// ExportMetrics export the replication lag (seconds) of the replicas.
+// The lag is NaN if it is unknown, and the replicas which are gone are removed.
+func (r *PostgreSQL) ExportMetrics(lags map[string]float64) {
+	if r.metrics == nil {
+		return
+	}
+	labels := func(replica string) prometheus.Labels {
+		return metric.AddConstLabels(prometheus.Labels{
+			"name":    r.ProbeName,
+			"replica": replica,
+		}, r.Labels)
+	}
+	for replica := range r.lagReplicas {
+		if _, ok := lags[replica]; !ok {
+			r.metrics.ReplicationLag.Delete(labels(replica))
+		}
+	}
+	r.lagReplicas = map[string]bool{}
+	for replica, lag := range lags {
+		r.metrics.ReplicationLag.With(labels(replica)).Set(lag)
+		r.lagReplicas[replica] = true
+	}
+}
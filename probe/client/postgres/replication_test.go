@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/megaease/easeprobe/monkey"
+	"github.com/megaease/easeprobe/probe/client/conf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplication(t *testing.T) {
+	opt := conf.Options{
+		Host:        "example.com:5432",
+		DriverType:  conf.PostgreSQL,
+		Username:    "username",
+		Replication: conf.Replication{Enable: true, MaxLag: -1},
+	}
+	opt.ProbeKind = "client"
+	opt.ProbeTag = "postgres"
+	opt.ProbeName = "replica"
+
+	pg, err := New(opt)
+	assert.Nil(t, pg)
+	assert.ErrorContains(t, err, "Invalid replication max lag")
+
+	opt.Replication.MaxLag = 0
+	pg, err = New(opt)
+	assert.Nil(t, err)
+	assert.NotNil(t, pg.metrics)
+
+	monkey.Patch(sql.OpenDB, func(c driver.Connector) *sql.DB {
+		return &sql.DB{}
+	})
+	var db *sql.DB
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Close", func(_ *sql.DB) error {
+		return nil
+	})
+	statements := []string{}
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Query", func(_ *sql.DB, query string, args ...interface{}) (*sql.Rows, error) {
+		statements = append(statements, query)
+		return &sql.Rows{}, nil
+	})
+	var r *sql.Rows
+	monkey.PatchInstanceMethod(reflect.TypeOf(r), "Close", func(_ *sql.Rows) error {
+		return nil
+	})
+	rows := []map[string]interface{}{}
+	monkey.Patch(conf.ScanRows, func(_ *sql.Rows) (*conf.QueryResult, error) {
+		return &conf.QueryResult{Count: len(rows), Rows: rows}, nil
+	})
+
+	lag := func(replica string) float64 {
+		return testutil.ToFloat64(pg.metrics.ReplicationLag.With(prometheus.Labels{"name": "replica", "replica": replica}))
+	}
+
+	s, m := pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "no data found")
+	assert.Equal(t, replicaStatusSQL, statements[0])
+
+	row := map[string]interface{}{"in_recovery": false, "receiver": "", "paused": false, "lag_seconds": nil}
+	rows = []map[string]interface{}{row}
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "not a replica")
+
+	// the WAL receiver is running, but the user has no privilege to read it
+	row["in_recovery"] = true
+	row["has_receiver"] = true
+	row["receiver"] = nil
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "insufficient privilege (needs pg_monitor)")
+
+	row["receiver"] = "stopping"
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "WAL receiver is not streaming (stopping)")
+
+	row["receiver"] = "streaming"
+	row["paused"] = true
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "WAL replay is paused")
+
+	row["paused"] = false
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "replication lag is unknown")
+
+	row["lag_seconds"] = "2.5"
+	s, m = pg.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Successfully! Replication: [example.com:5432] replication lag 2.5s")
+	assert.Equal(t, 2.5, lag("example.com:5432"))
+
+	row["lag_seconds"] = float64(120)
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "replication lag 2m0s > 30s")
+
+	// the lag is unknown if the WAL receiver stops
+	row["receiver"] = "stopping"
+	row["lag_seconds"] = float64(0)
+	s, _ = pg.Probe()
+	assert.False(t, s)
+	assert.True(t, math.IsNaN(lag("example.com:5432")))
+
+	// primary
+	pg.Replication.Role = conf.RolePrimary
+	rows = []map[string]interface{}{
+		{"application_name": "replica1", "client_addr": "10.0.0.2", "state": "streaming", "lag_seconds": "0.5"},
+		{"application_name": "replica2", "client_addr": "", "state": "catchup", "lag_seconds": "0"},
+	}
+	s, m = pg.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Replication: 1 replica(s) streaming - [replica1@10.0.0.2] replication lag 500ms; [replica2] state is catchup")
+	assert.Equal(t, replicasSQL, statements[len(statements)-1])
+	assert.Equal(t, 0.5, lag("replica1@10.0.0.2"))
+	assert.True(t, math.IsNaN(lag("replica2")))
+	assert.Equal(t, 2, testutil.CollectAndCount(pg.metrics.ReplicationLag))
+
+	pg.Replication.MinReplicas = 2
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Replication Error: 1 replica(s) streaming, expected at least 2")
+
+	pg.Replication.MinReplicas = 1
+	rows[0]["lag_seconds"] = "31"
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "[replica1@10.0.0.2] replication lag 31s > 30s")
+
+	// the replicas without application name
+	rows = []map[string]interface{}{
+		{"pid": int64(101), "application_name": "", "client_addr": "", "state": "streaming", "lag_seconds": "1"},
+		{"pid": int64(102), "application_name": nil, "client_addr": "", "state": "streaming", "lag_seconds": "2"},
+	}
+	s, _ = pg.Probe()
+	assert.True(t, s)
+	assert.Equal(t, 1.0, lag("pid:101"))
+	assert.Equal(t, 2.0, lag("pid:102"))
+	assert.Equal(t, 2, testutil.CollectAndCount(pg.metrics.ReplicationLag))
+
+	// no privilege to read the replicas
+	rows = []map[string]interface{}{
+		{"pid": int64(101), "application_name": nil, "client_addr": "", "state": nil, "lag_seconds": "0"},
+	}
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Replicas error - insufficient privilege (needs pg_monitor)")
+	assert.Equal(t, 0, testutil.CollectAndCount(pg.metrics.ReplicationLag))
+
+	// query error
+	monkey.PatchInstanceMethod(reflect.TypeOf(db), "Query", func(_ *sql.DB, query string, args ...interface{}) (*sql.Rows, error) {
+		return nil, fmt.Errorf("permission denied")
+	})
+	s, m = pg.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "permission denied")
+	assert.Equal(t, 0, testutil.CollectAndCount(pg.metrics.ReplicationLag))
+
+	monkey.UnpatchAll()
+}
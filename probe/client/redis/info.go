@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+)
+
+// parseInfo parses the `key:value` lines of the INFO and CLUSTER INFO commands
+func parseInfo(s string) map[string]string {
+	info := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) <= 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		info[kv[0]] = kv[1]
+	}
+	return info
+}
+
+func infoInt(info map[string]string, key string) int64 {
+	v, _ := strconv.ParseInt(info[key], 10, 64)
+	return v
+}
+
+// ProbeInfo checks the fields of the INFO command
+func (r *Redis) ProbeInfo(ctx context.Context, rdb *redis.Client) (bool, string) {
+	out, err := rdb.Info(ctx).Result()
+	if err != nil {
+		r.lastRejected = -1
+		return false, fmt.Sprintf("INFO Error - %v", err)
+	}
+	return r.checkInfo(parseInfo(out))
+}
+
+// checkInfo checks the role, the master link, the connected slaves, the memory usage and the rejected connections
+func (r *Redis) checkInfo(info map[string]string) (bool, string) {
+	c := r.Redis.Info
+	status := true
+	failed := []string{}
+	fail := func(format string, args ...interface{}) {
+		status = false
+		failed = append(failed, fmt.Sprintf(format, args...))
+	}
+
+	role := info["role"]
+	if len(c.Role) > 0 && role != c.Role {
+		fail("role is %s, expected %s", role, c.Role)
+	}
+	if role == "slave" && info["master_link_status"] != "up" {
+		fail("master_link_status is %s", info["master_link_status"])
+	}
+
+	slaves := infoInt(info, "connected_slaves")
+	if slaves < int64(c.MinConnectedSlaves) {
+		fail("connected_slaves %d < %d", slaves, c.MinConnectedSlaves)
+	}
+
+	usage := "-"
+	if maxMemory := infoInt(info, "maxmemory"); maxMemory > 0 {
+		pct := float64(infoInt(info, "used_memory")) * 100 / float64(maxMemory)
+		usage = fmt.Sprintf("%.2f%%", pct)
+		if c.MaxMemoryUsage > 0 && pct > c.MaxMemoryUsage {
+			fail("memory usage %.2f%% > %v%%", pct, c.MaxMemoryUsage)
+		}
+	}
+
+	rejected := infoInt(info, "rejected_connections")
+	if c.MaxRejectedConnections != nil && r.lastRejected >= 0 && rejected >= r.lastRejected {
+		if delta := rejected - r.lastRejected; delta > *c.MaxRejectedConnections {
+			fail("%d connections rejected since the last probe", delta)
+		}
+	}
+	r.lastRejected = rejected
+
+	log.Debugf("[%s / %s / %s] - INFO - role: %s, connected_slaves: %d, memory usage: %s, rejected_connections: %d",
+		r.ProbeKind, r.ProbeName, r.ProbeTag, role, slaves, usage, rejected)
+	if !status {
+		return false, "INFO Error: " + strings.Join(failed, "; ")
+	}
+	return true, fmt.Sprintf("INFO: role %s, connected_slaves %d, memory usage %s", role, slaves, usage)
+}
+
+// ProbeCluster checks the state of the Redis Cluster
+func (r *Redis) ProbeCluster(ctx context.Context, rdb *redis.Client) (bool, string) {
+	out, err := rdb.ClusterInfo(ctx).Result()
+	if err != nil {
+		return false, fmt.Sprintf("CLUSTER INFO Error - %v", err)
+	}
+	info := parseInfo(out)
+	message := fmt.Sprintf("slots assigned %s, slots fail %s, known nodes %s, size %s",
+		info["cluster_slots_assigned"], info["cluster_slots_fail"], info["cluster_known_nodes"], info["cluster_size"])
+	if state := info["cluster_state"]; state != "ok" {
+		return false, fmt.Sprintf("Cluster Error: cluster_state is %s - %s", state, message)
+	}
+	return true, "Cluster: state ok - " + message
+}
+
+// ProbeSentinel checks the master address and the quorum of the master monitored by the Sentinel
+func (r *Redis) ProbeSentinel() (bool, string) {
+	sc := redis.NewSentinelClient(r.options())
+	defer sc.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context, r.Timeout())
+	defer cancel()
+
+	name := r.Redis.MasterName
+	addr, err := sc.GetMasterAddrByName(ctx, name).Result()
+	if err == redis.Nil {
+		return false, fmt.Sprintf("Sentinel Error: master [%s] is not found", name)
+	}
+	if err != nil {
+		return false, fmt.Sprintf("Sentinel Error - %v", err)
+	}
+	if len(addr) != 2 {
+		return false, fmt.Sprintf("Sentinel Error: invalid address of the master [%s] - %v", name, addr)
+	}
+
+	quorum, err := sc.CkQuorum(ctx, name).Result()
+	if err != nil {
+		return false, fmt.Sprintf("Sentinel Error: master [%s] at %s:%s - %v", name, addr[0], addr[1], err)
+	}
+	return true, fmt.Sprintf("Sentinel: master [%s] at %s:%s - %s", name, addr[0], addr[1], quorum)
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/megaease/easeprobe/probe/client/conf"
@@ -36,6 +37,9 @@ type Redis struct {
 	conf.Options `yaml:",inline"`
 	tls          *tls.Config     `yaml:"-" json:"-"`
 	Context      context.Context `yaml:"-" json:"-"`
+
+	// the rejected_connections of the last probe, -1 means unknown
+	lastRejected int64 `yaml:"-" json:"-"`
 }
 
 // New create a Redis client
@@ -47,10 +51,21 @@ func New(opt conf.Options) (*Redis, error) {
 		return nil, fmt.Errorf("TLS Config Error - %v", err)
 	}
 
+	if err := opt.Redis.Check(); err != nil {
+		log.Errorf("[%s / %s / %s] - %v", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, err)
+		return nil, err
+	}
+	// the keys of the Redis Cluster could live on the other nodes, which are not connected
+	if len(opt.Data) > 0 && opt.Redis.Mode != conf.RedisStandalone {
+		log.Errorf("[%s / %s / %s] - the data checking is not supported for the Redis %s", opt.ProbeKind, opt.ProbeName, opt.ProbeTag, opt.Redis.Mode)
+		return nil, fmt.Errorf("the data checking is not supported for the Redis %s", opt.Redis.Mode)
+	}
+
 	r := Redis{
-		Options: opt,
-		tls:     tls,
-		Context: context.Background(),
+		Options:      opt,
+		tls:          tls,
+		Context:      context.Background(),
+		lastRejected: -1,
 	}
 	return &r, nil
 }
@@ -60,16 +75,31 @@ func (r *Redis) Kind() string {
 	return Kind
 }
 
-// Probe do the health check
-func (r *Redis) Probe() (bool, string) {
-
-	rdb := redis.NewClient(&redis.Options{
+// options returns the options of the Redis client
+func (r *Redis) options() *redis.Options {
+	return &redis.Options{
 		Addr:        r.Host,
+		Username:    r.Username,  // ACL username (Redis 6+)
 		Password:    r.Password,  // no password set
 		DB:          0,           // use default DB
 		DialTimeout: r.Timeout(), // dial timout
 		TLSConfig:   r.tls,       //tls
-	})
+	}
+}
+
+// Probe do the health check
+func (r *Redis) Probe() (bool, string) {
+
+	if r.Redis.Mode == conf.RedisSentinel {
+		return r.ProbeSentinel()
+	}
+
+	// the rejected connections are counted since the last probe,
+	// so the counter is reset if the INFO is not read in this probe
+	lastRejected := r.lastRejected
+	r.lastRejected = -1
+
+	rdb := redis.NewClient(r.options())
 
 	ctx, cancel := context.WithTimeout(r.Context, r.Timeout())
 	defer cancel()
@@ -94,5 +124,27 @@ func (r *Redis) Probe() (bool, string) {
 			return false, err.Error()
 		}
 	}
-	return true, "Ping Redis Server Successfully!"
+
+	// all of the checks run even if one of them fails
+	status := true
+	messages := []string{"Ping Redis Server Successfully!"}
+	failed := []string{}
+	check := func(ok bool, msg string) {
+		status = status && ok
+		if !ok {
+			failed = append(failed, msg)
+		}
+		messages = append(messages, msg)
+	}
+	if r.Redis.Mode == conf.RedisCluster {
+		check(r.ProbeCluster(ctx, rdb))
+	}
+	if r.Redis.Info != nil {
+		r.lastRejected = lastRejected
+		check(r.ProbeInfo(ctx, rdb))
+	}
+	if !status {
+		return false, strings.Join(failed, " ")
+	}
+	return true, strings.Join(messages, " ")
 }
@@ -143,3 +143,260 @@ func TestData(t *testing.T) {
 
 	monkey.UnpatchAll()
 }
+
+// patchProcess patches the Redis clients to reply the commands without the server
+func patchProcess(reply func(cmd redis.Cmder) error) {
+	process := func(cmd redis.Cmder) error {
+		err := reply(cmd)
+		if err != nil {
+			cmd.SetErr(err)
+		}
+		return err
+	}
+	var client *redis.Client
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "Process", func(_ *redis.Client, _ context.Context, cmd redis.Cmder) error {
+		return process(cmd)
+	})
+	var sentinel *redis.SentinelClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(sentinel), "Process", func(_ *redis.SentinelClient, _ context.Context, cmd redis.Cmder) error {
+		return process(cmd)
+	})
+}
+
+func TestRedisOptions(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com:6379",
+		DriverType: conf.Redis,
+		Username:   "monitor",
+		Password:   "password",
+	}
+	r, err := New(opt)
+	assert.Nil(t, err)
+	assert.Equal(t, conf.RedisStandalone, r.Redis.Mode)
+	assert.Equal(t, "monitor", r.options().Username)
+	assert.Equal(t, "password", r.options().Password)
+
+	opt.Redis = conf.RedisOptions{Mode: "replica"}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "Invalid Redis mode")
+
+	opt.Redis = conf.RedisOptions{Mode: "Sentinel"}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "the master name is required")
+
+	opt.Redis = conf.RedisOptions{Mode: "sentinel", MasterName: "mymaster", Info: &conf.RedisInfo{}}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "INFO checking is not supported")
+
+	opt.Redis = conf.RedisOptions{Mode: "sentinel", MasterName: "mymaster"}
+	opt.Data = map[string]string{"key": "value"}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "data checking is not supported")
+
+	opt.Redis = conf.RedisOptions{Mode: "cluster"}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "the data checking is not supported for the Redis cluster")
+
+	opt.Data = nil
+	opt.Redis = conf.RedisOptions{Info: &conf.RedisInfo{Role: "primary"}}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "Invalid Redis role")
+
+	opt.Redis = conf.RedisOptions{Info: &conf.RedisInfo{MinConnectedSlaves: -1}}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "Invalid Redis min connected slaves")
+
+	opt.Redis = conf.RedisOptions{Info: &conf.RedisInfo{MaxMemoryUsage: 120}}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "Invalid Redis max memory usage")
+
+	negative := int64(-1)
+	opt.Redis = conf.RedisOptions{Info: &conf.RedisInfo{MaxRejectedConnections: &negative}}
+	_, err = New(opt)
+	assert.ErrorContains(t, err, "Invalid Redis max rejected connections")
+}
+
+func TestInfo(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com:6379",
+		DriverType: conf.Redis,
+		Redis: conf.RedisOptions{
+			Info: &conf.RedisInfo{Role: "master", MinConnectedSlaves: 1, MaxMemoryUsage: 80},
+		},
+	}
+	r, err := New(opt)
+	assert.Nil(t, err)
+
+	info := map[string]string{
+		"role":                 "master",
+		"connected_slaves":     "2",
+		"used_memory":          "512",
+		"maxmemory":            "1024",
+		"rejected_connections": "0",
+	}
+	patchProcess(func(cmd redis.Cmder) error {
+		switch c := cmd.(type) {
+		case *redis.StatusCmd:
+			c.SetVal("PONG")
+		case *redis.StringCmd:
+			s := "# Server\r\nredis_version:7.0.0\r\n\r\n# Replication\r\n"
+			for k, v := range info {
+				s += k + ":" + v + "\r\n"
+			}
+			c.SetVal(s)
+		}
+		return nil
+	})
+
+	s, m := r.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Successfully! INFO: role master, connected_slaves 2, memory usage 50.00%")
+
+	info["used_memory"] = "1000"
+	info["connected_slaves"] = "0"
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "INFO Error: connected_slaves 0 < 1; memory usage 97.66% > 80%")
+
+	info["used_memory"] = "512"
+	info["connected_slaves"] = "1"
+	// the rejected connections are not checked by default
+	info["rejected_connections"] = "3"
+	s, _ = r.Probe()
+	assert.True(t, s)
+
+	zero := int64(0)
+	r.Redis.Info.MaxRejectedConnections = &zero
+	info["rejected_connections"] = "6"
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "3 connections rejected since the last probe")
+	s, _ = r.Probe()
+	assert.True(t, s)
+
+	// the counter is updated even if the cluster checking fails
+	r.Redis.Mode = conf.RedisCluster
+	info["rejected_connections"] = "8"
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Cluster Error: cluster_state is")
+	assert.Contains(t, m, "INFO Error: 2 connections rejected since the last probe")
+	r.Redis.Mode = conf.RedisStandalone
+	s, _ = r.Probe()
+	assert.True(t, s)
+
+	r.Redis.Info.MaxRejectedConnections = nil
+	info["rejected_connections"] = "10"
+	s, _ = r.Probe()
+	assert.True(t, s)
+
+	info["role"] = "slave"
+	info["master_link_status"] = "down"
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "role is slave, expected master; master_link_status is down")
+
+	patchProcess(func(cmd redis.Cmder) error {
+		if _, ok := cmd.(*redis.StringCmd); ok {
+			return fmt.Errorf("NOPERM this user has no permissions to run the 'info' command")
+		}
+		return nil
+	})
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "INFO Error - NOPERM")
+
+	monkey.UnpatchAll()
+}
+
+func TestCluster(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com:6379",
+		DriverType: conf.Redis,
+		Redis:      conf.RedisOptions{Mode: "cluster"},
+	}
+	r, err := New(opt)
+	assert.Nil(t, err)
+
+	state := "ok"
+	patchProcess(func(cmd redis.Cmder) error {
+		switch c := cmd.(type) {
+		case *redis.StatusCmd:
+			c.SetVal("PONG")
+		case *redis.StringCmd:
+			assert.Equal(t, []interface{}{"cluster", "info"}, c.Args())
+			c.SetVal("cluster_state:" + state + "\r\ncluster_slots_assigned:16384\r\ncluster_slots_fail:0\r\n" +
+				"cluster_known_nodes:6\r\ncluster_size:3\r\n")
+		}
+		return nil
+	})
+
+	s, m := r.Probe()
+	assert.True(t, s)
+	assert.Contains(t, m, "Cluster: state ok - slots assigned 16384, slots fail 0, known nodes 6, size 3")
+
+	state = "fail"
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Cluster Error: cluster_state is fail")
+
+	patchProcess(func(cmd redis.Cmder) error {
+		if _, ok := cmd.(*redis.StringCmd); ok {
+			return fmt.Errorf("ERR This instance has cluster support disabled")
+		}
+		return nil
+	})
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "cluster support disabled")
+
+	monkey.UnpatchAll()
+}
+
+func TestSentinel(t *testing.T) {
+	opt := conf.Options{
+		Host:       "example.com:26379",
+		DriverType: conf.Redis,
+		Redis:      conf.RedisOptions{Mode: "sentinel", MasterName: "mymaster"},
+	}
+	r, err := New(opt)
+	assert.Nil(t, err)
+
+	var addrErr, quorumErr error
+	patchProcess(func(cmd redis.Cmder) error {
+		switch c := cmd.(type) {
+		case *redis.StringSliceCmd:
+			if addrErr != nil {
+				return addrErr
+			}
+			c.SetVal([]string{"10.0.0.1", "6379"})
+		case *redis.StringCmd:
+			if quorumErr != nil {
+				return quorumErr
+			}
+			c.SetVal("OK 3 usable Sentinels. Quorum and failover authorization can be reached")
+		}
+		return nil
+	})
+
+	s, m := r.Probe()
+	assert.True(t, s)
+	assert.Equal(t, "Sentinel: master [mymaster] at 10.0.0.1:6379 - OK 3 usable Sentinels. Quorum and failover authorization can be reached", m)
+
+	quorumErr = fmt.Errorf("NOQUORUM 1 usable Sentinels. Not enough available Sentinels to reach the specified quorum for this master")
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Sentinel Error: master [mymaster] at 10.0.0.1:6379 - NOQUORUM")
+
+	addrErr = redis.Nil
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "master [mymaster] is not found")
+
+	addrErr = fmt.Errorf("connection refused")
+	s, m = r.Probe()
+	assert.False(t, s)
+	assert.Contains(t, m, "connection refused")
+
+	monkey.UnpatchAll()
+}
@@ -114,6 +114,24 @@ func (tc *TextChecker) CheckRegExp(Output string) error {
 	return nil
 }
 
+// MatchEnd returns the end index of the first match of the Contain in the text,
+// it is the length of the text if the Contain is not set, or -1 if there is no match.
+func (tc *TextChecker) MatchEnd(text string) int {
+	if len(tc.Contain) <= 0 || (tc.RegExp && tc.containReg == nil) {
+		return len(text)
+	}
+	if tc.RegExp {
+		if loc := tc.containReg.FindStringIndex(text); loc != nil {
+			return loc[1]
+		}
+		return -1
+	}
+	if i := strings.Index(text, tc.Contain); i >= 0 {
+		return i + len(tc.Contain)
+	}
+	return -1
+}
+
 // CheckEmpty return "empty" if the string is empty
 func CheckEmpty(s string) string {
 	if len(strings.TrimSpace(s)) <= 0 {
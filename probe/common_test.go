@@ -155,6 +155,20 @@ func TestTextChecker(t *testing.T) {
 	assert.NotNil(t, checker.Check("<p>test hello world </p>"))
 }
 
+func TestMatchEnd(t *testing.T) {
+	checker := TextChecker{Contain: "250"}
+	assert.Equal(t, 8, checker.MatchEnd("220\r\n250 ok"))
+	assert.Equal(t, -1, checker.MatchEnd("220 ready"))
+
+	checker = TextChecker{Contain: `(?m)^250 .*\r\n`, RegExp: true}
+	checker.Config()
+	assert.Equal(t, 14, checker.MatchEnd("250-a\r\n250 b\r\n221 bye\r\n"))
+	assert.Equal(t, -1, checker.MatchEnd("250-a\r\n"))
+
+	checker = TextChecker{NotContain: "502"}
+	assert.Equal(t, 6, checker.MatchEnd("250 ok"))
+}
+
 func TestCheckEmpty(t *testing.T) {
 	assert.Equal(t, "a", CheckEmpty("a"))
 	assert.Equal(t, "empty", CheckEmpty("    "))
@@ -89,6 +89,18 @@ func GetResultData(name string) *Result {
 	return nil
 }
 
+// GetResultNames get the sorted names of all probe results
+func GetResultNames() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	names := make([]string, 0, len(resultData))
+	for name := range resultData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // CleanData removes the items in resultData not in []Prober
 // Note: No need to consider the thread-safe, because this function is only called once during the startup
 func CleanData(p []Prober) {
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package probe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dependent is the interface of the prober which depends on other probers
+type Dependent interface {
+	Dependencies() []string
+}
+
+// CheckDependencies checks the dependency graph of the probers.
+// It returns the errors of the probers which depend on an unknown prober or are in a dependency cycle.
+func CheckDependencies(probers []Prober) map[string]error {
+	errs := map[string]error{}
+
+	graph := map[string][]string{}
+	for _, p := range probers {
+		graph[p.Name()] = nil
+	}
+	for _, p := range probers {
+		d, ok := p.(Dependent)
+		if !ok {
+			continue
+		}
+		for _, dep := range d.Dependencies() {
+			if _, ok := graph[dep]; !ok {
+				errs[p.Name()] = fmt.Errorf("the dependency [%s] is not found", dep)
+				continue
+			}
+			graph[p.Name()] = append(graph[p.Name()], dep)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	path := []string{}
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range graph[name] {
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				// found a cycle, all of the probers in the cycle are invalid
+				i := len(path) - 1
+				for path[i] != dep {
+					i--
+				}
+				cycle := append(append([]string{}, path[i:]...), dep)
+				err := fmt.Errorf("dependency cycle found: %s", strings.Join(cycle, " -> "))
+				for _, n := range path[i:] {
+					if _, ok := errs[n]; !ok {
+						errs[n] = err
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+	}
+
+	for _, p := range probers {
+		if state[p.Name()] == unvisited {
+			visit(p.Name())
+		}
+	}
+	return errs
+}
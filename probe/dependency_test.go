@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type DummyDependentProbe struct {
+	DummyProbe
+	Deps []string
+}
+
+func (d *DummyDependentProbe) Dependencies() []string {
+	return d.Deps
+}
+
+func newDependentProbe(name string, deps ...string) Prober {
+	return &DummyDependentProbe{
+		DummyProbe: DummyProbe{MyName: name},
+		Deps:       deps,
+	}
+}
+
+func TestCheckDependencies(t *testing.T) {
+	probers := []Prober{
+		newDependentProbe("router"),
+		newDependentProbe("bastion", "router"),
+		newDependentProbe("web", "router", "bastion"),
+		&DummyProbe{MyName: "plain"},
+	}
+	assert.Empty(t, CheckDependencies(probers))
+
+	// unknown dependency
+	probers = append(probers, newDependentProbe("api", "unknown"))
+	errs := CheckDependencies(probers)
+	assert.Equal(t, 1, len(errs))
+	assert.Contains(t, errs["api"].Error(), "the dependency [unknown] is not found")
+
+	// self dependency
+	probers = []Prober{newDependentProbe("self", "self")}
+	errs = CheckDependencies(probers)
+	assert.Contains(t, errs["self"].Error(), "dependency cycle found: self -> self")
+
+	// a -> b -> c -> a, and d -> a is not in the cycle
+	probers = []Prober{
+		newDependentProbe("a", "b"),
+		newDependentProbe("b", "c"),
+		newDependentProbe("c", "a"),
+		newDependentProbe("d", "a"),
+		newDependentProbe("e"),
+	}
+	errs = CheckDependencies(probers)
+	assert.Equal(t, 3, len(errs))
+	for _, n := range []string{"a", "b", "c"} {
+		assert.Contains(t, errs[n].Error(), "dependency cycle found: a -> b -> c -> a")
+	}
+	assert.Nil(t, errs["d"])
+	assert.Nil(t, errs["e"])
+}
@@ -0,0 +1,345 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dns is the dns probe package
+package dns
+
+import (
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+// The supported protocols
+const (
+	UDP = "udp"
+	TCP = "tcp"
+	DoT = "tls"
+)
+
+// DefaultRecordType is the default DNS record type to query
+const DefaultRecordType = "A"
+
+// DefaultRcode is the default expected DNS response code
+const DefaultRcode = "NOERROR"
+
+var supportedTypes = map[string]uint16{
+	"A":     dns.TypeA,
+	"AAAA":  dns.TypeAAAA,
+	"CNAME": dns.TypeCNAME,
+	"MX":    dns.TypeMX,
+	"TXT":   dns.TypeTXT,
+	"SRV":   dns.TypeSRV,
+	"NS":    dns.TypeNS,
+	"SOA":   dns.TypeSOA,
+}
+
+var defaultPort = map[string]string{
+	UDP: "53",
+	TCP: "53",
+	DoT: "853",
+}
+
+// DNS implements a config for DNS
+type DNS struct {
+	base.DefaultProbe `yaml:",inline"`
+	Server            string        `yaml:"server" json:"server" jsonschema:"required,title=Name Server,description=the name server to query,example=8.8.8.8:53"`
+	Domain            string        `yaml:"domain" json:"domain" jsonschema:"required,title=Domain,description=the domain name to resolve,example=example.com"`
+	Protocol          string        `yaml:"protocol,omitempty" json:"protocol,omitempty" jsonschema:"enum=udp,enum=tcp,enum=tls,title=Protocol,description=the protocol to talk to the name server (tls means DNS over TLS),default=udp"`
+	Type              string        `yaml:"type,omitempty" json:"type,omitempty" jsonschema:"enum=A,enum=AAAA,enum=CNAME,enum=MX,enum=TXT,enum=SRV,enum=NS,enum=SOA,title=Record Type,description=the DNS record type to query,default=A"`
+	Answers           []string      `yaml:"answers,omitempty" json:"answers,omitempty" jsonschema:"title=Expected Answers,description=the answers must be present in the response"`
+	ExactAnswers      bool          `yaml:"exact_answers,omitempty" json:"exact_answers,omitempty" jsonschema:"title=Exact Answers,description=the response must contain exactly the expected answers,default=false"`
+	MinTTL            time.Duration `yaml:"min_ttl,omitempty" json:"min_ttl,omitempty" jsonschema:"type=string,format=duration,title=Minimum TTL,description=the minimum TTL of the answers"`
+	MaxTTL            time.Duration `yaml:"max_ttl,omitempty" json:"max_ttl,omitempty" jsonschema:"type=string,format=duration,title=Maximum TTL,description=the maximum TTL of the answers"`
+	Rcode             string        `yaml:"rcode,omitempty" json:"rcode,omitempty" jsonschema:"title=Response Code,description=the expected DNS response code,example=NXDOMAIN,default=NOERROR"`
+
+	// Option - TLS Config for DNS over TLS
+	global.TLS `yaml:",inline"`
+
+	qtype  uint16      `yaml:"-" json:"-"`
+	rcode  int         `yaml:"-" json:"-"`
+	client *dns.Client `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// Config DNS Config Object
+func (d *DNS) Config(gConf global.ProbeSettings) error {
+	kind := "dns"
+	tag := ""
+	name := d.ProbeName
+
+	d.Protocol = strings.ToLower(strings.TrimSpace(d.Protocol))
+	if d.Protocol == "" {
+		d.Protocol = UDP
+	}
+	d.Server = strings.TrimSpace(d.Server)
+	port, ok := defaultPort[d.Protocol]
+	if _, _, err := net.SplitHostPort(d.Server); err != nil && ok && d.Server != "" {
+		d.Server = net.JoinHostPort(d.Server, port)
+	}
+
+	d.DefaultProbe.Config(gConf, kind, tag, name, d.Server, d.DoProbe)
+
+	if !ok {
+		return fmt.Errorf("unsupported protocol: %s", d.Protocol)
+	}
+	if d.Server == "" {
+		return fmt.Errorf("the name server is required")
+	}
+
+	if strings.TrimSpace(d.Domain) == "" {
+		return fmt.Errorf("the domain is required")
+	}
+	d.Domain = dns.Fqdn(strings.TrimSpace(d.Domain))
+
+	d.Type = strings.ToUpper(strings.TrimSpace(d.Type))
+	if d.Type == "" {
+		d.Type = DefaultRecordType
+	}
+	if d.qtype, ok = supportedTypes[d.Type]; !ok {
+		return fmt.Errorf("unsupported record type: %s", d.Type)
+	}
+
+	d.Rcode = strings.ToUpper(strings.TrimSpace(d.Rcode))
+	if d.Rcode == "" {
+		d.Rcode = DefaultRcode
+	}
+	if d.rcode, ok = dns.StringToRcode[d.Rcode]; !ok {
+		return fmt.Errorf("unknown response code: %s", d.Rcode)
+	}
+
+	if d.MaxTTL > 0 && d.MinTTL > d.MaxTTL {
+		return fmt.Errorf("min_ttl(%s) is greater than max_ttl(%s)", d.MinTTL, d.MaxTTL)
+	}
+
+	d.client = &dns.Client{
+		Net:     d.Protocol,
+		Timeout: d.Timeout(),
+	}
+	if d.Protocol == DoT {
+		tlsConfig, err := d.TLS.Config()
+		if err != nil {
+			log.Errorf("[%s / %s] TLS configuration error - %s", d.ProbeKind, d.ProbeName, err)
+			return err
+		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName, _, _ = net.SplitHostPort(d.Server)
+		}
+		d.client.Net = "tcp-tls"
+		d.client.TLSConfig = tlsConfig
+	}
+
+	d.metrics = newMetrics(kind, tag, d.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", d.ProbeKind, d.ProbeName, *d)
+	return nil
+}
+
+// DoProbe return the checking result
+func (d *DNS) DoProbe() (bool, string) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(d.Domain, d.qtype)
+
+	resp, rtt, err := d.client.Exchange(msg, d.Server)
+	if err != nil {
+		log.Errorf("[%s / %s] error: %v", d.ProbeKind, d.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+
+	records := []dns.RR{}
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == d.qtype {
+			records = append(records, rr)
+		}
+	}
+	d.ExportMetrics(resp, records, rtt)
+
+	rcode := dns.RcodeToString[resp.Rcode]
+	if resp.Rcode != d.rcode {
+		return false, fmt.Sprintf("%s %s @%s - response code is %s, expected %s",
+			d.Type, d.Domain, d.Server, rcode, d.Rcode)
+	}
+
+	// the negative answer is expected, no need to check the records
+	if d.rcode != dns.RcodeSuccess {
+		return true, fmt.Sprintf("%s %s @%s - response code is %s (%s)", d.Type, d.Domain, d.Server, rcode, rtt)
+	}
+
+	if len(records) == 0 {
+		return false, fmt.Sprintf("%s %s @%s - no %s record found", d.Type, d.Domain, d.Server, d.Type)
+	}
+
+	values := make([]string, 0, len(records))
+	for _, rr := range records {
+		values = append(values, RecordValue(rr))
+	}
+	log.Debugf("[%s / %s] %s %s - %v", d.ProbeKind, d.ProbeName, d.Type, d.Domain, values)
+
+	if err := d.CheckAnswers(values); err != nil {
+		return false, fmt.Sprintf("%s %s @%s - %v", d.Type, d.Domain, d.Server, err)
+	}
+	if err := d.CheckTTL(records); err != nil {
+		return false, fmt.Sprintf("%s %s @%s - %v", d.Type, d.Domain, d.Server, err)
+	}
+
+	return true, fmt.Sprintf("%s %s @%s - %d record(s) resolved (%s): %s",
+		d.Type, d.Domain, d.Server, len(records), rtt, strings.Join(values, ", "))
+}
+
+// CheckAnswers checks the resolved values with the expected answers
+func (d *DNS) CheckAnswers(values []string) error {
+	if len(d.Answers) == 0 {
+		return nil
+	}
+
+	got := map[string]bool{}
+	for _, v := range values {
+		got[normalize(v)] = true
+	}
+
+	missing := []string{}
+	expected := map[string]bool{}
+	for _, a := range d.Answers {
+		expected[normalize(a)] = true
+		if !got[normalize(a)] {
+			missing = append(missing, a)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("expected answers [%s] are missing, got [%s]",
+			strings.Join(missing, ", "), strings.Join(values, ", "))
+	}
+
+	if d.ExactAnswers {
+		unexpected := []string{}
+		for _, v := range values {
+			if !expected[normalize(v)] {
+				unexpected = append(unexpected, v)
+			}
+		}
+		if len(unexpected) > 0 {
+			return fmt.Errorf("unexpected answers [%s]", strings.Join(unexpected, ", "))
+		}
+	}
+	return nil
+}
+
+// CheckTTL checks the TTL of the records is in the range of [min_ttl, max_ttl]
+func (d *DNS) CheckTTL(records []dns.RR) error {
+	for _, rr := range records {
+		ttl := time.Duration(rr.Header().Ttl) * time.Second
+		if d.MinTTL > 0 && ttl < d.MinTTL {
+			return fmt.Errorf("TTL of [%s] is %s, less than %s", RecordValue(rr), ttl, d.MinTTL)
+		}
+		if d.MaxTTL > 0 && ttl > d.MaxTTL {
+			return fmt.Errorf("TTL of [%s] is %s, greater than %s", RecordValue(rr), ttl, d.MaxTTL)
+		}
+	}
+	return nil
+}
+
+// RecordValue returns the value of the record as a string
+func RecordValue(rr dns.RR) string {
+	switch r := rr.(type) {
+	case *dns.A:
+		return r.A.String()
+	case *dns.AAAA:
+		return r.AAAA.String()
+	case *dns.CNAME:
+		return r.Target
+	case *dns.NS:
+		return r.Ns
+	case *dns.MX:
+		return fmt.Sprintf("%d %s", r.Preference, r.Mx)
+	case *dns.TXT:
+		return strings.Join(r.Txt, "")
+	case *dns.SRV:
+		return fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, r.Target)
+	case *dns.SOA:
+		return fmt.Sprintf("%s %s %d", r.Ns, r.Mbox, r.Serial)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))
+}
+
+// normalize makes the domain names comparable, e.g. "Example.com" == "example.com."
+func normalize(s string) string {
+	fields := strings.Fields(s)
+	for i, f := range fields {
+		fields[i] = strings.TrimSuffix(strings.ToLower(f), ".")
+	}
+	return strings.Join(fields, " ")
+}
+
+// ExportMetrics export DNS metrics
+func (d *DNS) ExportMetrics(resp *dns.Msg, records []dns.RR, rtt time.Duration) {
+	d.metrics.Rcode.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     d.ProbeName,
+		"rcode":    dns.RcodeToString[resp.Rcode],
+		"endpoint": d.ProbeResult.Endpoint,
+	}, d.Labels)).Inc()
+
+	d.metrics.RTT.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     d.ProbeName,
+		"endpoint": d.ProbeResult.Endpoint,
+	}, d.Labels)).Set(float64(rtt.Milliseconds()))
+
+	d.metrics.Answers.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     d.ProbeName,
+		"type":     d.Type,
+		"endpoint": d.ProbeResult.Endpoint,
+	}, d.Labels)).Set(float64(len(records)))
+
+	// the min and max TTL of the records, they are NaN if there is no record
+	min, max := math.NaN(), math.NaN()
+	for i, rr := range records {
+		ttl := float64(rr.Header().Ttl)
+		if i == 0 || ttl < min {
+			min = ttl
+		}
+		if i == 0 || ttl > max {
+			max = ttl
+		}
+	}
+	d.metrics.TTL.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     d.ProbeName,
+		"type":     d.Type,
+		"state":    "min",
+		"endpoint": d.ProbeResult.Endpoint,
+	}, d.Labels)).Set(min)
+
+	d.metrics.TTL.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     d.ProbeName,
+		"type":     d.Type,
+		"state":    "max",
+		"endpoint": d.ProbeResult.Endpoint,
+	}, d.Labels)).Set(max)
+}
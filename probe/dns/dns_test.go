@@ -0,0 +1,255 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+var zone = map[uint16][]string{
+	dns.TypeA:     {"example.com. 300 IN A 10.0.0.1", "example.com. 600 IN A 10.0.0.2"},
+	dns.TypeAAAA:  {"example.com. 300 IN AAAA ::1"},
+	dns.TypeMX:    {"example.com. 3600 IN MX 10 mail.example.com."},
+	dns.TypeTXT:   {`example.com. 60 IN TXT "v=spf1 -all"`},
+	dns.TypeNS:    {"example.com. 86400 IN NS ns1.example.com."},
+	dns.TypeSRV:   {"example.com. 60 IN SRV 10 5 443 api.example.com."},
+	dns.TypeCNAME: {"example.com. 60 IN CNAME www.example.com."},
+	dns.TypeSOA:   {"example.com. 60 IN SOA ns1.example.com. admin.example.com. 2022 7200 3600 1209600 3600"},
+}
+
+func handler(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	q := r.Question[0]
+	if q.Name != "example.com." {
+		m.Rcode = dns.RcodeNameError
+		w.WriteMsg(m)
+		return
+	}
+	for _, s := range zone[q.Qtype] {
+		rr, _ := dns.NewRR(s)
+		m.Answer = append(m.Answer, rr)
+	}
+	w.WriteMsg(m)
+}
+
+func startServer(t *testing.T, network string) (string, func()) {
+	started := make(chan struct{})
+	server := &dns.Server{
+		Handler:           dns.HandlerFunc(handler),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	switch network {
+	case "udp":
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		server.PacketConn = pc
+	case "tcp":
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		server.Listener = l
+	}
+	go server.ActivateAndServe()
+	<-started
+
+	addr := ""
+	if server.PacketConn != nil {
+		addr = server.PacketConn.LocalAddr().String()
+	} else {
+		addr = server.Listener.Addr().String()
+	}
+	return addr, func() { server.Shutdown() }
+}
+
+func newDNS(server, domain, t string) *DNS {
+	return &DNS{
+		DefaultProbe: base.DefaultProbe{ProbeName: "dummy dns", ProbeTimeout: time.Second},
+		Server:       server,
+		Domain:       domain,
+		Type:         t,
+	}
+}
+
+func TestDNS(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, shutdown := startServer(t, "udp")
+	defer shutdown()
+
+	d := newDNS(addr, "example.com", "")
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	assert.Equal(t, "dns", d.ProbeKind)
+	assert.Equal(t, "A", d.Type)
+	assert.Equal(t, "NOERROR", d.Rcode)
+	assert.Equal(t, "example.com.", d.Domain)
+
+	s, m := d.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "10.0.0.1")
+
+	d.Answers = []string{"10.0.0.2"}
+	s, m = d.DoProbe()
+	assert.True(t, s)
+
+	d.ExactAnswers = true
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "unexpected answers [10.0.0.1]")
+
+	d.Answers = []string{"10.0.0.3"}
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "[10.0.0.3] are missing")
+
+	// TTL bounds
+	d = newDNS(addr, "example.com", "A")
+	d.MaxTTL = time.Minute
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "greater than 1m0s")
+
+	d.MaxTTL = 0
+	d.MinTTL = 10 * time.Minute
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "less than 10m0s")
+
+	d.MinTTL = time.Minute
+	s, _ = d.DoProbe()
+	assert.True(t, s)
+
+	// the min and max TTL are exported, instead of the TTL of every record
+	ttl := func(state string) float64 {
+		return testutil.ToFloat64(d.metrics.TTL.With(prometheus.Labels{
+			"name": d.ProbeName, "type": "A", "state": state, "endpoint": d.ProbeResult.Endpoint}))
+	}
+	assert.Equal(t, 300.0, ttl("min"))
+	assert.Equal(t, 600.0, ttl("max"))
+	assert.Equal(t, 2, testutil.CollectAndCount(d.metrics.TTL))
+
+	// Record types
+	cases := map[string]string{
+		"AAAA":  "::1",
+		"MX":    "10 mail.example.com",
+		"TXT":   "v=spf1 -all",
+		"NS":    "NS1.example.com.",
+		"SRV":   "10 5 443 api.example.com",
+		"CNAME": "www.example.com",
+		"SOA":   "ns1.example.com admin.example.com 2022",
+	}
+	for typ, answer := range cases {
+		d = newDNS(addr, "example.com", typ)
+		d.Answers = []string{answer}
+		assert.Nil(t, d.Config(global.ProbeSettings{}))
+		s, m = d.DoProbe()
+		assert.Truef(t, s, "%s - %s", typ, m)
+	}
+
+	// RCODE
+	d = newDNS(addr, "unknown.com", "A")
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "response code is NXDOMAIN, expected NOERROR")
+
+	d.Rcode = "nxdomain"
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	s, _ = d.DoProbe()
+	assert.True(t, s)
+
+	// no record
+	d = newDNS(addr, "example.com", "A")
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	d.qtype = dns.TypePTR
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "no A record found")
+}
+
+func TestDNSOverTCP(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, shutdown := startServer(t, "tcp")
+	defer shutdown()
+
+	d := newDNS(addr, "example.com", "A")
+	d.Protocol = "TCP"
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	assert.Equal(t, TCP, d.client.Net)
+	s, m := d.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "2 record(s) resolved")
+
+	// UDP client cannot talk to the TCP server
+	d.Protocol = "udp"
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	s, m = d.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error")
+}
+
+func TestDNSConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	d := newDNS("8.8.8.8", "example.com", "A")
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	assert.Equal(t, "8.8.8.8:53", d.Server)
+	assert.Equal(t, "8.8.8.8:53", d.Result().Endpoint)
+
+	d = newDNS("dns.google", "example.com", "A")
+	d.Protocol = "tls"
+	assert.Nil(t, d.Config(global.ProbeSettings{}))
+	assert.Equal(t, "dns.google:853", d.Server)
+	assert.Equal(t, "tcp-tls", d.client.Net)
+	assert.Equal(t, "dns.google", d.client.TLSConfig.ServerName)
+
+	d.TLS = global.TLS{CA: "/path/not/exist"}
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", "example.com", "A")
+	d.Protocol = "http"
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+	assert.NotNil(t, d.Result())
+
+	d = newDNS("", "example.com", "A")
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", "", "A")
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", "example.com", "PTR")
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", "example.com", "A")
+	d.Rcode = "BAD"
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+
+	d = newDNS("8.8.8.8", "example.com", "A")
+	d.MinTTL = time.Hour
+	d.MaxTTL = time.Minute
+	assert.NotNil(t, d.Config(global.ProbeSettings{}))
+}
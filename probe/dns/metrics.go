@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics is the metrics for dns probe
+type metrics struct {
+	Rcode   *prometheus.CounterVec
+	RTT     *prometheus.GaugeVec
+	Answers *prometheus.GaugeVec
+	TTL     *prometheus.GaugeVec
+}
+
+// newMetrics create the dns metrics
+func newMetrics(subsystem, name string, constLabels prometheus.Labels) *metrics {
+	namespace := global.GetEaseProbe().Name
+	return &metrics{
+		Rcode: metric.NewCounter(namespace, subsystem, name, "rcode",
+			"DNS Response Code", []string{"name", "rcode", "endpoint"}, constLabels),
+		RTT: metric.NewGauge(namespace, subsystem, name, "rtt",
+			"DNS Query Round Trip Time", []string{"name", "endpoint"}, constLabels),
+		Answers: metric.NewGauge(namespace, subsystem, name, "answers",
+			"Number of DNS Answers", []string{"name", "type", "endpoint"}, constLabels),
+		TTL: metric.NewGauge(namespace, subsystem, name, "ttl",
+			"Min and Max TTL of DNS Records", []string{"name", "type", "state", "endpoint"}, constLabels),
+	}
+}
@@ -0,0 +1,418 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package file is the file probe package, it checks the existence, age, size, checksum
+// and content of a file on the local host or the remote host by SSH.
+package file
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/megaease/easeprobe/eval"
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/ssh"
+)
+
+// the max size of the content read for the text checker and the evaluator
+const maxContentSize = 1024 * 1024
+
+// the output of the remote command for the missing file
+const missing = "missing"
+
+// the separator of the file information and the file content
+const separator = "\n---\n"
+
+// the supported checksum algorithms
+var hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// File implements a config for the file probe
+type File struct {
+	ssh.Server `yaml:",inline"`
+
+	Path     string        `yaml:"path" json:"path" jsonschema:"required,title=File Path,description=the path of the file to check,example=/backup/db.sql.gz"`
+	MaxAge   time.Duration `yaml:"max_age,omitempty" json:"max_age,omitempty" jsonschema:"type=string,format=duration,title=Max Age,description=the file must be modified within the duration,example=26h"`
+	MinSize  int64         `yaml:"min_size,omitempty" json:"min_size,omitempty" jsonschema:"title=Min Size,description=the minimum size (bytes) of the file"`
+	MaxSize  int64         `yaml:"max_size,omitempty" json:"max_size,omitempty" jsonschema:"title=Max Size,description=the maximum size (bytes) of the file"`
+	Checksum string        `yaml:"checksum,omitempty" json:"checksum,omitempty" jsonschema:"title=Checksum,description=the expected checksum with the algorithm prefix (md5/sha1/sha256/sha512),example=sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+
+	// Evaluator of the file content
+	Evaluator eval.Evaluator `yaml:"eval,omitempty" json:"eval,omitempty" jsonschema:"title=File Content Evaluator,description=the evaluator of the file content"`
+
+	algorithm string `yaml:"-" json:"-"`
+	sum       string `yaml:"-" json:"-"`
+
+	exists bool    `yaml:"-" json:"-"`
+	size   int64   `yaml:"-" json:"-"`
+	age    float64 `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// fileInfo is the information of the file
+type fileInfo struct {
+	exists   bool
+	size     int64
+	modTime  time.Time
+	now      time.Time
+	checksum string
+	content  string
+}
+
+// Config File Config Object
+func (f *File) Config(gConf global.ProbeSettings) error {
+	kind := "file"
+	tag := ""
+	name := f.ProbeName
+
+	if len(f.Host) <= 0 {
+		f.Host = ssh.LocalHost
+	}
+	endpoint := f.Host + ":" + f.Path
+
+	var err error
+	if f.IsLocal() {
+		err = f.DefaultProbe.Config(gConf, kind, tag, name, endpoint, f.DoProbe)
+		if err == nil {
+			err = f.TextChecker.Config()
+		}
+	} else {
+		err = f.Configure(gConf, kind, tag, name, endpoint, &ssh.BastionMap, f.DoProbe)
+	}
+	if err != nil {
+		log.Errorf("[%s / %s] %v", f.ProbeKind, f.ProbeName, err)
+		return err
+	}
+
+	if err := f.check(); err != nil {
+		log.Errorf("[%s / %s] %v", f.ProbeKind, f.ProbeName, err)
+		return err
+	}
+
+	// if the evaluator is set, config it
+	if f.Evaluator.DocType != eval.Unsupported && len(strings.TrimSpace(f.Evaluator.Expression)) > 0 {
+		if err := f.Evaluator.Config(); err != nil {
+			log.Errorf("[%s / %s] %v", f.ProbeKind, f.ProbeName, err)
+			return err
+		}
+	}
+
+	f.Command = f.command()
+	f.metrics = newMetrics(kind, tag, f.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", f.ProbeKind, f.ProbeName, *f)
+	return nil
+}
+
+// check the configuration
+func (f *File) check() error {
+	if len(strings.TrimSpace(f.Path)) <= 0 {
+		return fmt.Errorf("the path is required")
+	}
+	if f.MinSize < 0 || f.MaxSize < 0 {
+		return fmt.Errorf("the size cannot be negative")
+	}
+	if f.MaxSize > 0 && f.MaxSize < f.MinSize {
+		return fmt.Errorf("the max_size %d is less than the min_size %d", f.MaxSize, f.MinSize)
+	}
+	if f.MaxAge < 0 {
+		return fmt.Errorf("the max_age cannot be negative")
+	}
+
+	f.algorithm, f.sum = "", ""
+	if len(f.Checksum) > 0 {
+		algorithm, sum := "sha256", f.Checksum
+		if kv := strings.SplitN(f.Checksum, ":", 2); len(kv) == 2 {
+			algorithm, sum = strings.ToLower(kv[0]), kv[1]
+		}
+		if _, ok := hashes[algorithm]; !ok {
+			return fmt.Errorf("unsupported checksum algorithm [%s]", algorithm)
+		}
+		if _, err := hex.DecodeString(sum); err != nil || len(sum) <= 0 {
+			return fmt.Errorf("invalid checksum [%s]", f.Checksum)
+		}
+		f.algorithm, f.sum = algorithm, strings.ToLower(sum)
+	}
+	return nil
+}
+
+// needContent returns true if the content of the file needs to be checked
+func (f *File) needContent() bool {
+	return len(f.Contain) > 0 || len(f.NotContain) > 0 ||
+		(f.Evaluator.DocType != eval.Unsupported && f.Evaluator.Extractor != nil &&
+			len(strings.TrimSpace(f.Evaluator.Expression)) > 0)
+}
+
+// quote the string for the shell
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// command returns the shell command to collect the file information on the remote host.
+// The current time of the remote host is used to compute the age, so the clock skew doesn't matter.
+func (f *File) command() string {
+	cmd := []string{
+		"f=" + quote(f.Path),
+		`[ -e "$f" ] || { echo ` + missing + `; exit 0; }`,
+		"date +%s",
+		`stat -c '%s %Y' "$f" 2>/dev/null || stat -f '%z %m' "$f"`,
+	}
+	if len(f.algorithm) > 0 {
+		cmd = append(cmd, f.algorithm+`sum "$f" | cut -d ' ' -f 1`)
+	}
+	if f.needContent() {
+		cmd = append(cmd, "echo ---", fmt.Sprintf(`head -c %d "$f"`, maxContentSize))
+	}
+	return strings.Join(cmd, "\n")
+}
+
+// DoProbe return the checking result
+func (f *File) DoProbe() (bool, string) {
+	var info *fileInfo
+	var err error
+	if f.IsLocal() {
+		info, err = f.statLocal()
+	} else {
+		info, err = f.statRemote()
+	}
+	if err != nil {
+		log.Errorf("[%s / %s] %v", f.ProbeKind, f.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+	return f.checkFile(info)
+}
+
+// statLocal collects the file information on the local host
+func (f *File) statLocal() (*fileInfo, error) {
+	st, err := os.Stat(f.Path)
+	if os.IsNotExist(err) {
+		return &fileInfo{exists: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := &fileInfo{
+		exists:  true,
+		size:    st.Size(),
+		modTime: st.ModTime(),
+		now:     time.Now(),
+	}
+	if len(f.algorithm) <= 0 && !f.needContent() {
+		return info, nil
+	}
+	if st.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", f.Path)
+	}
+
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if len(f.algorithm) > 0 {
+		h := hashes[f.algorithm]()
+		if _, err := io.Copy(h, file); err != nil {
+			return nil, err
+		}
+		info.checksum = hex.EncodeToString(h.Sum(nil))
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	if f.needContent() {
+		content, err := io.ReadAll(io.LimitReader(file, maxContentSize))
+		if err != nil {
+			return nil, err
+		}
+		info.content = string(content)
+	}
+	return info, nil
+}
+
+// statRemote collects the file information on the remote host by SSH
+func (f *File) statRemote() (*fileInfo, error) {
+	output, err := f.RunSSHCmd()
+	if err != nil {
+		return nil, fmt.Errorf("%v - %s", err, probe.CheckEmpty(output))
+	}
+	return f.parseOutput(output)
+}
+
+// parseOutput parse the output of the remote command
+func (f *File) parseOutput(output string) (*fileInfo, error) {
+	if strings.TrimSpace(output) == missing {
+		return &fileInfo{exists: false}, nil
+	}
+
+	content := ""
+	if f.needContent() {
+		parts := strings.SplitN(output, separator, 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid file output - %s", probe.CheckEmpty(output))
+		}
+		output, content = parts[0], parts[1]
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	expected := 2
+	if len(f.algorithm) > 0 {
+		expected++
+	}
+	if len(lines) < expected {
+		return nil, fmt.Errorf("invalid file output - %s", probe.CheckEmpty(output))
+	}
+
+	now, err := strconv.ParseInt(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time output: %v", err)
+	}
+	st := strings.Fields(lines[1])
+	if len(st) != 2 {
+		return nil, fmt.Errorf("invalid stat output - %s", lines[1])
+	}
+	size, err := strconv.ParseInt(st[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file size: %v", err)
+	}
+	mtime, err := strconv.ParseInt(st[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file modification time: %v", err)
+	}
+
+	info := &fileInfo{
+		exists:  true,
+		size:    size,
+		modTime: time.Unix(mtime, 0),
+		now:     time.Unix(now, 0),
+		content: content,
+	}
+	if len(f.algorithm) > 0 {
+		info.checksum = strings.TrimSpace(lines[2])
+	}
+	return info, nil
+}
+
+// checkFile checks the file information
+func (f *File) checkFile(info *fileInfo) (bool, string) {
+	f.exists = info.exists
+	if !info.exists {
+		f.ExportMetrics()
+		return false, fmt.Sprintf("Error: %s does not exist", f.Path)
+	}
+
+	age := info.now.Sub(info.modTime)
+	if age < 0 {
+		age = 0
+	}
+	f.size, f.age = info.size, age.Seconds()
+	f.ExportMetrics()
+
+	message := fmt.Sprintf("%s - size %d bytes, modified %s ago", f.Path, info.size, age.Round(time.Second))
+
+	if f.MaxAge > 0 && age > f.MaxAge {
+		return false, fmt.Sprintf("Error: not modified within %s - %s", f.MaxAge, message)
+	}
+	if f.MinSize > 0 && info.size < f.MinSize {
+		return false, fmt.Sprintf("Error: the size is less than %d bytes - %s", f.MinSize, message)
+	}
+	if f.MaxSize > 0 && info.size > f.MaxSize {
+		return false, fmt.Sprintf("Error: the size is greater than %d bytes - %s", f.MaxSize, message)
+	}
+	if len(f.algorithm) > 0 && info.checksum != f.sum {
+		return false, fmt.Sprintf("Error: the %s checksum is %s, expected %s - %s",
+			f.algorithm, probe.CheckEmpty(info.checksum), f.sum, message)
+	}
+
+	if !f.needContent() {
+		return true, message
+	}
+
+	log.Debugf("[%s / %s] - %s", f.ProbeKind, f.ProbeName, f.TextChecker.String())
+	if err := f.Check(info.content); err != nil {
+		log.Errorf("[%s / %s] - %v", f.ProbeKind, f.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v - %s", err, message)
+	}
+
+	if f.Evaluator.DocType != eval.Unsupported && f.Evaluator.Extractor != nil &&
+		len(strings.TrimSpace(f.Evaluator.Expression)) > 0 {
+
+		log.Debugf("[%s / %s] - Evaluator expression: %s", f.ProbeKind, f.ProbeName, f.Evaluator.Expression)
+		f.Evaluator.SetDocument(f.Evaluator.DocType, info.content)
+		result, err := f.Evaluator.Evaluate()
+		if err != nil {
+			log.Errorf("[%s / %s] - %v", f.ProbeKind, f.ProbeName, err)
+			return false, fmt.Sprintf("Error: %s. Evaluation Error: %v", message, err)
+		}
+		if !result {
+			log.Errorf("[%s / %s] - expression is evaluated to false!", f.ProbeKind, f.ProbeName)
+			message = fmt.Sprintf("Error: %s. Expression is evaluated to false!", message)
+			for k, v := range f.Evaluator.ExtractedValues {
+				message += fmt.Sprintf(" [%s = %v]", k, v)
+			}
+			return false, message
+		}
+		log.Debugf("[%s / %s] - expression is evaluated to true!", f.ProbeKind, f.ProbeName)
+	}
+	return true, message
+}
+
+// ExportMetrics export file metrics
+func (f *File) ExportMetrics() {
+	exists := 0.0
+	if f.exists {
+		exists = 1
+	}
+	f.metrics.Exists.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     f.ProbeName,
+		"endpoint": f.ProbeResult.Endpoint,
+	}, f.Labels)).Set(exists)
+
+	if !f.exists {
+		return
+	}
+
+	f.metrics.Size.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     f.ProbeName,
+		"endpoint": f.ProbeResult.Endpoint,
+	}, f.Labels)).Set(float64(f.size))
+
+	f.metrics.Age.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     f.ProbeName,
+		"endpoint": f.ProbeResult.Endpoint,
+	}, f.Labels)).Set(f.age)
+}
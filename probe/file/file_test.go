@@ -0,0 +1,245 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/megaease/easeprobe/eval"
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/monkey"
+	"github.com/megaease/easeprobe/probe/base"
+	"github.com/megaease/easeprobe/probe/ssh"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFile(name, path string) *File {
+	return &File{
+		Server: ssh.Server{
+			DefaultProbe: base.DefaultProbe{ProbeName: name},
+		},
+		Path: path,
+	}
+}
+
+func TestFileLocal(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "backup.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"status": "ok", "rows": 100}`), 0644))
+
+	f := newFile("backup", path)
+	f.MaxAge = time.Hour
+	f.MinSize = 10
+	f.MaxSize = 1024
+	f.Checksum = "md5:e6d5a1d2d9c2c8a1d7b6f1b0a8f3c4d5"
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	assert.Equal(t, "file", f.ProbeKind)
+	assert.Equal(t, ssh.LocalHost+":"+path, f.ProbeResult.Endpoint)
+	s, m := f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "the md5 checksum is")
+
+	f.Checksum = "sha256:8F9A4E3F1DE3A5E2D6B2D2A3A2C8E5C5B0E3A3F9D7C0B4A1E6F2D3C4B5A69788"
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	assert.Equal(t, "sha256", f.algorithm)
+	assert.Equal(t, "8f9a4e3f1de3a5e2d6b2d2a3a2c8e5c5b0e3a3f9d7c0b4a1e6f2d3c4b5a69788", f.sum)
+
+	f.Checksum = ""
+	f.Contain = `"status": "ok"`
+	f.Evaluator = eval.Evaluator{DocType: eval.JSON, Expression: "x_int('//rows') >= 100"}
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	s, m = f.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "size 29 bytes")
+	assert.True(t, f.exists)
+	assert.Equal(t, int64(29), f.size)
+
+	f.Evaluator.Expression = "x_int('//rows') > 100"
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Expression is evaluated to false")
+
+	f.Evaluator = eval.Evaluator{}
+	f.Contain = "failed"
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "does not contain [failed]")
+
+	f.Contain = ""
+	f.MinSize = 100
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "the size is less than 100 bytes")
+
+	f.MinSize = 0
+	f.MaxSize = 10
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "the size is greater than 10 bytes")
+
+	f.MaxSize = 0
+	old := time.Now().Add(-2 * time.Hour)
+	assert.Nil(t, os.Chtimes(path, old, old))
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "not modified within 1h0m0s")
+
+	f.Path = filepath.Join(dir, "none")
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "does not exist")
+	assert.False(t, f.exists)
+}
+
+func TestFileChecksum(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	path := filepath.Join(t.TempDir(), "data")
+	assert.Nil(t, os.WriteFile(path, []byte("test"), 0644))
+
+	sums := map[string]string{
+		"md5":    "098f6bcd4621d373cade4e832627b4f6",
+		"sha1":   "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3",
+		"sha256": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	}
+	for algorithm, sum := range sums {
+		f := newFile(algorithm, path)
+		f.Checksum = algorithm + ":" + sum
+		assert.Nil(t, f.Config(global.ProbeSettings{}))
+		s, m := f.DoProbe()
+		assert.True(t, s, m)
+	}
+
+	// sha256 is the default algorithm
+	f := newFile("default", path)
+	f.Checksum = sums["sha256"]
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	s, m := f.DoProbe()
+	assert.True(t, s, m)
+
+	// the directory has no checksum
+	f.Path = filepath.Dir(path)
+	s, m = f.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "is a directory")
+}
+
+func TestFileRemote(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	f := newFile("remote backup", "/backup/it's.sql")
+	f.Host = "server.example.com"
+	f.User = "root"
+	f.Password = "password"
+	f.MaxAge = 26 * time.Hour
+	f.Checksum = "md5:098f6bcd4621d373cade4e832627b4f6"
+	f.Contain = "test"
+	assert.Nil(t, f.Config(global.ProbeSettings{}))
+	assert.Equal(t, "server.example.com:/backup/it's.sql", f.ProbeResult.Endpoint)
+	assert.Contains(t, f.Command, `f='/backup/it'\''s.sql'`)
+	assert.Contains(t, f.Command, `md5sum "$f"`)
+	assert.Contains(t, f.Command, fmt.Sprintf(`head -c %d "$f"`, maxContentSize))
+
+	now := time.Now().Unix()
+	output := fmt.Sprintf("%d\n4 %d\n098f6bcd4621d373cade4e832627b4f6\n---\ntest", now, now-3600)
+	var s *ssh.Server
+	monkey.PatchInstanceMethod(reflect.TypeOf(s), "RunSSHCmd", func(_ *ssh.Server) (string, error) {
+		return output, nil
+	})
+	defer monkey.UnpatchAll()
+
+	status, m := f.DoProbe()
+	assert.True(t, status, m)
+	assert.Equal(t, "/backup/it's.sql - size 4 bytes, modified 1h0m0s ago", m)
+	assert.Equal(t, 3600.0, f.age)
+
+	output = fmt.Sprintf("%d\n4 %d\n098f6bcd4621d373cade4e832627b4f6\n---\ntest", now, now-27*3600)
+	status, m = f.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "not modified within 26h0m0s")
+
+	output = "missing\n"
+	status, m = f.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "does not exist")
+
+	output = fmt.Sprintf("%d\n4 %d\n", now, now)
+	status, m = f.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "invalid file output")
+
+	output = "now\n4 0\nsum\n---\n"
+	status, m = f.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "invalid time output")
+
+	monkey.PatchInstanceMethod(reflect.TypeOf(s), "RunSSHCmd", func(_ *ssh.Server) (string, error) {
+		return "", fmt.Errorf("ssh error")
+	})
+	status, m = f.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "ssh error")
+}
+
+func TestFileConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	f := newFile("no path", "")
+	assert.NotNil(t, f.Config(global.ProbeSettings{}))
+
+	f = newFile("size", "/tmp")
+	f.MinSize = 10
+	f.MaxSize = 5
+	assert.NotNil(t, f.Config(global.ProbeSettings{}))
+
+	f = newFile("age", "/tmp")
+	f.MaxAge = -time.Second
+	assert.NotNil(t, f.Config(global.ProbeSettings{}))
+
+	f = newFile("algorithm", "/tmp")
+	f.Checksum = "crc32:1234"
+	err := f.Config(global.ProbeSettings{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unsupported checksum algorithm")
+
+	f = newFile("checksum", "/tmp")
+	f.Checksum = "md5:xyz"
+	err = f.Config(global.ProbeSettings{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid checksum")
+
+	f = newFile("regex", "/tmp")
+	f.RegExp = true
+	f.Contain = "("
+	assert.NotNil(t, f.Config(global.ProbeSettings{}))
+
+	f = newFile("remote", "/tmp")
+	f.Host = "server.example.com"
+	assert.NotNil(t, f.Config(global.ProbeSettings{}))
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics is the metrics for file probe
+type metrics struct {
+	Exists *prometheus.GaugeVec
+	Size   *prometheus.GaugeVec
+	Age    *prometheus.GaugeVec
+}
+
+// newMetrics create the file metrics
+func newMetrics(subsystem, name string, constLabels prometheus.Labels) *metrics {
+	namespace := global.GetEaseProbe().Name
+	return &metrics{
+		Exists: metric.NewGauge(namespace, subsystem, name, "exists",
+			"Whether the file exists", []string{"name", "endpoint"}, constLabels),
+		Size: metric.NewGauge(namespace, subsystem, name, "size",
+			"The size (bytes) of the file", []string{"name", "endpoint"}, constLabels),
+		Age: metric.NewGauge(namespace, subsystem, name, "age",
+			"The seconds since the file was modified", []string{"name", "endpoint"}, constLabels),
+	}
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package group is the host group package, a group declares several checks
+// against one target, and expands them into the individual probers.
+package group
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/aggregate"
+	"github.com/megaease/easeprobe/probe/base"
+	"github.com/megaease/easeprobe/probe/http"
+	"github.com/megaease/easeprobe/probe/ping"
+	"github.com/megaease/easeprobe/probe/ssh"
+	"github.com/megaease/easeprobe/probe/tcp"
+	"github.com/megaease/easeprobe/probe/tls"
+)
+
+// Checks is the sub-checks of the group
+type Checks struct {
+	TCP  []tcp.TCP    `yaml:"tcp,omitempty" json:"tcp,omitempty" jsonschema:"title=TCP Checks,description=The TCP checks of the group"`
+	HTTP []http.HTTP  `yaml:"http,omitempty" json:"http,omitempty" jsonschema:"title=HTTP Checks,description=The HTTP checks of the group"`
+	Ping []ping.Ping  `yaml:"ping,omitempty" json:"ping,omitempty" jsonschema:"title=Ping Checks,description=The Ping checks of the group"`
+	TLS  []tls.TLS    `yaml:"tls,omitempty" json:"tls,omitempty" jsonschema:"title=TLS Checks,description=The TLS checks of the group"`
+	SSH  []ssh.Server `yaml:"ssh,omitempty" json:"ssh,omitempty" jsonschema:"title=SSH Checks,description=The SSH checks of the group"`
+}
+
+// Group is one target with many checks.
+// The host, labels, channels, bastion, interval and timeout are inherited by all of the checks,
+// and a rollup probe named as the group reports the status of the whole group.
+type Group struct {
+	Name     string            `yaml:"name" json:"name" jsonschema:"required,title=Group Name,description=The name of the group, also the name of the rollup probe"`
+	Host     string            `yaml:"host" json:"host" jsonschema:"required,title=Host,description=The hostname or IP address of the target"`
+	Labels   prometheus.Labels `yaml:"labels,omitempty" json:"labels,omitempty" jsonschema:"title=Labels,description=The labels of all of the checks"`
+	Channels []string          `yaml:"channels,omitempty" json:"channels,omitempty" jsonschema:"title=Channels,description=The channels of all of the checks"`
+	Bastion  string            `yaml:"bastion,omitempty" json:"bastion,omitempty" jsonschema:"title=Bastion,description=The bastion host id of the SSH checks"`
+	Interval time.Duration     `yaml:"interval,omitempty" json:"interval,omitempty" jsonschema:"type=string,format=duration,title=Interval,description=The interval of all of the checks"`
+	Timeout  time.Duration     `yaml:"timeout,omitempty" json:"timeout,omitempty" jsonschema:"type=string,format=duration,title=Timeout,description=The timeout of all of the checks"`
+	Quorum   int               `yaml:"quorum,omitempty" json:"quorum,omitempty" jsonschema:"title=Quorum,description=The minimum number of the checks must be up for the group to be up (0 means all of them),default=0"`
+	Checks   Checks            `yaml:"checks" json:"checks" jsonschema:"required,title=Checks,description=The checks of the group"`
+
+	probers []probe.Prober `yaml:"-" json:"-"`
+}
+
+// Probers returns the expanded probers of the group, the rollup probe is the last one.
+func (g *Group) Probers() []probe.Prober {
+	if g.probers != nil {
+		return g.probers
+	}
+	if err := g.check(); err != nil {
+		log.Errorf("[group / %s] %v, ignored!", g.Name, err)
+		g.probers = []probe.Prober{}
+		return g.probers
+	}
+
+	probers := []probe.Prober{}
+	for i := range g.Checks.TCP {
+		c := &g.Checks.TCP[i]
+		g.inherit(&c.DefaultProbe, "tcp", i, len(g.Checks.TCP))
+		c.Host = g.hostPort(c.Host)
+		probers = append(probers, c)
+	}
+	for i := range g.Checks.HTTP {
+		c := &g.Checks.HTTP[i]
+		g.inherit(&c.DefaultProbe, "http", i, len(g.Checks.HTTP))
+		c.URL, _ = g.url(c.URL) // the URL has been checked
+		probers = append(probers, c)
+	}
+	for i := range g.Checks.Ping {
+		c := &g.Checks.Ping[i]
+		g.inherit(&c.DefaultProbe, "ping", i, len(g.Checks.Ping))
+		if len(c.Host) <= 0 {
+			c.Host = g.hostname()
+		}
+		probers = append(probers, c)
+	}
+	for i := range g.Checks.TLS {
+		c := &g.Checks.TLS[i]
+		g.inherit(&c.DefaultProbe, "tls", i, len(g.Checks.TLS))
+		c.Host = g.hostPort(c.Host)
+		probers = append(probers, c)
+	}
+	for i := range g.Checks.SSH {
+		c := &g.Checks.SSH[i]
+		g.inherit(&c.DefaultProbe, "ssh", i, len(g.Checks.SSH))
+		c.Host = g.hostPort(c.Host)
+		if len(c.BastionID) <= 0 {
+			c.BastionID = g.Bastion
+		}
+		probers = append(probers, c)
+	}
+
+	// the rollup probe aggregates all of the checks
+	members := make([]string, 0, len(probers))
+	for _, p := range probers {
+		members = append(members, escape(p.Name()))
+	}
+	rollup := &aggregate.Aggregate{
+		Probes: members,
+		Quorum: g.Quorum,
+	}
+	rollup.ProbeName = g.Name
+	g.inherit(&rollup.DefaultProbe, "", 0, 0)
+	probers = append(probers, rollup)
+
+	g.probers = probers
+	return g.probers
+}
+
+// check the group configuration
+func (g *Group) check() error {
+	if len(g.Name) <= 0 {
+		return fmt.Errorf("the name of the group is required")
+	}
+	if len(g.Host) <= 0 {
+		return fmt.Errorf("the host of the group is required")
+	}
+	if len(g.Checks.TCP)+len(g.Checks.HTTP)+len(g.Checks.Ping)+len(g.Checks.TLS)+len(g.Checks.SSH) <= 0 {
+		return fmt.Errorf("no check is configured in the group")
+	}
+	for _, c := range g.Checks.HTTP {
+		if _, err := g.url(c.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inherit sets the group settings to the check if the check doesn't have its own.
+// The check is named as "group / name", the name is the kind of the check by default.
+func (g *Group) inherit(p *base.DefaultProbe, kind string, idx, total int) {
+	if len(kind) > 0 {
+		name := p.ProbeName
+		if len(name) <= 0 {
+			name = kind
+			if total > 1 {
+				name = fmt.Sprintf("%s #%d", kind, idx+1)
+			}
+		}
+		p.ProbeName = g.Name + " / " + name
+	}
+	if len(p.ProbeChannels) <= 0 && len(g.Channels) > 0 {
+		p.ProbeChannels = append([]string{}, g.Channels...)
+	}
+	if p.ProbeTimeInterval <= 0 {
+		p.ProbeTimeInterval = g.Interval
+	}
+	if p.ProbeTimeout <= 0 {
+		p.ProbeTimeout = g.Timeout
+	}
+	// every check has its own label map, the check's labels take precedence
+	labels := prometheus.Labels{}
+	for k, v := range g.Labels {
+		labels[k] = v
+	}
+	for k, v := range p.Labels {
+		labels[k] = v
+	}
+	if len(labels) > 0 {
+		p.Labels = labels
+	}
+}
+
+// hostPort fills the group host into the "host:port" of the check,
+// an empty host means the group host, and ":port" means the port of the group host.
+func (g *Group) hostPort(host string) string {
+	if len(host) <= 0 {
+		return g.Host
+	}
+	if strings.HasPrefix(host, ":") {
+		return net.JoinHostPort(g.hostname(), host[1:])
+	}
+	return host
+}
+
+// hostname returns the group host without the brackets of the IPv6 address
+func (g *Group) hostname() string {
+	return strings.TrimSuffix(strings.TrimPrefix(g.Host, "["), "]")
+}
+
+// url fills the group host into the URL of the check if the URL has no host,
+// e.g. "/health" => "http://host/health", "https://:8443/health" => "https://host:8443/health".
+// Only the path or the URL with the scheme and the port could be without the host.
+func (g *Group) url(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL [%s] of the http check - %v", u, err)
+	}
+	if len(parsed.Hostname()) > 0 {
+		return u, nil
+	}
+	switch {
+	case len(parsed.Scheme) <= 0 && len(parsed.Host) <= 0 && strings.HasPrefix(parsed.Path, "/"):
+		parsed.Scheme = "http"
+	case len(parsed.Scheme) > 0 && len(parsed.Port()) > 0:
+	default:
+		return "", fmt.Errorf("invalid URL [%s] of the http check - it must be a path starting with \"/\", "+
+			"a URL with the host, or \"scheme://:port\" to use the group host", u)
+	}
+	host := g.hostname()
+	switch {
+	case len(parsed.Port()) > 0:
+		parsed.Host = net.JoinHostPort(host, parsed.Port())
+	case strings.Contains(host, ":"):
+		parsed.Host = "[" + host + "]"
+	default:
+		parsed.Host = host
+	}
+	return parsed.String(), nil
+}
+
+// escape the glob meta characters, so the aggregate probe matches the name exactly
+func escape(name string) string {
+	r := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+	return r.Replace(name)
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package group
+
+import (
+	"testing"
+	"time"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/aggregate"
+	"github.com/megaease/easeprobe/probe/http"
+	"github.com/megaease/easeprobe/probe/ping"
+	"github.com/megaease/easeprobe/probe/ssh"
+	"github.com/megaease/easeprobe/probe/tcp"
+	"github.com/megaease/easeprobe/probe/tls"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const groupYAML = `
+name: web-01
+host: 10.0.0.1
+labels:
+  env: prod
+channels:
+  - ops
+bastion: aws
+interval: 30s
+timeout: 5s
+quorum: 4
+checks:
+  tcp:
+    - host: ":22"
+    - host: ":3306"
+      name: mysql
+      channels: [ "dba" ]
+  http:
+    - url: "/health"
+      labels:
+        env: canary
+    - url: "https://:8443/status"
+      interval: 1m
+    - url: "https://example.com"
+  ping:
+    - {}
+  tls:
+    - host: ":443"
+  ssh:
+    - cmd: uptime
+      username: root
+    - cmd: uptime
+      host: other:2222
+      bastion: gcp
+`
+
+func TestGroupProbers(t *testing.T) {
+	g := Group{}
+	assert.Nil(t, yaml.Unmarshal([]byte(groupYAML), &g))
+
+	probers := g.Probers()
+	assert.Equal(t, 10, len(probers))
+	// the probers are expanded only once
+	assert.Equal(t, probers, g.Probers())
+
+	names := []string{}
+	for _, p := range probers {
+		names = append(names, p.Name())
+	}
+	assert.Equal(t, []string{
+		"web-01 / tcp #1", "web-01 / mysql",
+		"web-01 / http #1", "web-01 / http #2", "web-01 / http #3",
+		"web-01 / ping", "web-01 / tls",
+		"web-01 / ssh #1", "web-01 / ssh #2",
+		"web-01",
+	}, names)
+
+	t1 := probers[0].(*tcp.TCP)
+	assert.Equal(t, "10.0.0.1:22", t1.Host)
+	assert.Equal(t, []string{"ops"}, t1.ProbeChannels)
+	assert.Equal(t, 30*time.Second, t1.ProbeTimeInterval)
+	assert.Equal(t, 5*time.Second, t1.ProbeTimeout)
+	assert.Equal(t, "prod", t1.Labels["env"])
+	t2 := probers[1].(*tcp.TCP)
+	assert.Equal(t, "10.0.0.1:3306", t2.Host)
+	assert.Equal(t, []string{"dba"}, t2.ProbeChannels)
+
+	h1 := probers[2].(*http.HTTP)
+	assert.Equal(t, "http://10.0.0.1/health", h1.URL)
+	assert.Equal(t, "canary", h1.Labels["env"])
+	h2 := probers[3].(*http.HTTP)
+	assert.Equal(t, "https://10.0.0.1:8443/status", h2.URL)
+	assert.Equal(t, time.Minute, h2.ProbeTimeInterval)
+	assert.Equal(t, "https://example.com", probers[4].(*http.HTTP).URL)
+
+	// every check has its own label map
+	t1.Labels["env"] = "changed"
+	assert.Equal(t, "prod", t2.Labels["env"])
+
+	assert.Equal(t, "10.0.0.1", probers[5].(*ping.Ping).Host)
+	assert.Equal(t, "10.0.0.1:443", probers[6].(*tls.TLS).Host)
+
+	s1 := probers[7].(*ssh.Server)
+	assert.Equal(t, "10.0.0.1", s1.Host)
+	assert.Equal(t, "aws", s1.BastionID)
+	s2 := probers[8].(*ssh.Server)
+	assert.Equal(t, "other:2222", s2.Host)
+	assert.Equal(t, "gcp", s2.BastionID)
+
+	rollup := probers[9].(*aggregate.Aggregate)
+	assert.Equal(t, 4, rollup.Quorum)
+	assert.Equal(t, 9, len(rollup.Probes))
+	assert.Equal(t, "web-01 / tcp #1", rollup.Probes[0])
+	assert.Equal(t, []string{"ops"}, rollup.ProbeChannels)
+}
+
+func TestGroupIPv6(t *testing.T) {
+	for _, host := range []string{"2001:db8::1", "[2001:db8::1]"} {
+		g := Group{
+			Name: "v6 " + host,
+			Host: host,
+			Checks: Checks{
+				TCP:  []tcp.TCP{{Host: ":22"}},
+				HTTP: []http.HTTP{{URL: "/health"}, {URL: "https://:8443/status"}},
+				Ping: []ping.Ping{{}},
+				TLS:  []tls.TLS{{Host: ":443"}},
+			},
+		}
+		probers := g.Probers()
+		assert.Equal(t, 6, len(probers))
+		assert.Equal(t, "[2001:db8::1]:22", probers[0].(*tcp.TCP).Host)
+		assert.Equal(t, "http://[2001:db8::1]/health", probers[1].(*http.HTTP).URL)
+		assert.Equal(t, "https://[2001:db8::1]:8443/status", probers[2].(*http.HTTP).URL)
+		assert.Equal(t, "2001:db8::1", probers[3].(*ping.Ping).Host)
+		assert.Equal(t, "[2001:db8::1]:443", probers[4].(*tls.TLS).Host)
+	}
+}
+
+func TestGroupRollup(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	g := Group{
+		Name: "db [primary]",
+		Host: "127.0.0.1",
+		Checks: Checks{
+			TCP: []tcp.TCP{{Host: ":1"}, {Host: ":2"}},
+		},
+	}
+	probers := g.Probers()
+	assert.Equal(t, 3, len(probers))
+	for _, p := range probers {
+		assert.Nil(t, p.Config(global.ProbeSettings{}))
+	}
+
+	set := func(name string, s probe.Status) {
+		r := probe.NewResultWithName(name)
+		r.Status = s
+		probe.SetResultData(name, r)
+	}
+	set("db [primary] / tcp #1", probe.StatusUp)
+	set("db [primary] / tcp #2", probe.StatusUp)
+
+	rollup := probers[2]
+	assert.Equal(t, `db \[primary] / tcp #1`, rollup.(*aggregate.Aggregate).Probes[0])
+	s, m := rollup.(*aggregate.Aggregate).DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "2 of 2 probes are up")
+
+	set("db [primary] / tcp #2", probe.StatusDown)
+	s, _ = rollup.(*aggregate.Aggregate).DoProbe()
+	assert.False(t, s)
+}
+
+func TestGroupInvalid(t *testing.T) {
+	g := Group{Host: "localhost", Checks: Checks{Ping: []ping.Ping{{}}}}
+	assert.Empty(t, g.Probers())
+
+	g = Group{Name: "no host", Checks: Checks{Ping: []ping.Ping{{}}}}
+	assert.Empty(t, g.Probers())
+
+	g = Group{Name: "no checks", Host: "localhost"}
+	assert.Empty(t, g.Probers())
+
+	for _, u := range []string{"example.com/health", "health", "", "https:///health", "example.com:8080/health", "http://%zz"} {
+		g = Group{Name: "invalid url", Host: "localhost", Checks: Checks{HTTP: []http.HTTP{{URL: u}}}}
+		assert.Error(t, g.check(), u)
+		assert.Empty(t, g.Probers(), u)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grpc is the gRPC health checking probe package
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+// GRPC implements a config for gRPC health checking
+type GRPC struct {
+	base.DefaultProbe `yaml:",inline"`
+	Host              string            `yaml:"host" json:"host" jsonschema:"required,format=hostname,title=Host,description=The gRPC server to probe,example=localhost:50051"`
+	Service           string            `yaml:"service,omitempty" json:"service,omitempty" jsonschema:"title=Service,description=The service name to check (empty means the overall health of the server)"`
+	Watch             bool              `yaml:"watch,omitempty" json:"watch,omitempty" jsonschema:"title=Watch,description=Use the Health/Watch streaming method instead of Health/Check,default=false"`
+	Metadata          map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty" jsonschema:"title=Metadata,description=The metadata headers sent with the request"`
+	UseTLS            bool              `yaml:"use_tls,omitempty" json:"use_tls,omitempty" jsonschema:"title=Use TLS,description=Use TLS with the system root CAs if no CA file is configured,default=false"`
+
+	// Option - TLS Config
+	global.TLS `yaml:",inline"`
+
+	credentials credentials.TransportCredentials `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// Config gRPC Config Object
+func (g *GRPC) Config(gConf global.ProbeSettings) error {
+	kind := "grpc"
+	tag := ""
+	name := g.ProbeName
+	g.DefaultProbe.Config(gConf, kind, tag, name, g.Host, g.DoProbe)
+
+	if _, _, err := net.SplitHostPort(g.Host); err != nil {
+		log.Errorf("[%s / %s] Invalid Host: %s - %v", g.ProbeKind, g.ProbeName, g.Host, err)
+		return fmt.Errorf("Invalid Host: %s. %v", g.Host, err)
+	}
+
+	tlsConfig, err := g.TLS.Config()
+	if err != nil {
+		log.Errorf("[%s / %s] TLS configuration error - %s", g.ProbeKind, g.ProbeName, err)
+		return err
+	}
+	if tlsConfig == nil && g.UseTLS {
+		tlsConfig = &tls.Config{}
+	}
+	if tlsConfig != nil {
+		g.credentials = credentials.NewTLS(tlsConfig)
+	} else {
+		g.credentials = insecure.NewCredentials()
+	}
+
+	g.metrics = newMetrics(kind, tag, g.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", g.ProbeKind, g.ProbeName, *g)
+	return nil
+}
+
+// DoProbe return the checking result
+func (g *GRPC) DoProbe() (bool, string) {
+	conn, err := grpc.NewClient(g.Host,
+		grpc.WithTransportCredentials(g.credentials),
+		grpc.WithUserAgent(global.OrgProgVer))
+	if err != nil {
+		log.Errorf("[%s / %s] error: %v", g.ProbeKind, g.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.Timeout())
+	defer cancel()
+	if len(g.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(g.Metadata))
+	}
+
+	client := grpc_health_v1.NewHealthClient(conn)
+	req := &grpc_health_v1.HealthCheckRequest{Service: g.Service}
+
+	method := "Check"
+	var resp *grpc_health_v1.HealthCheckResponse
+	if g.Watch {
+		method = "Watch"
+		resp, err = g.watch(ctx, client, req)
+	} else {
+		resp, err = client.Check(ctx, req)
+	}
+
+	servingStatus := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	if resp != nil {
+		servingStatus = resp.GetStatus()
+	}
+	g.ExportMetrics(servingStatus)
+
+	if err != nil {
+		log.Errorf("[%s / %s] %s error: %v", g.ProbeKind, g.ProbeName, method, err)
+		return false, fmt.Sprintf("Error: %s", errorMessage(err))
+	}
+
+	message := fmt.Sprintf("gRPC Health %s - service [%s] is %s", method, g.Service, servingStatus)
+	if servingStatus != grpc_health_v1.HealthCheckResponse_SERVING {
+		return false, message
+	}
+	return true, message
+}
+
+// watch only waits for the first response of the Watch stream which is the current serving status
+func (g *GRPC) watch(ctx context.Context, client grpc_health_v1.HealthClient,
+	req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+
+	stream, err := client.Watch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return stream.Recv()
+}
+
+// errorMessage makes the gRPC status error more readable
+func errorMessage(err error) string {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err.Error()
+	}
+	switch s.Code() {
+	case codes.Unimplemented:
+		return "the server does not implement the gRPC health checking protocol"
+	case codes.NotFound:
+		return "the service is unknown to the server"
+	}
+	return fmt.Sprintf("%s - %s", s.Code(), s.Message())
+}
+
+// ExportMetrics export gRPC metrics
+func (g *GRPC) ExportMetrics(s grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	g.metrics.ServingStatus.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     g.ProbeName,
+		"service":  g.Service,
+		"status":   s.String(),
+		"endpoint": g.ProbeResult.Endpoint,
+	}, g.Labels)).Inc()
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+func authInterceptor(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get("authorization")) == 0 || md.Get("authorization")[0] != "token" {
+		return status.Error(codes.Unauthenticated, "missing token")
+	}
+	return nil
+}
+
+func startServer(t *testing.T, auth bool) (string, *health.Server, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	var opts []grpc.ServerOption
+	if auth {
+		opts = append(opts,
+			grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				if err := authInterceptor(ctx); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := authInterceptor(ss.Context()); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}))
+	}
+	server := grpc.NewServer(opts...)
+	hs := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, hs)
+	go server.Serve(l)
+
+	return l.Addr().String(), hs, server.Stop
+}
+
+func newGRPC(host, service string) *GRPC {
+	return &GRPC{
+		DefaultProbe: base.DefaultProbe{ProbeName: "dummy grpc", ProbeTimeout: time.Second},
+		Host:         host,
+		Service:      service,
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, hs, stop := startServer(t, false)
+	defer stop()
+	hs.SetServingStatus("foo", grpc_health_v1.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("bar", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+
+	g := newGRPC(addr, "")
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	assert.Equal(t, "grpc", g.ProbeKind)
+	assert.Equal(t, "insecure", g.credentials.Info().SecurityProtocol)
+
+	for _, watch := range []bool{false, true} {
+		g.Watch = watch
+
+		g.Service = ""
+		s, m := g.DoProbe()
+		assert.True(t, s, m)
+
+		g.Service = "foo"
+		s, m = g.DoProbe()
+		assert.True(t, s, m)
+		assert.Contains(t, m, "service [foo] is SERVING")
+
+		g.Service = "bar"
+		s, m = g.DoProbe()
+		assert.False(t, s)
+		assert.Contains(t, m, "service [bar] is NOT_SERVING")
+	}
+
+	// Check returns NotFound for an unknown service
+	g.Watch = false
+	g.Service = "unknown"
+	s, m := g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "the service is unknown to the server")
+
+	// Watch reports SERVICE_UNKNOWN for an unknown service
+	g.Watch = true
+	s, m = g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "SERVICE_UNKNOWN")
+}
+
+func TestGRPCMetadata(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, _, stop := startServer(t, true)
+	defer stop()
+
+	g := newGRPC(addr, "")
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	for _, watch := range []bool{false, true} {
+		g.Watch = watch
+		g.Metadata = nil
+		s, m := g.DoProbe()
+		assert.False(t, s)
+		assert.Contains(t, m, "Unauthenticated - missing token")
+
+		g.Metadata = map[string]string{"Authorization": "token"}
+		s, m = g.DoProbe()
+		assert.True(t, s, m)
+	}
+}
+
+func TestGRPCConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	g := newGRPC("localhost", "")
+	assert.NotNil(t, g.Config(global.ProbeSettings{}))
+	assert.NotNil(t, g.Result())
+
+	g = newGRPC("localhost:50051", "")
+	g.UseTLS = true
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	assert.Equal(t, "tls", g.credentials.Info().SecurityProtocol)
+
+	g = newGRPC("localhost:50051", "")
+	g.TLS = global.TLS{Insecure: true}
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	assert.Equal(t, "tls", g.credentials.Info().SecurityProtocol)
+
+	g.TLS = global.TLS{CA: "/path/not/exist"}
+	assert.NotNil(t, g.Config(global.ProbeSettings{}))
+
+	// nothing is listening
+	g = newGRPC("127.0.0.1:1", "")
+	assert.Nil(t, g.Config(global.ProbeSettings{}))
+	s, m := g.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error")
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package heartbeat is the passive heartbeat probe package (a.k.a. dead man's switch).
+// It doesn't poll anything, the monitored jobs send the heartbeats to the web server,
+// and the probe is down if no heartbeat arrives in time.
+package heartbeat
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+// DefaultGrace is the default grace period after the interval
+const DefaultGrace = 30 * time.Second
+
+// APIPath is the path prefix of the heartbeat API of the web server
+const APIPath = "/api/v1/heartbeat/"
+
+// The errors of receiving the heartbeat
+var (
+	ErrNotFound     = errors.New("heartbeat probe not found")
+	ErrUnauthorized = errors.New("invalid heartbeat token")
+)
+
+// Heartbeat implements a config for the passive heartbeat probe
+type Heartbeat struct {
+	base.DefaultProbe `yaml:",inline"`
+	Token             string        `yaml:"token" json:"token" jsonschema:"required,title=Token,description=the token which the heartbeat request must carry"`
+	Grace             time.Duration `yaml:"grace,omitempty" json:"grace,omitempty" jsonschema:"type=string,format=duration,title=Grace Period,description=the grace period after the interval,default=30s"`
+
+	mutex    sync.Mutex `yaml:"-" json:"-"`
+	start    time.Time  `yaml:"-" json:"-"`
+	lastPing time.Time  `yaml:"-" json:"-"`
+	from     string     `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// the heartbeat probes which receive the heartbeats, the key is the probe name
+var (
+	registry      = map[string]*Heartbeat{}
+	registryMutex sync.RWMutex
+)
+
+// Config Heartbeat Config Object
+func (h *Heartbeat) Config(gConf global.ProbeSettings) error {
+	kind := "heartbeat"
+	tag := ""
+	name := h.ProbeName
+	endpoint := APIPath + name
+
+	if err := h.DefaultProbe.Config(gConf, kind, tag, name, endpoint, h.DoProbe); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(h.Token)) <= 0 {
+		log.Errorf("[%s / %s] the token is required", h.ProbeKind, h.ProbeName)
+		return fmt.Errorf("the token is required")
+	}
+	if h.Grace < 0 {
+		log.Errorf("[%s / %s] the grace period cannot be negative", h.ProbeKind, h.ProbeName)
+		return fmt.Errorf("the grace period cannot be negative")
+	}
+	if h.Grace == 0 {
+		h.Grace = DefaultGrace
+	}
+
+	h.mutex.Lock()
+	h.start = time.Now()
+	h.mutex.Unlock()
+
+	registryMutex.Lock()
+	registry[name] = h
+	registryMutex.Unlock()
+
+	h.metrics = newMetrics(kind, tag, h.Labels)
+
+	log.Debugf("[%s / %s] configuration: interval %s, grace %s", h.ProbeKind, h.ProbeName, h.Interval(), h.Grace)
+	return nil
+}
+
+// Ping receives the heartbeat of the probe with the token, from is the address of the sender
+func Ping(name, token, from string) error {
+	registryMutex.RLock()
+	h, ok := registry[name]
+	registryMutex.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) != 1 {
+		log.Warnf("[%s / %s] invalid heartbeat token from %s", h.ProbeKind, h.ProbeName, from)
+		return ErrUnauthorized
+	}
+
+	h.mutex.Lock()
+	h.lastPing = time.Now()
+	h.from = from
+	h.mutex.Unlock()
+
+	log.Debugf("[%s / %s] received the heartbeat from %s", h.ProbeKind, h.ProbeName, from)
+	return nil
+}
+
+// DoProbe return the checking result
+func (h *Heartbeat) DoProbe() (bool, string) {
+	h.mutex.Lock()
+	start, lastPing, from := h.start, h.lastPing, h.from
+	h.mutex.Unlock()
+
+	deadline := h.Interval() + h.Grace
+	now := time.Now()
+
+	if lastPing.IsZero() {
+		elapsed := now.Sub(start)
+		h.ExportMetrics(elapsed)
+		if elapsed > deadline {
+			return false, fmt.Sprintf("Error: no heartbeat received in %s since started", elapsed.Round(time.Second))
+		}
+		return true, "Waiting for the first heartbeat"
+	}
+
+	elapsed := now.Sub(lastPing)
+	h.ExportMetrics(elapsed)
+	message := fmt.Sprintf("Last heartbeat from %s at %s (%s ago)",
+		from, lastPing.UTC().Format(time.RFC3339), elapsed.Round(time.Second))
+	if elapsed > deadline {
+		return false, fmt.Sprintf("Error: no heartbeat within %s (interval %s + grace %s) - %s",
+			deadline, h.Interval(), h.Grace, message)
+	}
+	return true, message
+}
+
+// ExportMetrics export heartbeat metrics
+func (h *Heartbeat) ExportMetrics(elapsed time.Duration) {
+	h.metrics.Elapsed.With(metric.AddConstLabels(prometheus.Labels{
+		"name": h.ProbeName,
+	}, h.Labels)).Set(elapsed.Seconds())
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package heartbeat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/probe/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHeartbeat(name, token string) *Heartbeat {
+	return &Heartbeat{
+		DefaultProbe: base.DefaultProbe{
+			ProbeName:         name,
+			ProbeTimeInterval: time.Minute,
+		},
+		Token: token,
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	h := newHeartbeat("backup job", "secret")
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	assert.Equal(t, "heartbeat", h.ProbeKind)
+	assert.Equal(t, DefaultGrace, h.Grace)
+	assert.Equal(t, "/api/v1/heartbeat/backup job", h.ProbeResult.Endpoint)
+
+	// waiting for the first heartbeat
+	s, m := h.DoProbe()
+	assert.True(t, s)
+	assert.Equal(t, "Waiting for the first heartbeat", m)
+
+	// no heartbeat since started
+	h.start = time.Now().Add(-2 * time.Minute)
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "no heartbeat received in 2m0s since started")
+
+	assert.Equal(t, ErrNotFound, Ping("none", "secret", "127.0.0.1"))
+	assert.Equal(t, ErrUnauthorized, Ping("backup job", "wrong", "127.0.0.1"))
+	assert.Equal(t, ErrUnauthorized, Ping("backup job", "", "127.0.0.1"))
+	assert.Nil(t, Ping("backup job", "secret", "10.0.0.1:1234"))
+
+	s, m = h.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "Last heartbeat from 10.0.0.1:1234")
+
+	// within the grace period
+	h.lastPing = time.Now().Add(-80 * time.Second)
+	s, m = h.DoProbe()
+	assert.True(t, s, m)
+
+	// the heartbeat is missed
+	h.lastPing = time.Now().Add(-100 * time.Second)
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "no heartbeat within 1m30s (interval 1m0s + grace 30s)")
+
+	assert.Nil(t, Ping("backup job", "secret", "10.0.0.2:1234"))
+	s, m = h.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "10.0.0.2:1234")
+}
+
+func TestHeartbeatConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	h := newHeartbeat("no token", " ")
+	assert.NotNil(t, h.Config(global.ProbeSettings{}))
+	assert.Equal(t, ErrNotFound, Ping("no token", " ", "127.0.0.1"))
+
+	h = newHeartbeat("negative grace", "secret")
+	h.Grace = -time.Second
+	assert.NotNil(t, h.Config(global.ProbeSettings{}))
+
+	h = newHeartbeat("grace", "secret")
+	h.Grace = time.Hour
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	assert.Equal(t, time.Hour, h.Grace)
+}
@@ -36,6 +36,11 @@ type IMetrics interface {
 	ExportMetrics(name string)      // ExportMetrics export the metrics
 }
 
+// Degrader is the optional interface of the metrics which have the soft threshold
+type Degrader interface {
+	CheckDegraded() (bool, string) // CheckDegraded returns true if the metrics usage breaches the soft threshold
+}
+
 // ResourceUsage is the resource usage for cpu and memory
 type ResourceUsage struct {
 	Used  int     `yaml:"used"`
@@ -42,8 +42,11 @@ type CPU struct {
 	Soft              float64 `yaml:"soft"`
 	Steal             float64 `yaml:"steal"`
 
-	Threshold float64 `yaml:"threshold"`
-	metrics   *prometheus.GaugeVec
+	Threshold         float64 `yaml:"threshold"`
+	DegradedThreshold float64 `yaml:"degraded_threshold"`
+	metrics           *prometheus.GaugeVec
+	lastStat          []float64 // the last /proc/stat of the local host
+	lastUsage         string    // the last cpu usage of the local host
 }
 
 // Name returns the name of the metric
@@ -74,6 +77,7 @@ func (c *CPU) Config(s *Server) {
 // SetThreshold set the cpu threshold
 func (c *CPU) SetThreshold(t *Threshold) {
 	c.Threshold = t.CPU
+	c.DegradedThreshold = t.DegradedCPU
 }
 
 // Parse a string to a CPU struct
@@ -109,6 +113,14 @@ func (c *CPU) CheckThreshold() (bool, string) {
 	return true, ""
 }
 
+// CheckDegraded check the cpu usage with the soft threshold
+func (c *CPU) CheckDegraded() (bool, string) {
+	if c.DegradedThreshold > 0 && c.DegradedThreshold <= (100-c.Idle)/100 {
+		return true, fmt.Sprintf("CPU usage %.2f%% >= %.2f%%", 100-c.Idle, c.DegradedThreshold*100)
+	}
+	return false, ""
+}
+
 // CreateMetrics create the cpu metrics
 func (c *CPU) CreateMetrics(subsystem, name string) {
 	namespace := global.GetEaseProbe().Name
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+var customNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Custom is the user defined metrics, every metric is a command which outputs a number
+type Custom struct {
+	base.DefaultProbe `yaml:",inline"`
+	Names             []string           `yaml:"names"`
+	Commands          map[string]string  `yaml:"commands"`
+	Metrics           map[string]float64 `yaml:"metrics"`
+
+	Threshold map[string]float64 `yaml:"threshold"`
+	metrics   *prometheus.GaugeVec
+}
+
+// Name returns the name of the metrics
+func (c *Custom) Name() string {
+	return "custom"
+}
+
+// exitStatus is the output of the custom metric command which exits with non-zero status
+const exitStatus = "exit status "
+
+// Command returns the commands of the custom metrics.
+// Every command outputs exactly one line, which is the last line of its output,
+// or the "exit status N" if the command fails.
+func (c *Custom) Command() string {
+	cmds := []string{}
+	for _, n := range c.Names {
+		cmds = append(cmds, `if out="$(`+c.Commands[n]+`)"; then echo "$out" | tail -n 1; else echo "`+exitStatus+`$?"; fi;`)
+	}
+	return strings.Join(cmds, "\n")
+}
+
+// OutputLines returns the lines of command output
+func (c *Custom) OutputLines() int {
+	return len(c.Names)
+}
+
+// Config returns the config of the custom metrics
+func (c *Custom) Config(s *Server) {
+	c.Names = []string{}
+	c.Commands = s.Metrics
+	for n := range s.Metrics {
+		c.Names = append(c.Names, n)
+	}
+	sort.Strings(c.Names)
+	c.Metrics = make(map[string]float64)
+	c.SetThreshold(&s.Threshold)
+	c.CreateMetrics(s.ProbeKind, s.ProbeTag)
+}
+
+// SetThreshold set the threshold of the custom metrics
+func (c *Custom) SetThreshold(t *Threshold) {
+	c.Threshold = t.Custom
+}
+
+// Parse the output of the custom metrics
+func (c *Custom) Parse(s []string) error {
+	if len(s) < c.OutputLines() {
+		return fmt.Errorf("invalid custom metrics output")
+	}
+	for i, n := range c.Names {
+		if strings.HasPrefix(s[i], exitStatus) {
+			return fmt.Errorf("custom metric [%s] command failed: %s", n, strings.TrimSpace(s[i]))
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(s[i]), 64)
+		if err != nil {
+			return fmt.Errorf("invalid custom metric [%s] output: %q", n, strings.TrimSpace(s[i]))
+		}
+		c.Metrics[n] = v
+	}
+	return nil
+}
+
+// UsageInfo returns the usage info of the custom metrics
+func (c *Custom) UsageInfo() string {
+	usage := []string{}
+	for _, n := range c.Names {
+		usage = append(usage, fmt.Sprintf("%s: %s", n, strconv.FormatFloat(c.Metrics[n], 'f', -1, 64)))
+	}
+	return strings.Join(usage, " - ")
+}
+
+// CheckThreshold check the custom metrics threshold
+func (c *Custom) CheckThreshold() (bool, string) {
+	status := true
+	message := ""
+	for _, n := range c.Names {
+		t, ok := c.Threshold[n]
+		if !ok {
+			continue
+		}
+		if v := c.Metrics[n]; v > t {
+			status = false
+			message = addMessage(message, fmt.Sprintf("%s threshold alert! - %s > %s", n,
+				strconv.FormatFloat(v, 'f', -1, 64), strconv.FormatFloat(t, 'f', -1, 64)))
+		}
+	}
+	return status, message
+}
+
+// CreateMetrics create the custom metrics
+func (c *Custom) CreateMetrics(subsystem, name string) {
+	namespace := global.GetEaseProbe().Name
+	c.metrics = metric.NewGauge(namespace, subsystem, name, "custom",
+		"Custom Metrics", []string{"host", "metric"}, c.Labels)
+}
+
+// ExportMetrics export the custom metrics
+func (c *Custom) ExportMetrics(name string) {
+	for _, n := range c.Names {
+		c.metrics.With(metric.AddConstLabels(prometheus.Labels{
+			"host":   name,
+			"metric": n,
+		}, c.Labels)).Set(c.Metrics[n])
+	}
+}
+
+// checkCustomMetrics checks the names of the custom metrics and their thresholds
+func checkCustomMetrics(metrics map[string]string, t *Threshold) error {
+	reserved := map[string]bool{}
+	tt := reflect.TypeOf(*t)
+	for i := 0; i < tt.NumField(); i++ {
+		tag := strings.Split(tt.Field(i).Tag.Get("yaml"), ",")[0]
+		if len(tag) > 0 {
+			reserved[tag] = true
+		}
+	}
+
+	for n, cmd := range metrics {
+		if !customNameRegex.MatchString(n) {
+			return fmt.Errorf("invalid custom metric name [%s]", n)
+		}
+		if reserved[n] {
+			return fmt.Errorf("the custom metric name [%s] is reserved", n)
+		}
+		if len(strings.TrimSpace(cmd)) <= 0 {
+			return fmt.Errorf("the command of the custom metric [%s] is empty", n)
+		}
+	}
+	for n := range t.Custom {
+		if _, ok := metrics[n]; !ok {
+			return fmt.Errorf("the threshold [%s] has no custom metric", n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+// FD is the system-wide open file descriptors usage
+// "1184	0	9223372036854775807" - allocated, unused and max
+type FD struct {
+	base.DefaultProbe `yaml:",inline"`
+	Used              uint64  `yaml:"used"`
+	Max               uint64  `yaml:"max"`
+	Usage             float64 `yaml:"usage"`
+
+	Threshold float64 `yaml:"threshold"`
+	metrics   *prometheus.GaugeVec
+}
+
+// Name returns the name of the metrics
+func (f *FD) Name() string {
+	return "fd"
+}
+
+// Command returns the command to get the file descriptors usage
+func (f *FD) Command() string {
+	return `cat /proc/sys/fs/file-nr;`
+}
+
+// OutputLines returns the lines of command output
+func (f *FD) OutputLines() int {
+	return 1
+}
+
+// Config returns the config of the file descriptors usage
+func (f *FD) Config(s *Server) {
+	f.SetThreshold(&s.Threshold)
+	f.CreateMetrics(s.ProbeKind, s.ProbeTag)
+}
+
+// SetThreshold set the threshold of the file descriptors usage
+func (f *FD) SetThreshold(t *Threshold) {
+	f.Threshold = t.FD
+}
+
+// Parse a string to the file descriptors usage
+func (f *FD) Parse(s []string) error {
+	if len(s) < f.OutputLines() {
+		return fmt.Errorf("invalid file descriptor output")
+	}
+	fd := strings.Fields(s[0])
+	if len(fd) < 3 {
+		return fmt.Errorf("invalid file descriptor output")
+	}
+	allocated, err1 := strconv.ParseUint(fd[0], 10, 64)
+	unused, err2 := strconv.ParseUint(fd[1], 10, 64)
+	max, err3 := strconv.ParseUint(fd[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || max == 0 || unused > allocated {
+		return fmt.Errorf("invalid file descriptor output")
+	}
+	f.Used = allocated - unused
+	f.Max = max
+	f.Usage = float64(f.Used) * 100 / float64(f.Max)
+	return nil
+}
+
+// UsageInfo returns the usage info of the file descriptors
+func (f *FD) UsageInfo() string {
+	return fmt.Sprintf("FD: %d/%d", f.Used, f.Max)
+}
+
+// CheckThreshold check the file descriptors usage
+func (f *FD) CheckThreshold() (bool, string) {
+	if f.Threshold > 0 && f.Threshold <= f.Usage/100 {
+		return false, fmt.Sprintf("File descriptor threshold alert! - %d/%d", f.Used, f.Max)
+	}
+	return true, ""
+}
+
+// CreateMetrics create the file descriptors metrics
+func (f *FD) CreateMetrics(subsystem, name string) {
+	namespace := global.GetEaseProbe().Name
+	f.metrics = metric.NewGauge(namespace, subsystem, name, "fd",
+		"Open File Descriptors", []string{"host", "state"}, f.Labels)
+}
+
+// ExportMetrics export the file descriptors metrics
+func (f *FD) ExportMetrics(name string) {
+	f.metrics.With(metric.AddConstLabels(prometheus.Labels{
+		"host":  name,
+		"state": "used",
+	}, f.Labels)).Set(float64(f.Used))
+
+	f.metrics.With(metric.AddConstLabels(prometheus.Labels{
+		"host":  name,
+		"state": "max",
+	}, f.Labels)).Set(float64(f.Max))
+
+	f.metrics.With(metric.AddConstLabels(prometheus.Labels{
+		"host":  name,
+		"state": "usage",
+	}, f.Labels)).Set(f.Usage)
+}
@@ -19,6 +19,7 @@
 package host
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -31,8 +32,11 @@ import (
 // Server is the server of a host probe
 type Server struct {
 	ssh.Server `yaml:",inline"`
-	Threshold  Threshold `yaml:"threshold,omitempty" json:"threshold,omitempty" jsonschema:"title=Threshold,description=the threshold of the probe for cpu/memory/disk"`
-	Disks      []string  `yaml:"disks,omitempty" json:"disks,omitempty" jsonschema:"title=Disks,description=the disks to be monitored,example=[\"/\", \"/data\"]"`
+	Threshold  Threshold         `yaml:"threshold,omitempty" json:"threshold,omitempty" jsonschema:"title=Threshold,description=the threshold of the probe for cpu/memory/disk"`
+	Disks      []string          `yaml:"disks,omitempty" json:"disks,omitempty" jsonschema:"title=Disks,description=the disks to be monitored,example=[\"/\", \"/data\"]"`
+	Interfaces []string          `yaml:"interfaces,omitempty" json:"interfaces,omitempty" jsonschema:"title=Network Interfaces,description=the network interfaces to be monitored (default: all except the loopback)"`
+	Collectors []string          `yaml:"collectors,omitempty" json:"collectors,omitempty" jsonschema:"title=Optional Collectors,description=the optional collectors to be enabled,enum=inode,enum=fd,enum=net"`
+	Metrics    map[string]string `yaml:"metrics,omitempty" json:"metrics,omitempty" jsonschema:"title=Custom Metrics,description=the commands of the custom metrics which output a number by the metric name"`
 
 	outputLines int        `yaml:"-" json:"-"`
 	hostMetrics []IMetrics `yaml:"-" json:"-"`
@@ -48,6 +52,16 @@ type Host struct {
 // BastionMap is a map of bastion
 var BastionMap ssh.BastionMapType
 
+// optionalCollectors are the collectors which are disabled by default,
+// a collector is enabled by the `collectors` or by setting any of its thresholds.
+var optionalCollectors = map[string]func(s *Server) bool{
+	"inode": func(s *Server) bool { return s.Threshold.Inode > 0 },
+	"fd":    func(s *Server) bool { return s.Threshold.FD > 0 },
+	"net": func(s *Server) bool {
+		return len(s.Interfaces) > 0 || s.Threshold.NetRx > 0 || s.Threshold.NetTx > 0 || s.Threshold.NetErrors > 0
+	},
+}
+
 // Config is the host probe configuration
 func (s *Server) Config(gConf global.ProbeSettings) error {
 	kind := "host"
@@ -58,8 +72,21 @@ func (s *Server) Config(gConf global.ProbeSettings) error {
 	s.ProbeTag = tag
 	s.ProbeName = name
 
-	// put all of the metrics into the IMetrics slice
-	s.hostMetrics = s.info.IMetrics()
+	for _, c := range s.Collectors {
+		if _, ok := optionalCollectors[c]; !ok {
+			err := fmt.Errorf("unknown collector [%s]", c)
+			log.Errorf("[%s / %s] %v", s.ProbeKind, s.ProbeName, err)
+			return err
+		}
+	}
+
+	// put all of the enabled metrics into the IMetrics slice
+	s.hostMetrics = []IMetrics{}
+	for _, m := range s.info.IMetrics() {
+		if s.enabled(m.Name()) {
+			s.hostMetrics = append(s.hostMetrics, m)
+		}
+	}
 
 	// Combine the commands and Config the metrics
 	s.outputLines = 0
@@ -73,18 +100,52 @@ func (s *Server) Config(gConf global.ProbeSettings) error {
 	log.Debugf("[%s / %s]\n%s", s.ProbeKind, s.ProbeName, s.Command)
 
 	endpoint := s.Threshold.String()
-	err := s.Configure(gConf, kind, tag, name, endpoint, &BastionMap, s.DoProbe)
+	var err error
+	if s.IsLocal() {
+		// the local host is monitored without SSH
+		err = s.DefaultProbe.Config(gConf, kind, tag, name, endpoint, s.DoProbe)
+	} else {
+		err = s.Configure(gConf, kind, tag, name, endpoint, &BastionMap, s.DoProbe)
+	}
+	if err == nil {
+		if err = checkCustomMetrics(s.Metrics, &s.Threshold); err != nil {
+			log.Errorf("[%s / %s] %v", s.ProbeKind, s.ProbeName, err)
+		}
+	}
 	log.Debugf("[%s / %s] configuration: %+v", s.ProbeKind, s.ProbeName, *s)
 	return err
 }
 
+// enabled returns true if the metric is not an optional collector, or the optional collector is enabled
+func (s *Server) enabled(name string) bool {
+	configured, ok := optionalCollectors[name]
+	if !ok {
+		return true
+	}
+	for _, c := range s.Collectors {
+		if c == name {
+			return true
+		}
+	}
+	return configured(s)
+}
+
 // DoProbe return the checking result
 func (s *Server) DoProbe() (bool, string) {
 
-	output, err := s.RunSSHCmd()
+	var output string
+	var err error
+	if s.IsLocal() {
+		output, err = s.RunLocal()
+	} else {
+		output, err = s.RunSSHCmd()
+	}
 
 	if err != nil {
 		log.Errorf("[%s / %s] %v", s.ProbeKind, s.ProbeName, err)
+		if errors.Is(err, ssh.ErrHostKeyMismatch) {
+			return false, "Error: Host Key Mismatch! " + err.Error()
+		}
 		return false, err.Error() + " - " + output
 	}
 
@@ -103,15 +164,13 @@ func (s *Server) DoProbe() (bool, string) {
 
 // Usage return all of the resources usage
 func (s *Server) Usage(info Info) string {
-	usage := " ( "
-	for i := 0; i < len(s.hostMetrics)-1; i++ {
-		u := s.hostMetrics[i].UsageInfo()
-		if u != "" {
-			usage += u + " - "
+	usage := []string{}
+	for _, m := range s.hostMetrics {
+		if u := m.UsageInfo(); u != "" {
+			usage = append(usage, u)
 		}
 	}
-	usage += s.hostMetrics[len(s.hostMetrics)-1].UsageInfo() + " )"
-	return usage
+	return " ( " + strings.Join(usage, " - ") + " )"
 }
 
 // CheckThreshold check the threshold
@@ -127,6 +186,19 @@ func (s *Server) CheckThreshold(info Info) (bool, string) {
 		}
 	}
 
+	// only check the soft thresholds if the hard thresholds are fine
+	if status {
+		for _, metric := range s.hostMetrics {
+			d, ok := metric.(Degrader)
+			if !ok {
+				continue
+			}
+			if degraded, m := d.CheckDegraded(); degraded {
+				s.Degrade(m)
+			}
+		}
+	}
+
 	if message == "" {
 		message = "Fine!"
 	}
@@ -137,12 +209,12 @@ func (s *Server) CheckThreshold(info Info) (bool, string) {
 // ParseHostInfo parse the host info
 func (s *Server) ParseHostInfo(str string) (Info, error) {
 	line := strings.Split(str, "\n")
-	if len(line) < s.outputLines {
-		return s.info, fmt.Errorf("invalid output lines")
-	}
 
 	idx := 0
 	for _, m := range s.hostMetrics {
+		if idx+m.OutputLines() > len(line) {
+			return s.info, fmt.Errorf("invalid output lines")
+		}
 		strs := []string{}
 		for i := 0; i < m.OutputLines(); i++ {
 			strs = append(strs, line[idx])
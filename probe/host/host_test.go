@@ -18,16 +18,26 @@
 package host
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/megaease/easeprobe/global"
 	"github.com/megaease/easeprobe/monkey"
+	"github.com/megaease/easeprobe/probe"
 	"github.com/megaease/easeprobe/probe/base"
 	"github.com/megaease/easeprobe/probe/ssh"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func newHost(t *testing.T) *Host {
@@ -55,6 +65,13 @@ Ubuntu
 0.00 0.03 0.10
 `
 
+// the output of the optional collectors: inode, fd and net
+var collectorsInfo = `1000 6000000 1% /
+20 80 25% /data
+1184	0	100000
+eth0 1000 0 2000 0 lo 10 0 10 0 
+`
+
 func TestHostInfo(t *testing.T) {
 	host := newHost(t)
 	host.Servers[0].Config(global.ProbeSettings{})
@@ -96,7 +113,7 @@ func TestHost(t *testing.T) {
 		assert.Equal(t, "server", server.ProbeTag)
 	}
 
-	localHostInfo := hostInfo
+	localHostInfo := hostInfo + collectorsInfo
 	var s *ssh.Server
 	monkey.PatchInstanceMethod(reflect.TypeOf(s), "RunSSHCmd", func(_ *ssh.Server) (string, error) {
 		return localHostInfo, nil
@@ -108,6 +125,14 @@ func TestHost(t *testing.T) {
 	assert.True(t, status)
 	assert.Contains(t, message, "Fine")
 
+	// soft threshold
+	server.Threshold.DegradedCPU = 0.5
+	server.Config(global.ProbeSettings{})
+	result := server.Probe()
+	assert.Equal(t, probe.StatusDegraded, result.Status)
+	assert.Contains(t, result.Message, "CPU usage 73.20% >= 50.00%")
+	server.Threshold.DegradedCPU = 0
+
 	server.Threshold.CPU = 0.5
 	server.Config(global.ProbeSettings{})
 	status, message = server.DoProbe()
@@ -239,7 +264,7 @@ func TestLoad(t *testing.T) {
 	assert.Equal(t, 0.2, server.Threshold.Load["m5"])
 	assert.Equal(t, 0.3, server.Threshold.Load["m15"])
 
-	localHostInfo := hostInfo
+	localHostInfo := hostInfo + collectorsInfo
 	var s *ssh.Server
 	monkey.PatchInstanceMethod(reflect.TypeOf(s), "RunSSHCmd", func(_ *ssh.Server) (string, error) {
 		return localHostInfo, nil
@@ -289,3 +314,266 @@ func TestBadParse(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid load average output")
 }
+
+func TestCustomMetrics(t *testing.T) {
+	host := newHost(t)
+	server := &host.Servers[0]
+	server.Password = "password"
+	server.Metrics = map[string]string{
+		"numprocs": "ps axu | wc -l",
+		"conns":    "ss -tan | wc -l",
+	}
+	server.Threshold.Custom = map[string]float64{"numprocs": 400}
+	assert.Nil(t, server.Config(global.ProbeSettings{}))
+	assert.Contains(t, server.Command, `if out="$(ss -tan | wc -l)"; then echo "$out" | tail -n 1; else echo "exit status $?"; fi;`+"\n"+
+		`if out="$(ps axu | wc -l)"; then echo "$out" | tail -n 1; else echo "exit status $?"; fi;`)
+	assert.Contains(t, server.Result().Endpoint, "numprocs: 400")
+
+	localHostInfo := hostInfo + "128\n350\n"
+	var s *ssh.Server
+	monkey.PatchInstanceMethod(reflect.TypeOf(s), "RunSSHCmd", func(_ *ssh.Server) (string, error) {
+		return localHostInfo, nil
+	})
+
+	status, message := server.DoProbe()
+	assert.True(t, status)
+	assert.Contains(t, message, "conns: 128 - numprocs: 350 )")
+	assert.Equal(t, 350.0, server.info.Custom.Metrics["numprocs"])
+
+	localHostInfo = hostInfo + "128\n401\n"
+	status, message = server.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, message, "numprocs threshold alert! - 401 > 400")
+
+	localHostInfo = hostInfo + "128\nerror\n"
+	status, message = server.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, message, `invalid custom metric [numprocs] output: "error"`)
+
+	// the remote and the local host have the same output of the commands
+	server.Metrics = map[string]string{"conns": "echo 1; echo 42", "numprocs": "echo 1; exit 3"}
+	server.Threshold.Custom = nil
+	assert.Nil(t, server.Config(global.ProbeSettings{}))
+	remote, err := exec.Command("sh", "-c", server.info.Custom.Command()).Output()
+	assert.Nil(t, err)
+	assert.Equal(t, "42\nexit status 3\n", string(remote))
+	local, err := server.info.Custom.CollectLocal(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"42", "exit status 3"}, local)
+
+	// the failed command fails the probe with the metric name
+	localHostInfo = hostInfo + string(remote)
+	status, message = server.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, message, "custom metric [numprocs] command failed: exit status 3")
+
+	monkey.UnpatchAll()
+
+	// the thresholds of the custom metrics in yaml
+	th := Threshold{}
+	assert.Nil(t, yaml.Unmarshal([]byte("cpu: 0.5\nnumprocs: 400\n"), &th))
+	assert.Equal(t, 0.5, th.CPU)
+	assert.Equal(t, map[string]float64{"numprocs": 400}, th.Custom)
+
+	// bad configuration
+	server.Metrics = map[string]string{"num-procs": "ps axu | wc -l"}
+	server.Threshold.Custom = nil
+	assert.NotNil(t, server.Config(global.ProbeSettings{}))
+	server.Metrics = map[string]string{"cpu": "ps axu | wc -l"}
+	assert.NotNil(t, server.Config(global.ProbeSettings{}))
+	server.Metrics = map[string]string{"numprocs": " "}
+	assert.NotNil(t, server.Config(global.ProbeSettings{}))
+	server.Metrics = map[string]string{"numprocs": "ps axu | wc -l"}
+	server.Threshold.Custom = map[string]float64{"procs": 400}
+	assert.NotNil(t, server.Config(global.ProbeSettings{}))
+}
+
+func TestLocalHost(t *testing.T) {
+	host := newHost(t)
+	server := &host.Servers[0]
+	server.Host = LocalHost
+	server.Disks = []string{"/"}
+	server.Metrics = map[string]string{"numprocs": "echo 1; echo 42"}
+	assert.Nil(t, server.Config(global.ProbeSettings{}))
+	assert.True(t, server.IsLocal())
+
+	// the real local host
+	output, err := server.RunLocal()
+	assert.Nil(t, err)
+	info, err := server.ParseHostInfo(output)
+	assert.Nil(t, err)
+	assert.Greater(t, info.Basic.Core, int64(0))
+	assert.Greater(t, info.Memory.Total, 0)
+	assert.Equal(t, "/", info.Disks.Usage[0].Tag)
+	assert.Equal(t, 42.0, info.Custom.Metrics["numprocs"])
+
+	// the fake proc files
+	dir := t.TempDir()
+	procPath, osReleasePath = dir, filepath.Join(dir, "os-release")
+	defer func() { procPath, osReleasePath = "/proc", "/etc/os-release" }()
+	write := func(name, content string) {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("os-release", "PRETTY_NAME=\"Ubuntu 22.04\"\nNAME=\"Ubuntu\"\n")
+	write("cpuinfo", "processor\t: 0\nmodel name\t: x\nprocessor\t: 1\n")
+	write("stat", "cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 1 2 3 4\n")
+	write("meminfo", "MemTotal:       16000000 kB\nMemFree:         1000000 kB\nMemAvailable:    4000000 kB\n")
+	write("loadavg", "0.50 0.40 0.30 1/100 12345\n")
+	write("sys/fs/file-nr", "1184\t0\t100000\n")
+	write("net/dev", `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs colls carrier compressed
+    lo:    1000      10    0    0    0     0          0         0     1000      10    0    0    0     0       0          0
+  eth0: 2000000    2000    1    0    0     0          0         0  3000000    3000    2    0    0     0       0          0
+`)
+
+	server.info.CPU.lastStat, server.info.CPU.lastUsage = nil, ""
+	output, err = server.RunLocal()
+	assert.Nil(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, "Ubuntu", lines[1])
+	assert.Equal(t, "2", lines[2])
+	assert.Equal(t, "10.0 us, 10.0 sy, 0.0 ni, 70.0 id, 10.0 wa, 0.0 hi, 0.0 si, 0.0 st", lines[3])
+	assert.Equal(t, "11718 15625 75.00", lines[4])
+	assert.Equal(t, "2", lines[6])
+	assert.Equal(t, "0.50 0.40 0.30", lines[7])
+	assert.Equal(t, "42", lines[8])
+
+	// the optional collectors
+	server.Collectors = []string{"inode", "fd", "net"}
+	assert.Nil(t, server.Config(global.ProbeSettings{}))
+	output, err = server.RunLocal()
+	assert.Nil(t, err)
+	lines = strings.Split(output, "\n")
+	assert.Equal(t, "/", strings.Fields(lines[8])[3])
+	assert.Equal(t, "1184\t0\t100000", lines[9])
+	assert.Equal(t, "lo 1000 0 1000 0 eth0 2000000 1 3000000 2", lines[10])
+	assert.Equal(t, "42", lines[11])
+	server.Collectors = nil
+	assert.Nil(t, server.Config(global.ProbeSettings{}))
+
+	// the cpu usage is computed from the last collection
+	write("stat", "cpu  200 0 100 800 100 0 0 0 0 0\n")
+	output, err = server.RunLocal()
+	assert.Nil(t, err)
+	assert.Equal(t, "50.0 us, 0.0 sy, 0.0 ni, 50.0 id, 0.0 wa, 0.0 hi, 0.0 si, 0.0 st", strings.Split(output, "\n")[3])
+
+	status, message := server.DoProbe()
+	assert.True(t, status)
+	assert.Contains(t, message, "CPU: 50.00%")
+
+	server.Threshold.Mem = 0.5
+	server.Config(global.ProbeSettings{})
+	status, message = server.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, message, "Memory threshold alert!")
+
+	os.Remove(filepath.Join(dir, "meminfo"))
+	_, err = server.RunLocal()
+	assert.NotNil(t, err)
+
+	server.Disks = []string{"/not/exist/path"}
+	server.Config(global.ProbeSettings{})
+	_, err = server.RunLocal()
+	assert.NotNil(t, err)
+}
+
+func TestNetInodeFD(t *testing.T) {
+	host := newHost(t)
+	server := &host.Servers[0]
+	server.Password = "password"
+
+	// the optional collectors are disabled by default, and the endpoint is not changed
+	assert.Nil(t, server.Config(global.ProbeSettings{}))
+	for _, m := range server.hostMetrics {
+		assert.NotContains(t, []string{"inode", "fd", "net"}, m.Name())
+	}
+	assert.Equal(t, 0.0, server.Threshold.Inode)
+	assert.Equal(t, 0.0, server.Threshold.FD)
+	assert.Equal(t, "CPU: 0.80, Mem: 0.80, Disk: 0.95, Load: 0.80/0.80/0.80", server.Threshold.String())
+
+	// setting a threshold enables its collector
+	server.Threshold.FD = 0.9
+	assert.Nil(t, server.Config(global.ProbeSettings{}))
+	assert.Equal(t, "fd", server.hostMetrics[len(server.hostMetrics)-2].Name())
+	assert.Equal(t, "CPU: 0.80, Mem: 0.80, Disk: 0.95, Load: 0.80/0.80/0.80, FD: 0.9", server.Threshold.String())
+	server.Threshold.FD = 0
+
+	server.Collectors = []string{"disk"}
+	assert.NotNil(t, server.Config(global.ProbeSettings{}))
+	server.Collectors = []string{"inode", "fd", "net"}
+	assert.Nil(t, server.Config(global.ProbeSettings{}))
+
+	info, err := server.ParseHostInfo(hostInfo + collectorsInfo)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(info.Inodes.Usage))
+	assert.Equal(t, 1000, info.Inodes.Usage[0].Used)
+	assert.Equal(t, 6000000, info.Inodes.Usage[0].Total)
+	assert.Equal(t, "/data", info.Inodes.Usage[1].Tag)
+	assert.Equal(t, uint64(1184), info.FD.Used)
+	assert.Equal(t, uint64(100000), info.FD.Max)
+	// the loopback is ignored, and the first round has no rate
+	assert.Equal(t, 1, len(info.Net.Stats))
+	assert.Equal(t, "eth0", info.Net.Stats[0].Interface)
+	assert.Equal(t, 0.0, info.Net.Stats[0].RxRate)
+
+	localHostInfo := hostInfo + collectorsInfo
+	var s *ssh.Server
+	monkey.PatchInstanceMethod(reflect.TypeOf(s), "RunSSHCmd", func(_ *ssh.Server) (string, error) {
+		return localHostInfo, nil
+	})
+	defer monkey.UnpatchAll()
+
+	status, message := server.DoProbe()
+	assert.True(t, status)
+	assert.Contains(t, message, "Inode: `/` 1.00%, `/data` 25.00% - FD: 1184/100000 - Net: `eth0` rx 0.00MB/s tx 0.00MB/s")
+
+	// the network rate is computed from the last round
+	server.Threshold.NetRx = 1
+	server.Threshold.NetErrors = 5
+	server.Config(global.ProbeSettings{})
+	server.DoProbe()
+	server.info.Net.lastTime = server.info.Net.lastTime.Add(-10 * time.Second)
+	localHostInfo = hostInfo + strings.Replace(collectorsInfo, "eth0 1000 0 2000 0", fmt.Sprintf("eth0 %d 3 %d 3", 1000+20*mb, 2000+10*mb), 1)
+	status, message = server.DoProbe()
+	assert.False(t, status)
+	assert.InDelta(t, 2*mb, server.info.Net.Stats[0].RxRate, 0.1*mb)
+	assert.InDelta(t, 1*mb, server.info.Net.Stats[0].TxRate, 0.1*mb)
+	assert.Equal(t, uint64(6), server.info.Net.Stats[0].Errors)
+	assert.Contains(t, message, "Network threshold alert! - [`eth0` rx 2.00MB/s, `eth0` 6 errors]")
+
+	// the series of the disappeared interface is deleted
+	labels := prometheus.Labels{"host": server.Name(), "interface": "eth0", "state": "rx_rate"}
+	assert.InDelta(t, 2*mb, testutil.ToFloat64(server.info.Net.metrics.With(labels)), 0.1*mb)
+	localHostInfo = hostInfo + strings.Replace(collectorsInfo, "eth0 1000 0 2000 0", "eth1 1000 0 2000 0", 1)
+	status, _ = server.DoProbe()
+	assert.True(t, status)
+	assert.Equal(t, 3, testutil.CollectAndCount(server.info.Net.metrics))
+	assert.Equal(t, "eth1", server.info.Net.Stats[0].Interface)
+	localHostInfo = hostInfo + collectorsInfo
+
+	// the configured interface is not found
+	server.Interfaces = []string{"eth1"}
+	server.Config(global.ProbeSettings{})
+	status, message = server.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, message, "interface [eth1] is not found")
+	server.Interfaces = nil
+
+	server.Threshold.Inode = 0.2
+	server.Threshold.FD = 0.01
+	server.Config(global.ProbeSettings{})
+	status, message = server.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, message, "Inode threshold alert! - [/data]")
+	assert.Contains(t, message, "File descriptor threshold alert! - 1184/100000")
+
+	// bad outputs
+	info = Info{}
+	info.Inodes.Mount = []string{"/"}
+	assert.Contains(t, info.Inodes.Parse([]string{"1000 6000000"}).Error(), "invalid inode output")
+	assert.Contains(t, info.FD.Parse([]string{"1184 0"}).Error(), "invalid file descriptor output")
+	assert.Contains(t, info.FD.Parse([]string{"1184 0 0"}).Error(), "invalid file descriptor output")
+	assert.Contains(t, info.Net.Parse([]string{"eth0 1 2 3"}).Error(), "invalid network output")
+	assert.Contains(t, info.Net.Parse([]string{"eth0 1 2 3 x"}).Error(), "invalid network output")
+}
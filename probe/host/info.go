@@ -22,10 +22,14 @@ import "reflect"
 // Info is the host probe information
 type Info struct {
 	Basic  `yaml:",inline"`
-	CPU    CPU   `yaml:"cpu"`
-	Memory Mem   `yaml:"memory"`
-	Disks  Disks `yaml:"disks"`
-	Load   Load  `yaml:"load"`
+	CPU    CPU    `yaml:"cpu"`
+	Memory Mem    `yaml:"memory"`
+	Disks  Disks  `yaml:"disks"`
+	Load   Load   `yaml:"load"`
+	Inodes Inodes `yaml:"inodes"`
+	FD     FD     `yaml:"fd"`
+	Net    Net    `yaml:"net"`
+	Custom Custom `yaml:"custom"`
 }
 
 // IMetrics  put all of the Info member into IMetrics slices
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+// Inodes is the inode usage of the disks
+type Inodes struct {
+	base.DefaultProbe `yaml:",inline"`
+	Mount             []string        `yaml:"mount"`
+	Usage             []ResourceUsage `yaml:"usage"`
+
+	Threshold float64 `yaml:"threshold"`
+	metrics   *prometheus.GaugeVec
+}
+
+// Name returns the name of the metrics
+func (i *Inodes) Name() string {
+	return "inode"
+}
+
+// Command returns the command to get the inode usage
+func (i *Inodes) Command() string {
+	return `df -iP ` + strings.Join(i.Mount, " ") + ` 2>/dev/null | awk '(NR>1){printf "%d %d %s %s\n", $3,$2,$5,$6}'`
+}
+
+// OutputLines returns the lines of command output
+func (i *Inodes) OutputLines() int {
+	return len(i.Mount)
+}
+
+// Config returns the config of the inode usage, the disks are the same as the disk usage
+func (i *Inodes) Config(s *Server) {
+	if len(s.Disks) == 0 {
+		s.Disks = []string{"/"}
+	}
+	i.Mount = []string{}
+	i.Mount = append(i.Mount, s.Disks...)
+	i.Usage = make([]ResourceUsage, len(i.Mount))
+	i.SetThreshold(&s.Threshold)
+	i.CreateMetrics(s.ProbeKind, s.ProbeTag)
+}
+
+// SetThreshold set the threshold of the inode usage
+func (i *Inodes) SetThreshold(t *Threshold) {
+	i.Threshold = t.Inode
+}
+
+// Parse a string to the inode usage
+func (i *Inodes) Parse(s []string) error {
+	if len(s) != i.OutputLines() {
+		return fmt.Errorf("invalid inode output")
+	}
+
+	for idx := 0; idx < len(s); idx++ {
+		inode := strings.Split(strings.TrimSpace(s[idx]), " ")
+		if len(inode) < 4 {
+			return fmt.Errorf("invalid inode output")
+		}
+		// some filesystems have no inode limit, the usage is "-"
+		i.Usage[idx] = ResourceUsage{
+			Used:  int(strInt(inode[0])),
+			Total: int(strInt(inode[1])),
+			Usage: strFloat(strings.TrimSuffix(inode[2], "%")),
+			Tag:   inode[3],
+		}
+	}
+	return nil
+}
+
+// UsageInfo returns the usage info of the inodes
+func (i *Inodes) UsageInfo() string {
+	usage := []string{}
+	for _, inode := range i.Usage {
+		usage = append(usage, fmt.Sprintf("`%s` %.2f%%", inode.Tag, inode.Usage))
+	}
+	return "Inode: " + strings.Join(usage, ", ")
+}
+
+// CheckThreshold check the inode usage
+func (i *Inodes) CheckThreshold() (bool, string) {
+	disks := []string{}
+	for _, inode := range i.Usage {
+		if i.Threshold > 0 && i.Threshold <= inode.Usage/100 {
+			disks = append(disks, inode.Tag)
+		}
+	}
+	if len(disks) > 0 {
+		return false, fmt.Sprintf("Inode threshold alert! - [%s]", strings.Join(disks, ", "))
+	}
+	return true, ""
+}
+
+// CreateMetrics create the inode metrics
+func (i *Inodes) CreateMetrics(subsystem, name string) {
+	namespace := global.GetEaseProbe().Name
+	i.metrics = metric.NewGauge(namespace, subsystem, name, "inode",
+		"Inode Usage", []string{"host", "disk", "state"}, i.Labels)
+}
+
+// ExportMetrics export the inode metrics
+func (i *Inodes) ExportMetrics(name string) {
+	for _, inode := range i.Usage {
+		i.metrics.With(metric.AddConstLabels(prometheus.Labels{
+			"host":  name,
+			"disk":  inode.Tag,
+			"state": "used",
+		}, i.Labels)).Set(float64(inode.Used))
+
+		i.metrics.With(metric.AddConstLabels(prometheus.Labels{
+			"host":  name,
+			"disk":  inode.Tag,
+			"state": "total",
+		}, i.Labels)).Set(float64(inode.Total))
+
+		i.metrics.With(metric.AddConstLabels(prometheus.Labels{
+			"host":  name,
+			"disk":  inode.Tag,
+			"state": "usage",
+		}, i.Labels)).Set(inode.Usage)
+	}
+}
@@ -0,0 +1,292 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/megaease/easeprobe/probe/ssh"
+)
+
+// LocalHost is the host name for monitoring the machine EaseProbe runs on, no SSH is needed.
+const LocalHost = ssh.LocalHost
+
+// the paths of the local system information, they could be changed for testing
+var (
+	procPath      = "/proc"
+	osReleasePath = "/etc/os-release"
+)
+
+// LocalCollector is the optional interface of the metrics which could be collected from the local host.
+// It returns the same output lines as the command does, so the output is parsed in the same way.
+type LocalCollector interface {
+	CollectLocal(ctx context.Context) ([]string, error)
+}
+
+// RunLocal collects the metrics from the local host without SSH
+func (s *Server) RunLocal() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout())
+	defer cancel()
+
+	lines := []string{}
+	for _, m := range s.hostMetrics {
+		c, ok := m.(LocalCollector)
+		if !ok {
+			return "", fmt.Errorf("the metric [%s] doesn't support the local host", m.Name())
+		}
+		l, err := c.CollectLocal(ctx)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, l...)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func readLines(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// cpuCores returns the number of the processors in /proc/cpuinfo
+func cpuCores() (string, error) {
+	lines, err := readLines(filepath.Join(procPath, "cpuinfo"))
+	if err != nil {
+		return "", err
+	}
+	cores := 0
+	for _, l := range lines {
+		if strings.HasPrefix(l, "processor") {
+			cores++
+		}
+	}
+	return fmt.Sprintf("%d", cores), nil
+}
+
+// CollectLocal collects the host name, OS name and the cpu cores
+func (b *Basic) CollectLocal(ctx context.Context) ([]string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	osName := ""
+	if lines, err := readLines(osReleasePath); err == nil {
+		for _, l := range lines {
+			if strings.HasPrefix(l, "NAME=") {
+				osName = strings.Trim(strings.TrimPrefix(l, "NAME="), `"`)
+				break
+			}
+		}
+	}
+	cores, err := cpuCores()
+	if err != nil {
+		return nil, err
+	}
+	return []string{hostname, osName, cores}, nil
+}
+
+// CollectLocal collects the cpu usage from /proc/stat.
+// The usage is computed from the last collection, the first one is since the boot time as the `top -n 1` does.
+func (c *CPU) CollectLocal(ctx context.Context) ([]string, error) {
+	lines, err := readLines(filepath.Join(procPath, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) <= 0 || !strings.HasPrefix(lines[0], "cpu ") {
+		return nil, fmt.Errorf("invalid cpu output")
+	}
+	// user nice system idle iowait irq softirq steal
+	fields := strings.Fields(lines[0])[1:]
+	if len(fields) < 8 {
+		return nil, fmt.Errorf("invalid cpu output")
+	}
+	stat := make([]float64, 8)
+	for i := range stat {
+		stat[i] = strFloat(fields[i])
+	}
+
+	delta := make([]float64, 8)
+	total := 0.0
+	for i := range stat {
+		delta[i] = stat[i]
+		if len(c.lastStat) == len(stat) {
+			delta[i] -= c.lastStat[i]
+		}
+		total += delta[i]
+	}
+	// no cpu time passed since the last collection, use the last usage
+	if total <= 0 && len(c.lastUsage) > 0 {
+		return []string{c.lastUsage}, nil
+	}
+	if total <= 0 {
+		total = 1
+	}
+	pct := func(i int) float64 { return delta[i] * 100 / total }
+
+	c.lastStat = stat
+	c.lastUsage = fmt.Sprintf("%.1f us, %.1f sy, %.1f ni, %.1f id, %.1f wa, %.1f hi, %.1f si, %.1f st",
+		pct(0), pct(2), pct(1), pct(3), pct(4), pct(5), pct(6), pct(7))
+	return []string{c.lastUsage}, nil
+}
+
+// CollectLocal collects the memory usage (MB) from /proc/meminfo
+func (m *Mem) CollectLocal(ctx context.Context) ([]string, error) {
+	lines, err := readLines(filepath.Join(procPath, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	info := map[string]int64{}
+	for _, l := range lines {
+		kv := strings.SplitN(l, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		info[kv[0]] = strInt(strings.TrimSuffix(strings.TrimSpace(kv[1]), " kB"))
+	}
+
+	total := info["MemTotal"]
+	if total <= 0 {
+		return nil, fmt.Errorf("invalid memory output")
+	}
+	available, ok := info["MemAvailable"]
+	if !ok {
+		available = info["MemFree"] + info["Buffers"] + info["Cached"]
+	}
+	used := total - available
+	return []string{fmt.Sprintf("%d %d %.2f", used/1024, total/1024, float64(used)*100/float64(total))}, nil
+}
+
+// CollectLocal collects the disk usage (GB) by statfs
+func (d *Disks) CollectLocal(ctx context.Context) ([]string, error) {
+	const gb = 1024 * 1024 * 1024
+	lines := []string{}
+	for _, mount := range d.Mount {
+		used, avail, total, err := diskUsage(mount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid disk output: %v", err)
+		}
+		// the same as `df`, the usage is rounded up
+		usage := 0
+		if used+avail > 0 {
+			usage = int((used*100 + used + avail - 1) / (used + avail))
+		}
+		lines = append(lines, fmt.Sprintf("%d %d %d%% %s", used/gb, total/gb, usage, mount))
+	}
+	return lines, nil
+}
+
+// CollectLocal collects the cpu cores and the load average from /proc/loadavg
+func (l *Load) CollectLocal(ctx context.Context) ([]string, error) {
+	cores, err := cpuCores()
+	if err != nil {
+		return nil, err
+	}
+	lines, err := readLines(filepath.Join(procPath, "loadavg"))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) <= 0 || len(strings.Fields(lines[0])) < 3 {
+		return nil, fmt.Errorf("invalid load average output")
+	}
+	return []string{cores, strings.Join(strings.Fields(lines[0])[:3], " ")}, nil
+}
+
+// CollectLocal collects the inode usage by statfs
+func (i *Inodes) CollectLocal(ctx context.Context) ([]string, error) {
+	lines := []string{}
+	for _, mount := range i.Mount {
+		used, total, err := inodeUsage(mount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inode output: %v", err)
+		}
+		// the filesystem has no inode limit
+		if total <= 0 {
+			lines = append(lines, fmt.Sprintf("0 0 - %s", mount))
+			continue
+		}
+		usage := (used*100 + total - 1) / total
+		lines = append(lines, fmt.Sprintf("%d %d %d%% %s", used, total, usage, mount))
+	}
+	return lines, nil
+}
+
+// CollectLocal collects the file descriptors usage from /proc/sys/fs/file-nr
+func (f *FD) CollectLocal(ctx context.Context) ([]string, error) {
+	lines, err := readLines(filepath.Join(procPath, "sys", "fs", "file-nr"))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) <= 0 {
+		return nil, fmt.Errorf("invalid file descriptor output")
+	}
+	return lines[:1], nil
+}
+
+// CollectLocal collects the network statistics from /proc/net/dev
+func (n *Net) CollectLocal(ctx context.Context) ([]string, error) {
+	lines, err := readLines(filepath.Join(procPath, "net", "dev"))
+	if err != nil {
+		return nil, err
+	}
+	stats := []string{}
+	for i := 2; i < len(lines); i++ {
+		f := strings.Fields(strings.Replace(lines[i], ":", " ", 1))
+		if len(f) < 12 {
+			return nil, fmt.Errorf("invalid network output")
+		}
+		stats = append(stats, f[0], f[1], f[3], f[9], f[11])
+	}
+	return []string{strings.Join(stats, " ")}, nil
+}
+
+// CollectLocal runs the custom metrics commands on the local host,
+// the output of the failed command is the "exit status N", the same as the remote host.
+func (c *Custom) CollectLocal(ctx context.Context) ([]string, error) {
+	lines := []string{}
+	for _, n := range c.Names {
+		output, err := exec.CommandContext(ctx, "sh", "-c", c.Commands[n]).Output()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			lines = append(lines, fmt.Sprintf("%s%d", exitStatus, exitErr.ExitCode()))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("custom metric [%s] command failed: %v", n, err)
+		}
+		out := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+		lines = append(lines, out[len(out)-1])
+	}
+	return lines, nil
+}
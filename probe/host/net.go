@@ -0,0 +1,233 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe/base"
+)
+
+const mb = 1024 * 1024
+
+// NetStat is the network statistics of an interface
+type NetStat struct {
+	Interface string  `yaml:"interface"`
+	RxBytes   uint64  `yaml:"rx_bytes"`  // the counter of the received bytes
+	TxBytes   uint64  `yaml:"tx_bytes"`  // the counter of the transmitted bytes
+	RxErrors  uint64  `yaml:"rx_errors"` // the counter of the receive errors
+	TxErrors  uint64  `yaml:"tx_errors"` // the counter of the transmit errors
+	RxRate    float64 `yaml:"rx_rate"`   // the receive throughput (bytes/s) since the last round
+	TxRate    float64 `yaml:"tx_rate"`   // the transmit throughput (bytes/s) since the last round
+	Errors    uint64  `yaml:"errors"`    // the receive and transmit errors since the last round
+}
+
+// Net is the network throughput and errors of the interfaces, computed from the deltas of /proc/net/dev
+type Net struct {
+	base.DefaultProbe `yaml:",inline"`
+	Interfaces        []string  `yaml:"interfaces"`
+	Stats             []NetStat `yaml:"stats"`
+
+	RxThreshold     float64 `yaml:"rx_threshold"`
+	TxThreshold     float64 `yaml:"tx_threshold"`
+	ErrorsThreshold float64 `yaml:"errors_threshold"`
+	metrics         *prometheus.GaugeVec
+	last            map[string]NetStat
+	lastTime        time.Time
+	exported        map[string]bool // the interfaces of the exported series
+}
+
+// Name returns the name of the metrics
+func (n *Net) Name() string {
+	return "net"
+}
+
+// Command returns the command to get the network statistics in one line
+// "interface rx_bytes rx_errors tx_bytes tx_errors ..."
+func (n *Net) Command() string {
+	return `tail -n +3 /proc/net/dev | tr ':' ' ' | awk '{printf "%s %s %s %s %s ", $1,$2,$4,$10,$12}'; echo`
+}
+
+// OutputLines returns the lines of command output
+func (n *Net) OutputLines() int {
+	return 1
+}
+
+// Config returns the config of the network statistics
+func (n *Net) Config(s *Server) {
+	n.Interfaces = s.Interfaces
+	n.last = nil
+	n.SetThreshold(&s.Threshold)
+	n.CreateMetrics(s.ProbeKind, s.ProbeTag)
+}
+
+// SetThreshold set the threshold of the network statistics
+func (n *Net) SetThreshold(t *Threshold) {
+	n.RxThreshold = t.NetRx
+	n.TxThreshold = t.NetTx
+	n.ErrorsThreshold = t.NetErrors
+}
+
+// Parse a string to the network statistics, the loopback is ignored if no interface is configured
+func (n *Net) Parse(s []string) error {
+	if len(s) < n.OutputLines() {
+		return fmt.Errorf("invalid network output")
+	}
+	fields := strings.Fields(s[0])
+	if len(fields)%5 != 0 {
+		return fmt.Errorf("invalid network output")
+	}
+
+	stats := map[string]NetStat{}
+	for i := 0; i < len(fields); i += 5 {
+		st := NetStat{Interface: fields[i]}
+		counters := []*uint64{&st.RxBytes, &st.RxErrors, &st.TxBytes, &st.TxErrors}
+		for j, c := range counters {
+			v, err := strconv.ParseUint(fields[i+j+1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid network output")
+			}
+			*c = v
+		}
+		stats[st.Interface] = st
+	}
+
+	names := n.Interfaces
+	if len(names) <= 0 {
+		names = []string{}
+		for k := range stats {
+			if k != "lo" {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(n.lastTime).Seconds()
+	n.Stats = []NetStat{}
+	for _, name := range names {
+		st, ok := stats[name]
+		if !ok {
+			return fmt.Errorf("invalid network output: interface [%s] is not found", name)
+		}
+		if last, ok := n.last[name]; ok && elapsed > 0 {
+			st.RxRate = float64(delta(st.RxBytes, last.RxBytes)) / elapsed
+			st.TxRate = float64(delta(st.TxBytes, last.TxBytes)) / elapsed
+			st.Errors = delta(st.RxErrors, last.RxErrors) + delta(st.TxErrors, last.TxErrors)
+		}
+		n.Stats = append(n.Stats, st)
+	}
+	n.last = stats
+	n.lastTime = now
+	return nil
+}
+
+// delta returns the increment of the counter, the counter could be reset
+func delta(cur, last uint64) uint64 {
+	if cur < last {
+		return 0
+	}
+	return cur - last
+}
+
+// UsageInfo returns the usage info of the network
+func (n *Net) UsageInfo() string {
+	usage := []string{}
+	for _, st := range n.Stats {
+		usage = append(usage, fmt.Sprintf("`%s` rx %.2fMB/s tx %.2fMB/s", st.Interface, st.RxRate/mb, st.TxRate/mb))
+	}
+	if len(usage) <= 0 {
+		return ""
+	}
+	return "Net: " + strings.Join(usage, ", ")
+}
+
+// CheckThreshold check the network throughput (MB/s) and the errors since the last round
+func (n *Net) CheckThreshold() (bool, string) {
+	alerts := []string{}
+	for _, st := range n.Stats {
+		if n.RxThreshold > 0 && st.RxRate/mb > n.RxThreshold {
+			alerts = append(alerts, fmt.Sprintf("`%s` rx %.2fMB/s", st.Interface, st.RxRate/mb))
+		}
+		if n.TxThreshold > 0 && st.TxRate/mb > n.TxThreshold {
+			alerts = append(alerts, fmt.Sprintf("`%s` tx %.2fMB/s", st.Interface, st.TxRate/mb))
+		}
+		if n.ErrorsThreshold > 0 && float64(st.Errors) > n.ErrorsThreshold {
+			alerts = append(alerts, fmt.Sprintf("`%s` %d errors", st.Interface, st.Errors))
+		}
+	}
+	if len(alerts) > 0 {
+		return false, fmt.Sprintf("Network threshold alert! - [%s]", strings.Join(alerts, ", "))
+	}
+	return true, ""
+}
+
+// CreateMetrics create the network metrics
+func (n *Net) CreateMetrics(subsystem, name string) {
+	namespace := global.GetEaseProbe().Name
+	n.metrics = metric.NewGauge(namespace, subsystem, name, "network",
+		"Network Throughput and Errors", []string{"host", "interface", "state"}, n.Labels)
+}
+
+// ExportMetrics export the network metrics, the series of the disappeared interfaces are deleted
+func (n *Net) ExportMetrics(name string) {
+	exported := map[string]bool{}
+	for _, st := range n.Stats {
+		exported[st.Interface] = true
+		n.metrics.With(metric.AddConstLabels(prometheus.Labels{
+			"host":      name,
+			"interface": st.Interface,
+			"state":     "rx_rate",
+		}, n.Labels)).Set(st.RxRate)
+
+		n.metrics.With(metric.AddConstLabels(prometheus.Labels{
+			"host":      name,
+			"interface": st.Interface,
+			"state":     "tx_rate",
+		}, n.Labels)).Set(st.TxRate)
+
+		n.metrics.With(metric.AddConstLabels(prometheus.Labels{
+			"host":      name,
+			"interface": st.Interface,
+			"state":     "errors",
+		}, n.Labels)).Set(float64(st.Errors))
+	}
+
+	for i := range n.exported {
+		if exported[i] {
+			continue
+		}
+		for _, state := range []string{"rx_rate", "tx_rate", "errors"} {
+			n.metrics.Delete(metric.AddConstLabels(prometheus.Labels{
+				"host":      name,
+				"interface": i,
+				"state":     state,
+			}, n.Labels))
+		}
+	}
+	n.exported = exported
+}
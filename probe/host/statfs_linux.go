@@ -0,0 +1,44 @@
+//go:build linux
+
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import "syscall"
+
+// diskUsage returns the used, available and total bytes of the filesystem
+func diskUsage(path string) (used, avail, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err = syscall.Statfs(path, &st); err != nil {
+		return 0, 0, 0, err
+	}
+	bsize := uint64(st.Bsize)
+	total = st.Blocks * bsize
+	used = (st.Blocks - st.Bfree) * bsize
+	avail = st.Bavail * bsize
+	return used, avail, total, nil
+}
+
+// inodeUsage returns the used and total inodes of the filesystem
+func inodeUsage(path string) (used, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err = syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return st.Files - st.Ffree, st.Files, nil
+}
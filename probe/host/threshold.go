@@ -19,6 +19,8 @@ package host
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -36,6 +38,19 @@ type Threshold struct {
 	Mem  float64            `yaml:"mem,omitempty" json:"mem,omitempty" jsonschema:"title=Memory threshold,description=Memory threshold (default: 0.8)"`
 	Disk float64            `yaml:"disk,omitempty" json:"disk,omitempty" jsonschema:"title=Disk threshold,description=Disk threshold (default: 0.95)"`
 	Load map[string]float64 `yaml:"load,omitempty" json:"load,omitempty" jsonschema:"title=Load average threshold,description=Load Average M1/M5/M15 threshold (default: 0.8)"`
+
+	// Thresholds of the optional collectors, no default value, setting any of them enables its collector
+	Inode     float64 `yaml:"inode,omitempty" json:"inode,omitempty" jsonschema:"title=Inode threshold,description=Inode usage threshold of the disks"`
+	FD        float64 `yaml:"fd,omitempty" json:"fd,omitempty" jsonschema:"title=File descriptor threshold,description=System-wide open file descriptor usage threshold"`
+	NetRx     float64 `yaml:"net_rx,omitempty" json:"net_rx,omitempty" jsonschema:"title=Network receive threshold,description=the receive throughput threshold (MB/s)"`
+	NetTx     float64 `yaml:"net_tx,omitempty" json:"net_tx,omitempty" jsonschema:"title=Network transmit threshold,description=the transmit throughput threshold (MB/s)"`
+	NetErrors float64 `yaml:"net_errors,omitempty" json:"net_errors,omitempty" jsonschema:"title=Network errors threshold,description=the receive and transmit errors threshold since the last probe"`
+
+	// Soft thresholds, the probe is degraded if any of them is breached
+	DegradedCPU float64 `yaml:"degraded_cpu,omitempty" json:"degraded_cpu,omitempty" jsonschema:"title=CPU degraded threshold,description=the probe is degraded if the CPU usage is greater than it"`
+
+	// Custom is the thresholds of the custom metrics, the key is the name of the custom metric
+	Custom map[string]float64 `yaml:",inline" json:"-"`
 }
 
 func (t *Threshold) String() string {
@@ -44,5 +59,28 @@ func (t *Threshold) String() string {
 		load = append(load, fmt.Sprintf("%.2f", v))
 	}
 
-	return fmt.Sprintf("CPU: %.2f, Mem: %.2f, Disk: %.2f, Load: %s", t.CPU, t.Mem, t.Disk, strings.Join(load, "/"))
+	str := fmt.Sprintf("CPU: %.2f, Mem: %.2f, Disk: %.2f, Load: %s", t.CPU, t.Mem, t.Disk, strings.Join(load, "/"))
+
+	// the optional thresholds are only shown when they are set
+	optional := []struct {
+		name  string
+		value float64
+	}{
+		{"Inode", t.Inode}, {"FD", t.FD}, {"NetRx", t.NetRx}, {"NetTx", t.NetTx}, {"NetErrors", t.NetErrors},
+	}
+	for _, o := range optional {
+		if o.value > 0 {
+			str += fmt.Sprintf(", %s: %s", o.name, strconv.FormatFloat(o.value, 'f', -1, 64))
+		}
+	}
+
+	names := []string{}
+	for k := range t.Custom {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		str += fmt.Sprintf(", %s: %s", k, strconv.FormatFloat(t.Custom[k], 'f', -1, 64))
+	}
+	return str
 }
@@ -29,6 +29,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
@@ -63,6 +64,9 @@ type HTTP struct {
 	User string `yaml:"username,omitempty" json:"username,omitempty" jsonschema:"title=HTTP Basic Auth Username,description=HTTP Basic Auth Username"`
 	Pass string `yaml:"password,omitempty" json:"password,omitempty" jsonschema:"title=HTTP Basic Auth Password,description=HTTP Basic Auth Password"`
 
+	// Option - the probe is degraded if the total duration is greater than it
+	DegradedLatency time.Duration `yaml:"degraded_latency,omitempty" json:"degraded_latency,omitempty" jsonschema:"type=string,format=duration,title=Degraded Latency,description=the probe is degraded if the total duration of the request is greater than it"`
+
 	// Option - Preferred HTTP response code ranges
 	// If not set, default is [0, 499].
 	SuccessCode [][]int `yaml:"success_code,omitempty" json:"success_code,omitempty" jsonschema:"title=HTTP Success Code Range,description=Preferred HTTP response code ranges.  If not set the default is [0\\, 499]."`
@@ -70,6 +74,9 @@ type HTTP struct {
 	// Option - TLS Config
 	global.TLS `yaml:",inline"`
 
+	// Option - the requests of the transaction which run in order
+	Steps []Step `yaml:"steps,omitempty" json:"steps,omitempty" jsonschema:"title=HTTP Transaction Steps,description=the requests of the transaction which run in order with the same cookie jar"`
+
 	client *http.Client `yaml:"-" json:"-"`
 
 	traceStats *TraceStats `yaml:"-" json:"-"`
@@ -77,6 +84,29 @@ type HTTP struct {
 	metrics *metrics `yaml:"-" json:"-"`
 }
 
+// configSuccessCode returns the valid success code ranges
+func (h *HTTP) configSuccessCode(codes [][]int) [][]int {
+	var codeRange [][]int
+	for _, r := range codes {
+		if len(r) != 2 {
+			log.Warnf("[%s/ %s] HTTP Success Code range is not valid - %v, skip", h.ProbeKind, h.ProbeName, r)
+			continue
+		}
+		codeRange = append(codeRange, []int{r[0], r[1]})
+	}
+	return codeRange
+}
+
+// checkSuccessCode returns true if the code is in one of the ranges
+func checkSuccessCode(codeRange [][]int, code int) bool {
+	for _, r := range codeRange {
+		if r[0] <= code && code <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
 func checkHTTPMethod(m string) bool {
 	methods := [...]string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS", "TRACE"}
 	for _, method := range methods {
@@ -92,11 +122,18 @@ func (h *HTTP) Config(gConf global.ProbeSettings) error {
 	kind := "http"
 	tag := ""
 	name := h.ProbeName
-	h.DefaultProbe.Config(gConf, kind, tag, name, h.URL, h.DoProbe)
+	endpoint := h.URL
+	if len(endpoint) == 0 && len(h.Steps) > 0 {
+		endpoint = h.Steps[0].URL
+	}
+	h.DefaultProbe.Config(gConf, kind, tag, name, endpoint, h.DoProbe)
 
-	if _, err := url.ParseRequestURI(h.URL); err != nil {
-		log.Errorf("[%s / %s] URL is not valid - %+v url=%+v", h.ProbeKind, h.ProbeName, err, h.URL)
-		return err
+	// the URL is optional for the transaction, every step has its own URL
+	if len(h.Steps) == 0 || len(h.URL) > 0 {
+		if _, err := url.ParseRequestURI(h.URL); err != nil {
+			log.Errorf("[%s / %s] URL is not valid - %+v url=%+v", h.ProbeKind, h.ProbeName, err, h.URL)
+			return err
+		}
 	}
 
 	tls, err := h.TLS.Config()
@@ -163,18 +200,10 @@ func (h *HTTP) Config(gConf global.ProbeSettings) error {
 		h.Method = "GET"
 	}
 
-	var codeRange [][]int
-	for _, r := range h.SuccessCode {
-		if len(r) != 2 {
-			log.Warnf("[%s/ %s] HTTP Success Code range is not valid - %v, skip", h.ProbeKind, h.ProbeName, r)
-			continue
-		}
-		codeRange = append(codeRange, []int{r[0], r[1]})
+	h.SuccessCode = h.configSuccessCode(h.SuccessCode)
+	if len(h.SuccessCode) == 0 {
+		h.SuccessCode = [][]int{{0, 499}}
 	}
-	if len(codeRange) == 0 {
-		codeRange = [][]int{{0, 499}}
-	}
-	h.SuccessCode = codeRange
 
 	if err := h.TextChecker.Config(); err != nil {
 		return err
@@ -187,26 +216,30 @@ func (h *HTTP) Config(gConf global.ProbeSettings) error {
 		}
 	}
 
+	if err := h.configSteps(); err != nil {
+		return err
+	}
+
 	h.metrics = newMetrics(kind, tag, h.Labels)
 
 	log.Debugf("[%s / %s] configuration: %+v", h.ProbeKind, h.ProbeName, *h)
 	return nil
 }
 
-// DoProbe return the checking result
-func (h *HTTP) DoProbe() (bool, string) {
-	req, err := http.NewRequest(h.Method, h.URL, bytes.NewBuffer([]byte(h.Body)))
+// newRequest creates the HTTP request with the basic auth, the content type and the headers
+func (h *HTTP) newRequest(method, url, body, contentEncoding string, headers map[string]string) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, bytes.NewBuffer([]byte(body)))
 	if err != nil {
-		return false, fmt.Sprintf("HTTP request error - %v", err)
+		return nil, err
 	}
 	if len(h.User) > 0 && len(h.Pass) > 0 {
 		req.SetBasicAuth(h.User, h.Pass)
 	}
-	if len(h.ContentEncoding) > 0 {
-		req.Header.Set("Content-Type", h.ContentEncoding)
+	if len(contentEncoding) > 0 {
+		req.Header.Set("Content-Type", contentEncoding)
 	}
 	req.Header.Set("User-Agent", global.OrgProgVer)
-	for k, v := range h.Headers {
+	for k, v := range headers {
 		if strings.EqualFold(k, "host") {
 			req.Host = v
 		} else {
@@ -216,6 +249,19 @@ func (h *HTTP) DoProbe() (bool, string) {
 
 	// client close the connection
 	req.Close = true
+	return req, nil
+}
+
+// DoProbe return the checking result
+func (h *HTTP) DoProbe() (bool, string) {
+	if len(h.Steps) > 0 {
+		return h.DoTransaction()
+	}
+
+	req, err := h.newRequest(h.Method, h.URL, h.Body, h.ContentEncoding, h.Headers)
+	if err != nil {
+		return false, fmt.Sprintf("HTTP request error - %v", err)
+	}
 
 	// Tracing HTTP request
 	// set the http client trace
@@ -240,14 +286,7 @@ func (h *HTTP) DoProbe() (bool, string) {
 		return false, fmt.Sprintf("Error: %v", err)
 	}
 
-	var valid bool
-	for _, r := range h.SuccessCode {
-		if r[0] <= resp.StatusCode && resp.StatusCode <= r[1] {
-			valid = true
-			break
-		}
-	}
-	if !valid {
+	if !checkSuccessCode(h.SuccessCode, resp.StatusCode) {
 		if h.WithOutput {
 			return false, fmt.Sprintf("HTTP Status Code is %d. It missed in %v. Response:\n[%s]", resp.StatusCode, h.SuccessCode, string(response))
 		}
@@ -287,9 +326,22 @@ func (h *HTTP) DoProbe() (bool, string) {
 		log.Debugf("[%s / %s] - expression is evaluated to true!", h.ProbeKind, h.ProbeName)
 	}
 
+	if result {
+		h.checkLatency(h.traceStats.totalTook)
+	}
+
 	return result, message
 }
 
+// checkLatency marks the probe as degraded if the latency is greater than the degraded latency
+func (h *HTTP) checkLatency(latency time.Duration) {
+	if h.DegradedLatency > 0 && latency > h.DegradedLatency {
+		log.Warnf("[%s / %s] - latency %s is greater than %s", h.ProbeKind, h.ProbeName,
+			latency.Round(time.Millisecond), h.DegradedLatency)
+		h.Degrade(fmt.Sprintf("latency %s > %s", latency.Round(time.Millisecond), h.DegradedLatency))
+	}
+}
+
 // ExportMetrics export HTTP metrics
 func (h *HTTP) ExportMetrics(resp *http.Response) {
 	code := 0 // no response
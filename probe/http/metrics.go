@@ -34,6 +34,15 @@ type metrics struct {
 	WaitDuration     *prometheus.GaugeVec
 	TransferDuration *prometheus.GaugeVec
 	TotalDuration    *prometheus.GaugeVec
+	StepStatusCode   *prometheus.CounterVec
+	StepDuration     *prometheus.GaugeVec
+
+	StepDNSDuration      *prometheus.GaugeVec
+	StepConnectDuration  *prometheus.GaugeVec
+	StepTLSDuration      *prometheus.GaugeVec
+	StepSendDuration     *prometheus.GaugeVec
+	StepWaitDuration     *prometheus.GaugeVec
+	StepTransferDuration *prometheus.GaugeVec
 }
 
 // newMetrics create the HTTP metrics
@@ -58,5 +67,21 @@ func newMetrics(subsystem, name string, constLabels prometheus.Labels) *metrics
 			"Transfer Duration", []string{"name", "status", "endpoint"}, constLabels),
 		TotalDuration: metric.NewGauge(namespace, subsystem, name, "total_duration",
 			"Total Duration", []string{"name", "status", "endpoint"}, constLabels),
+		StepStatusCode: metric.NewCounter(namespace, subsystem, name, "step_status_code",
+			"HTTP Status Code of Transaction Step", []string{"name", "step", "status", "endpoint"}, constLabels),
+		StepDuration: metric.NewGauge(namespace, subsystem, name, "step_duration",
+			"Total Duration of Transaction Step", []string{"name", "step", "status", "endpoint"}, constLabels),
+		StepDNSDuration: metric.NewGauge(namespace, subsystem, name, "step_dns_duration",
+			"DNS Duration of Transaction Step", []string{"name", "step", "status", "endpoint"}, constLabels),
+		StepConnectDuration: metric.NewGauge(namespace, subsystem, name, "step_connect_duration",
+			"TCP Connection Duration of Transaction Step", []string{"name", "step", "status", "endpoint"}, constLabels),
+		StepTLSDuration: metric.NewGauge(namespace, subsystem, name, "step_tls_duration",
+			"TLS Duration of Transaction Step", []string{"name", "step", "status", "endpoint"}, constLabels),
+		StepSendDuration: metric.NewGauge(namespace, subsystem, name, "step_send_duration",
+			"Send Duration of Transaction Step", []string{"name", "step", "status", "endpoint"}, constLabels),
+		StepWaitDuration: metric.NewGauge(namespace, subsystem, name, "step_wait_duration",
+			"Wait Duration of Transaction Step", []string{"name", "step", "status", "endpoint"}, constLabels),
+		StepTransferDuration: metric.NewGauge(namespace, subsystem, name, "step_transfer_duration",
+			"Transfer Duration of Transaction Step", []string{"name", "step", "status", "endpoint"}, constLabels),
 	}
 }
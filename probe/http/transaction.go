@@ -0,0 +1,299 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/megaease/easeprobe/eval"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe"
+)
+
+// variableRegex matches the `{{ name }}` placeholder of the variable.
+// Note: `$name` cannot be used because the configuration expands the environment variables.
+var variableRegex = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Step is a request of the HTTP transaction
+type Step struct {
+	Name            string            `yaml:"name,omitempty" json:"name,omitempty" jsonschema:"title=Step Name,description=the name of the step"`
+	URL             string            `yaml:"url" json:"url" jsonschema:"required,title=HTTP URL,description=HTTP URL of the step, the variables can be used as {{name}}"`
+	Method          string            `yaml:"method,omitempty" json:"method,omitempty" jsonschema:"enum=GET,enum=POST,enum=DELETE,enum=PUT,enum=HEAD,enum=OPTIONS,enum=PATCH,enum=TRACE,enum=CONNECT,title=HTTP Method,description=HTTP method of the step"`
+	ContentEncoding string            `yaml:"content_encoding,omitempty" json:"content_encoding,omitempty" jsonschema:"title=Content Encoding,description=content encoding of the step (default is the probe's content encoding)"`
+	Headers         map[string]string `yaml:"headers,omitempty" json:"headers,omitempty" jsonschema:"title=HTTP Headers,description=HTTP headers of the step which override the probe's headers, the variables can be used as {{name}}"`
+	Body            string            `yaml:"body,omitempty" json:"body,omitempty" jsonschema:"title=HTTP Body,description=HTTP body of the step, the variables can be used as {{name}}"`
+	SuccessCode     [][]int           `yaml:"success_code,omitempty" json:"success_code,omitempty" jsonschema:"title=HTTP Success Code Range,description=Preferred HTTP response code ranges. If not set the probe's success code is used."`
+
+	// Output Text Checker
+	probe.TextChecker `yaml:",inline"`
+
+	// Evaluator - the variables are extracted and can be used by the following steps
+	Evaluator eval.Evaluator `yaml:"eval,omitempty" json:"eval,omitempty" jsonschema:"title=HTTP Evaluator,description=extract the variables and evaluate the expression of the response"`
+
+	traceStats *TraceStats `yaml:"-" json:"-"`
+}
+
+// title returns the title of the step
+func (s *Step) title(i int) string {
+	if len(s.Name) > 0 {
+		return fmt.Sprintf("Step #%d [%s]", i+1, s.Name)
+	}
+	return fmt.Sprintf("Step #%d", i+1)
+}
+
+// label returns the label of the step in the metrics, it is the step number if the name is not set
+func (s *Step) label(i int) string {
+	if len(s.Name) > 0 {
+		return s.Name
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// hasEvaluator returns true if the step needs to extract the variables or evaluate the expression
+func (s *Step) hasEvaluator() bool {
+	return s.Evaluator.DocType != eval.Unsupported &&
+		(len(s.Evaluator.Variables) > 0 || len(strings.TrimSpace(s.Evaluator.Expression)) > 0)
+}
+
+// configSteps config the steps of the transaction
+func (h *HTTP) configSteps() error {
+	for i := range h.Steps {
+		s := &h.Steps[i]
+		// the placeholders are replaced to check the URL
+		u, err := url.ParseRequestURI(variableRegex.ReplaceAllString(s.URL, "x"))
+		if err == nil && (len(u.Scheme) == 0 || len(u.Host) == 0) {
+			err = fmt.Errorf("the scheme or the host is missing")
+		}
+		if err != nil {
+			log.Errorf("[%s / %s] %s URL is not valid - %+v url=%+v", h.ProbeKind, h.ProbeName, s.title(i), err, s.URL)
+			return fmt.Errorf("%s URL is not valid - %v", s.title(i), err)
+		}
+		if !checkHTTPMethod(s.Method) {
+			s.Method = "GET"
+		}
+		s.Method = strings.ToUpper(s.Method)
+		if len(s.ContentEncoding) == 0 {
+			s.ContentEncoding = h.ContentEncoding
+		}
+		s.SuccessCode = h.configSuccessCode(s.SuccessCode)
+		if len(s.SuccessCode) == 0 {
+			s.SuccessCode = h.SuccessCode
+		}
+		if err := s.TextChecker.Config(); err != nil {
+			return fmt.Errorf("%s - %v", s.title(i), err)
+		}
+		if s.hasEvaluator() {
+			if err := s.Evaluator.Config(); err != nil {
+				return fmt.Errorf("%s - %v", s.title(i), err)
+			}
+		}
+	}
+	return nil
+}
+
+// substitute replaces the `{{ name }}` placeholders with the variables
+func substitute(str string, vars map[string]string) (string, error) {
+	return substituteEscape(str, vars, nil)
+}
+
+// substituteURL replaces the `{{ name }}` placeholders of the URL with the escaped variables,
+// the variables in the query string are query escaped, and the others are path escaped
+func substituteURL(u string, vars map[string]string) (string, error) {
+	path, query, hasQuery := strings.Cut(u, "?")
+	path, err := substituteEscape(path, vars, url.PathEscape)
+	if err != nil || !hasQuery {
+		return path, err
+	}
+	query, err = substituteEscape(query, vars, url.QueryEscape)
+	return path + "?" + query, err
+}
+
+// substituteEscape replaces the `{{ name }}` placeholders with the variables escaped by the escape function
+func substituteEscape(str string, vars map[string]string, escape func(string) string) (string, error) {
+	var err error
+	result := variableRegex.ReplaceAllStringFunc(str, func(m string) string {
+		name := variableRegex.FindStringSubmatch(m)[1]
+		v, ok := vars[name]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("the variable [%s] is not defined", name)
+			}
+			return m
+		}
+		if escape != nil {
+			return escape(v)
+		}
+		return v
+	})
+	return result, err
+}
+
+// DoTransaction runs the steps in order with the same cookie jar
+func (h *HTTP) DoTransaction() (bool, string) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+	// share the transport and the redirect policy of the probe
+	client := &http.Client{
+		Timeout:       h.client.Timeout,
+		Transport:     h.client.Transport,
+		CheckRedirect: h.client.CheckRedirect,
+		Jar:           jar,
+	}
+
+	vars := map[string]string{}
+	var summary []string
+	var latency time.Duration
+	for i := range h.Steps {
+		s := &h.Steps[i]
+		code, err := h.doStep(client, i, vars)
+		if err != nil {
+			log.Errorf("[%s / %s] %s - %v", h.ProbeKind, h.ProbeName, s.title(i), err)
+			return false, fmt.Sprintf("%s - %v", s.title(i), err)
+		}
+		summary = append(summary, fmt.Sprintf("%s %d (%.2fms)", s.title(i), code, toMS(s.traceStats.totalTook)))
+		latency += s.traceStats.totalTook
+	}
+	h.checkLatency(latency)
+
+	return true, fmt.Sprintf("HTTP Transaction with %d Step(s) Passed - %s", len(h.Steps), strings.Join(summary, ", "))
+}
+
+// doStep sends the request of the step, checks the response and extracts the variables
+func (h *HTTP) doStep(client *http.Client, i int, vars map[string]string) (int, error) {
+	s := &h.Steps[i]
+	u, err := substituteURL(s.URL, vars)
+	if err != nil {
+		return 0, err
+	}
+	body, err := substitute(s.Body, vars)
+	if err != nil {
+		return 0, err
+	}
+	headers := make(map[string]string, len(h.Headers)+len(s.Headers))
+	for k, v := range h.Headers {
+		headers[k] = v
+	}
+	for k, v := range s.Headers {
+		if headers[k], err = substitute(v, vars); err != nil {
+			return 0, err
+		}
+	}
+
+	req, err := h.newRequest(s.Method, u, body, s.ContentEncoding, headers)
+	if err != nil {
+		return 0, fmt.Errorf("HTTP request error - %v", err)
+	}
+
+	s.traceStats = NewTraceStats(h.ProbeKind, s.label(i), h.ProbeName)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), s.traceStats.clientTrace))
+
+	resp, err := client.Do(req)
+	s.traceStats.Done()
+
+	code := 0
+	if resp != nil {
+		code = resp.StatusCode
+	}
+	h.ExportStepMetrics(s, s.label(i), code)
+	if err != nil {
+		return code, fmt.Errorf("Error: %v", err)
+	}
+	defer resp.Body.Close()
+	response, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return code, fmt.Errorf("Error: %v", err)
+	}
+
+	if !checkSuccessCode(s.SuccessCode, code) {
+		if s.WithOutput {
+			return code, fmt.Errorf("HTTP Status Code is %d. It missed in %v. Response:\n[%s]", code, s.SuccessCode, string(response))
+		}
+		return code, fmt.Errorf("HTTP Status Code is %d. It missed in %v", code, s.SuccessCode)
+	}
+
+	if err := s.Check(string(response)); err != nil {
+		return code, fmt.Errorf("HTTP Status Code is %d. Error: %v", code, err)
+	}
+
+	if !s.hasEvaluator() {
+		return code, nil
+	}
+	s.Evaluator.SetDocument(s.Evaluator.DocType, string(response))
+	if err := s.Evaluator.Extract(); err != nil {
+		return code, fmt.Errorf("HTTP Status Code is %d. Extraction Error: %v", code, err)
+	}
+	for _, v := range s.Evaluator.Variables {
+		vars[v.Name] = fmt.Sprintf("%v", v.Value)
+		log.Debugf("[%s / %s] - Variable: [%s] = [%v]", h.ProbeKind, h.ProbeName, v.Name, v.Value)
+	}
+	if len(strings.TrimSpace(s.Evaluator.Expression)) > 0 {
+		result, err := s.Evaluator.Evaluate()
+		if err != nil {
+			return code, fmt.Errorf("HTTP Status Code is %d. Evaluation Error: %v", code, err)
+		}
+		if !result {
+			return code, fmt.Errorf("HTTP Status Code is %d. Expression is evaluated to false!", code)
+		}
+	}
+	return code, nil
+}
+
+// ExportStepMetrics export the metrics of the transaction step
+func (h *HTTP) ExportStepMetrics(s *Step, step string, code int) {
+	h.metrics.StepStatusCode.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     h.ProbeName,
+		"step":     step,
+		"status":   fmt.Sprintf("%d", code),
+		"endpoint": h.ProbeResult.Endpoint,
+	}, h.Labels)).Inc()
+
+	// the timings of every phase, the same as the single request
+	durations := []struct {
+		gauge *prometheus.GaugeVec
+		took  time.Duration
+	}{
+		{h.metrics.StepDNSDuration, s.traceStats.dnsTook},
+		{h.metrics.StepConnectDuration, s.traceStats.connTook},
+		{h.metrics.StepTLSDuration, s.traceStats.tlsTook},
+		{h.metrics.StepSendDuration, s.traceStats.sendTook},
+		{h.metrics.StepWaitDuration, s.traceStats.waitTook},
+		{h.metrics.StepTransferDuration, s.traceStats.transferTook},
+		{h.metrics.StepDuration, s.traceStats.totalTook},
+	}
+	for _, d := range durations {
+		d.gauge.With(metric.AddConstLabels(prometheus.Labels{
+			"name":     h.ProbeName,
+			"step":     step,
+			"status":   fmt.Sprintf("%d", code),
+			"endpoint": h.ProbeResult.Endpoint,
+		}, h.Labels)).Set(toMS(d.took))
+	}
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/megaease/easeprobe/eval"
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/base"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTransactionServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || string(body) != `{"user":"admin"}` {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t", Path: "/"})
+		w.Write([]byte("welcome"))
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err != nil || c.Value != "s3cr3t" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"token":"abc123","user":{"id":42}}`))
+	})
+	mux.HandleFunc("/api/42", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc123" || r.Header.Get("X-Env") != "test" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("bye"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTransaction(url string) *HTTP {
+	return &HTTP{
+		DefaultProbe: base.DefaultProbe{ProbeName: "dummy http transaction", ProbeTimeout: time.Second},
+		Headers:      map[string]string{"X-Env": "test"},
+		SuccessCode:  [][]int{{200, 299}},
+		Steps: []Step{
+			{
+				Name:   "login",
+				URL:    url + "/login",
+				Method: "post",
+				Body:   `{"user":"admin"}`,
+				TextChecker: probe.TextChecker{
+					Contain: "welcome",
+				},
+			},
+			{
+				Name: "token",
+				URL:  url + "/token",
+				Evaluator: eval.Evaluator{
+					DocType: eval.JSON,
+					Variables: []eval.Variable{
+						{Name: "token", Type: eval.String, Query: "//token"},
+						{Name: "uid", Type: eval.Int, Query: "//user/id"},
+					},
+				},
+			},
+			{
+				Name:    "api",
+				URL:     url + "/api/{{ uid }}",
+				Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+				Evaluator: eval.Evaluator{
+					DocType:    eval.JSON,
+					Expression: "x_str('//status') == 'ok'",
+				},
+			},
+			{
+				Name: "logout",
+				URL:  url + "/logout",
+			},
+		},
+	}
+}
+
+func TestHTTPTransaction(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	server := newTransactionServer()
+	defer server.Close()
+
+	h := newTransaction(server.URL)
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	assert.Equal(t, server.URL+"/login", h.Result().Endpoint)
+	assert.Equal(t, "POST", h.Steps[0].Method)
+	assert.Equal(t, "GET", h.Steps[1].Method)
+	assert.Equal(t, [][]int{{200, 299}}, h.Steps[1].SuccessCode)
+
+	s, m := h.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "4 Step(s) Passed")
+	assert.Contains(t, m, "Step #4 [logout] 200")
+	for _, step := range h.Steps {
+		assert.NotNil(t, step.traceStats)
+	}
+
+	// the cookie jar is not shared between the probes
+	h.Steps = h.Steps[1:]
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Step #1 [token] - HTTP Status Code is 403")
+
+	// the expression is evaluated to false
+	h = newTransaction(server.URL)
+	h.Steps[2].Evaluator.Expression = "x_str('//status') == 'error'"
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Step #3 [api] - HTTP Status Code is 200. Expression is evaluated to false!")
+
+	// the variable is not defined
+	h = newTransaction(server.URL)
+	h.Steps[1].Evaluator.Variables = h.Steps[1].Evaluator.Variables[1:]
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "the variable [token] is not defined")
+
+	// the text checker
+	h = newTransaction(server.URL)
+	h.Steps[0].Contain = "hello"
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Step #1 [login] - HTTP Status Code is 200. Error: the output does not contain [hello]")
+
+	// the extraction fails
+	h = newTransaction(server.URL)
+	h.Steps[1].Evaluator.DocType = eval.XML
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	s, m = h.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Extraction Error")
+
+	// the unnamed step is labeled by its number in the metrics
+	h = newTransaction(server.URL)
+	h.Steps[3].Name = ""
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	s, m = h.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "Step #4 200")
+	labels := prometheus.Labels{"name": h.ProbeName, "step": "#4", "status": "200", "endpoint": h.ProbeResult.Endpoint}
+	assert.Equal(t, 1.0, testutil.ToFloat64(h.metrics.StepStatusCode.With(labels)))
+
+	// the timings of every phase are exported by the step
+	stats := h.Steps[3].traceStats
+	assert.Equal(t, toMS(stats.connTook), testutil.ToFloat64(h.metrics.StepConnectDuration.With(labels)))
+	assert.Equal(t, toMS(stats.sendTook), testutil.ToFloat64(h.metrics.StepSendDuration.With(labels)))
+	assert.Equal(t, toMS(stats.waitTook), testutil.ToFloat64(h.metrics.StepWaitDuration.With(labels)))
+	assert.Equal(t, toMS(stats.transferTook), testutil.ToFloat64(h.metrics.StepTransferDuration.With(labels)))
+	assert.Equal(t, toMS(stats.totalTook), testutil.ToFloat64(h.metrics.StepDuration.With(labels)))
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(h.metrics.StepDNSDuration), 4)
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(h.metrics.StepTLSDuration), 4)
+}
+
+func TestHTTPTransactionConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	h := newTransaction("http://localhost:8080")
+	h.URL = "http://localhost:8080/health"
+	assert.Nil(t, h.Config(global.ProbeSettings{}))
+	assert.Equal(t, h.URL, h.Result().Endpoint)
+
+	h = newTransaction("http://localhost:8080")
+	h.URL = "not a url"
+	assert.NotNil(t, h.Config(global.ProbeSettings{}))
+
+	h = newTransaction("")
+	err := h.Config(global.ProbeSettings{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Step #1 [login] URL is not valid")
+
+	h = newTransaction("http://localhost:8080")
+	h.Steps[1].TextChecker = probe.TextChecker{Contain: "[", RegExp: true}
+	err = h.Config(global.ProbeSettings{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Step #2 [token]")
+
+	// no steps, the URL is required
+	h = newTransaction("http://localhost:8080")
+	h.Steps = nil
+	assert.NotNil(t, h.Config(global.ProbeSettings{}))
+}
+
+func TestSubstitute(t *testing.T) {
+	vars := map[string]string{"a": "1", "b_2": "two"}
+	s, err := substitute("{{a}}-{{ b_2 }}-{{a }}", vars)
+	assert.Nil(t, err)
+	assert.Equal(t, "1-two-1", s)
+
+	s, err = substitute("no variable {a}", vars)
+	assert.Nil(t, err)
+	assert.Equal(t, "no variable {a}", s)
+
+	_, err = substitute("{{c}}", vars)
+	assert.Equal(t, fmt.Errorf("the variable [c] is not defined"), err)
+
+	// the variables in the URL are escaped
+	vars = map[string]string{"id": "a/b c?", "q": "x&y=z #1"}
+	s, err = substituteURL("http://example.com/items/{{id}}?q={{q}}&id={{id}}", vars)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com/items/a%2Fb%20c%3F?q=x%26y%3Dz+%231&id=a%2Fb+c%3F", s)
+
+	s, err = substituteURL("http://example.com/{{id}}", vars)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com/a%2Fb%20c%3F", s)
+
+	_, err = substituteURL("http://example.com/?q={{c}}", vars)
+	assert.Equal(t, fmt.Errorf("the variable [c] is not defined"), err)
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package maintenance is the scheduled maintenance windows package
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/megaease/easeprobe/global"
+)
+
+// the time layouts supported by the one-off window
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// Window is the maintenance window.
+// It is either a one-off window (start/end) or a recurring window (cron/duration).
+// The window applies to all probes unless the `probes` or `labels` is configured.
+type Window struct {
+	Name     string            `yaml:"name,omitempty" json:"name,omitempty" jsonschema:"title=Name,description=The name of the maintenance window"`
+	Start    string            `yaml:"start,omitempty" json:"start,omitempty" jsonschema:"title=Start,description=The start time of the one-off window,example=2022-10-01 02:00"`
+	End      string            `yaml:"end,omitempty" json:"end,omitempty" jsonschema:"title=End,description=The end time of the one-off window,example=2022-10-01 04:00"`
+	Cron     string            `yaml:"cron,omitempty" json:"cron,omitempty" jsonschema:"title=Cron,description=The cron expression of the recurring window start,example=0 2 * * 6"`
+	Duration time.Duration     `yaml:"duration,omitempty" json:"duration,omitempty" jsonschema:"type=string,format=duration,title=Duration,description=The duration of the recurring window,example=2h"`
+	Probes   []string          `yaml:"probes,omitempty" json:"probes,omitempty" jsonschema:"title=Probes,description=The names of the probes in maintenance"`
+	Labels   map[string]string `yaml:"labels,omitempty" json:"labels,omitempty" jsonschema:"title=Labels,description=The label selector of the probes in maintenance"`
+
+	start    time.Time     `yaml:"-" json:"-"`
+	end      time.Time     `yaml:"-" json:"-"`
+	schedule cron.Schedule `yaml:"-" json:"-"`
+}
+
+var (
+	mutex   sync.RWMutex
+	windows []*Window
+)
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(s), global.GetTimeLocation()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time format [%s], the supported formats are: %s",
+		s, strings.Join(timeLayouts, ", "))
+}
+
+// Config check and configure the maintenance window
+func (w *Window) Config() error {
+	if len(w.Cron) > 0 && (len(w.Start) > 0 || len(w.End) > 0) {
+		return fmt.Errorf("the cron and start/end cannot be configured at the same time")
+	}
+
+	if len(w.Cron) > 0 {
+		schedule, err := cron.ParseStandard(w.Cron)
+		if err != nil {
+			return fmt.Errorf("invalid cron expression [%s]: %v", w.Cron, err)
+		}
+		if w.Duration <= 0 {
+			return fmt.Errorf("the duration must be greater than zero for the cron window")
+		}
+		w.schedule = schedule
+		return nil
+	}
+
+	if len(w.Start) <= 0 || len(w.End) <= 0 {
+		return fmt.Errorf("either the start/end or the cron/duration must be configured")
+	}
+	var err error
+	if w.start, err = parseTime(w.Start); err != nil {
+		return err
+	}
+	if w.end, err = parseTime(w.End); err != nil {
+		return err
+	}
+	if !w.end.After(w.start) {
+		return fmt.Errorf("the end time [%s] must be after the start time [%s]", w.End, w.Start)
+	}
+	return nil
+}
+
+// Match returns true if the window applies to the probe
+func (w *Window) Match(name string, labels map[string]string) bool {
+	if len(w.Probes) > 0 {
+		found := false
+		for _, p := range w.Probes {
+			if p == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for k, v := range w.Labels {
+		if l, ok := labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Active returns true if the time is in the window
+func (w *Window) Active(t time.Time) bool {
+	if w.schedule != nil {
+		// the latest start time before t must be within the duration,
+		// so the next start time after (t - duration) must not be after t
+		next := w.schedule.Next(t.In(global.GetTimeLocation()).Add(-w.Duration))
+		return !next.IsZero() && !next.After(t)
+	}
+	return !t.Before(w.start) && t.Before(w.end)
+}
+
+// SetWindows configure the maintenance windows, the invalid windows are ignored
+func SetWindows(ws []Window) {
+	valid := []*Window{}
+	for i := range ws {
+		w := ws[i]
+		if len(w.Name) <= 0 {
+			w.Name = fmt.Sprintf("maintenance #%d", i+1)
+		}
+		if err := w.Config(); err != nil {
+			log.Errorf("[Maintenance / %s] Invalid maintenance window, ignored: %v", w.Name, err)
+			continue
+		}
+		log.Infof("[Maintenance / %s] Maintenance window is configured!", w.Name)
+		valid = append(valid, &w)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	windows = valid
+}
+
+// Find returns the maintenance window which the probe is in at the time, nil if not found
+func Find(name string, labels map[string]string, t time.Time) *Window {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, w := range windows {
+		if w.Match(name, labels) && w.Active(t) {
+			return w
+		}
+	}
+	return nil
+}
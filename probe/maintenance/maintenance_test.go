@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/megaease/easeprobe/global"
+)
+
+func at(t *testing.T, s string) time.Time {
+	tm, err := parseTime(s)
+	assert.Nil(t, err)
+	return tm
+}
+
+func TestWindowConfig(t *testing.T) {
+	global.InitEaseProbeWithTime("easeprobe", "icon", global.DefaultTimeFormat, "UTC")
+
+	w := Window{Start: "2022-10-01 02:00", End: "2022-10-01T04:00:00Z"}
+	assert.Nil(t, w.Config())
+
+	w = Window{Cron: "0 2 * * 6", Duration: time.Hour}
+	assert.Nil(t, w.Config())
+	assert.NotNil(t, w.schedule)
+
+	errCases := []Window{
+		{},
+		{Start: "2022-10-01 02:00"},
+		{Start: "2022/10/01", End: "2022-10-01 04:00"},
+		{Start: "2022-10-01 02:00", End: "2022-10-01"},
+		{Start: "2022-10-01 04:00", End: "2022-10-01 02:00"},
+		{Cron: "0 2 * * 6"},
+		{Cron: "bad cron", Duration: time.Hour},
+		{Cron: "0 2 * * 6", Duration: time.Hour, Start: "2022-10-01 02:00"},
+	}
+	for _, c := range errCases {
+		assert.NotNil(t, c.Config(), "%+v", c)
+	}
+}
+
+func TestWindowActive(t *testing.T) {
+	global.InitEaseProbeWithTime("easeprobe", "icon", global.DefaultTimeFormat, "Asia/Shanghai")
+
+	// one-off window
+	w := Window{Start: "2022-10-01 02:00", End: "2022-10-01 04:00"}
+	assert.Nil(t, w.Config())
+	assert.False(t, w.Active(at(t, "2022-10-01 01:59:59")))
+	assert.True(t, w.Active(at(t, "2022-10-01 02:00")))
+	assert.True(t, w.Active(at(t, "2022-10-01 03:59:59")))
+	assert.False(t, w.Active(at(t, "2022-10-01 04:00")))
+	// the time zone is respected: 02:00 in Shanghai is 18:00 UTC of the day before
+	assert.True(t, w.Active(time.Date(2022, 9, 30, 18, 30, 0, 0, time.UTC)))
+	assert.False(t, w.Active(time.Date(2022, 10, 1, 2, 30, 0, 0, time.UTC)))
+
+	// every Saturday 02:00 - 04:00, 2022-10-01 is Saturday
+	w = Window{Cron: "0 2 * * 6", Duration: 2 * time.Hour}
+	assert.Nil(t, w.Config())
+	assert.False(t, w.Active(at(t, "2022-10-01 01:59:59")))
+	assert.True(t, w.Active(at(t, "2022-10-01 02:00")))
+	assert.True(t, w.Active(at(t, "2022-10-01 03:59:59")))
+	assert.False(t, w.Active(at(t, "2022-10-01 04:00")))
+	assert.True(t, w.Active(at(t, "2022-10-08 03:00")))
+	assert.False(t, w.Active(at(t, "2022-10-07 03:00")))
+	assert.True(t, w.Active(time.Date(2022, 9, 30, 18, 30, 0, 0, time.UTC)))
+}
+
+func TestWindowMatch(t *testing.T) {
+	w := Window{}
+	assert.True(t, w.Match("any", nil))
+
+	w = Window{Probes: []string{"a", "b"}}
+	assert.True(t, w.Match("a", nil))
+	assert.False(t, w.Match("c", nil))
+
+	w = Window{Labels: map[string]string{"env": "prod", "role": "db"}}
+	assert.True(t, w.Match("any", map[string]string{"env": "prod", "role": "db", "x": "y"}))
+	assert.False(t, w.Match("any", map[string]string{"env": "prod"}))
+	assert.False(t, w.Match("any", map[string]string{"env": "dev", "role": "db"}))
+
+	w = Window{Probes: []string{"a"}, Labels: map[string]string{"env": "prod"}}
+	assert.True(t, w.Match("a", map[string]string{"env": "prod"}))
+	assert.False(t, w.Match("b", map[string]string{"env": "prod"}))
+}
+
+func TestFind(t *testing.T) {
+	global.InitEaseProbeWithTime("easeprobe", "icon", global.DefaultTimeFormat, "UTC")
+
+	SetWindows([]Window{
+		{Start: "2022-10-01 02:00", End: "2022-10-01 04:00", Probes: []string{"db"}},
+		{Name: "weekly", Cron: "0 2 * * 6", Duration: time.Hour, Labels: map[string]string{"env": "prod"}},
+		{Name: "invalid", Cron: "0 2 * * 6"},
+	})
+	defer SetWindows(nil)
+	assert.Equal(t, 2, len(windows))
+
+	w := Find("db", nil, at(t, "2022-10-01 03:00"))
+	assert.NotNil(t, w)
+	assert.Equal(t, "maintenance #1", w.Name)
+
+	assert.Nil(t, Find("web", nil, at(t, "2022-10-01 03:00")))
+
+	w = Find("web", map[string]string{"env": "prod"}, at(t, "2022-10-08 02:30"))
+	assert.NotNil(t, w)
+	assert.Equal(t, "weekly", w.Name)
+
+	assert.Nil(t, Find("web", map[string]string{"env": "prod"}, at(t, "2022-10-08 03:30")))
+}
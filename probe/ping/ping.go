@@ -37,6 +37,7 @@ type Ping struct {
 	Host              string  `yaml:"host" json:"host" jsonschema:"required,title=Host,description=The host to ping"`
 	Count             int     `yaml:"count" json:"count" jsonschema:"title=Count,description=The number of ping packets to send,minimum=1,default=3"`
 	LostThreshold     float64 `yaml:"lost" json:"lost" jsonschema:"title=Lost Threshold,description=The threshold of packet loss,minimum=0,maximum=1,default=0"`
+	DegradedLost      float64 `yaml:"degraded_lost,omitempty" json:"degraded_lost,omitempty" jsonschema:"title=Degraded Lost Threshold,description=The probe is degraded if the packet loss is greater than it,minimum=0,maximum=1,default=0"`
 	Privileged        bool    `yaml:"privileged" json:"privileged" jsonschema:"title=Privileged,description=Run ping with privileged modem, default=false"`
 
 	metrics *metrics `yaml:"-" json:"-"`
@@ -70,6 +71,11 @@ func (p *Ping) Config(gConf global.ProbeSettings) error {
 		p.LostThreshold = DefaultLostThreshold
 	}
 
+	if p.DegradedLost < 0 || p.DegradedLost > 1 {
+		log.Warnf("[%s / %s] degraded lost threshold %f is invalid, ignored", p.ProbeKind, p.ProbeName, p.DegradedLost)
+		p.DegradedLost = 0
+	}
+
 	p.metrics = newMetrics(kind, tag, p.Labels)
 
 	log.Debugf("[%s / %s] configuration: %+v", p.ProbeKind, p.ProbeName, *p)
@@ -120,6 +126,8 @@ func (p *Ping) DoProbe() (bool, string) {
 	if stats.PacketLoss > p.LostThreshold*100 {
 		result = false
 		message = "Failed!"
+	} else if p.DegradedLost > 0 && stats.PacketLoss > p.DegradedLost*100 {
+		p.Degrade(fmt.Sprintf("%.2f%% packet loss > %.2f%%", stats.PacketLoss, p.DegradedLost*100))
 	}
 	message = fmt.Sprintf("Ping %s %s", p.Host, message)
 	log.Infof("[%s / %s] %s (%s-%s)", p.ProbeKind, p.ProbeName, message, network, protocol)
@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package process
+
+import (
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics is the metrics for process probe
+type metrics struct {
+	Instances *prometheus.GaugeVec
+	RSS       *prometheus.GaugeVec
+	CPU       *prometheus.GaugeVec
+}
+
+// newMetrics create the process metrics
+func newMetrics(subsystem, name string, constLabels prometheus.Labels) *metrics {
+	namespace := global.GetEaseProbe().Name
+	return &metrics{
+		Instances: metric.NewGauge(namespace, subsystem, name, "instances",
+			"The number of the running processes", []string{"name", "endpoint"}, constLabels),
+		RSS: metric.NewGauge(namespace, subsystem, name, "rss",
+			"The total RSS (bytes) of the processes", []string{"name", "endpoint"}, constLabels),
+		CPU: metric.NewGauge(namespace, subsystem, name, "cpu",
+			"The total CPU usage (percentage) of the processes", []string{"name", "endpoint"}, constLabels),
+	}
+}
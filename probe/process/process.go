@@ -0,0 +1,393 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package process is the process probe package, it checks the processes or the systemd units
+// on the local host or the remote host by SSH.
+package process
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/ssh"
+)
+
+// the max length of the process name in Linux, the longer name is truncated
+const maxCommLen = 15
+
+// the separator of the command output sections
+const separator = "---"
+
+// Process implements a config for the process probe
+type Process struct {
+	ssh.Server `yaml:",inline"`
+
+	// match the process by one of them
+	ProcessName string `yaml:"process_name,omitempty" json:"process_name,omitempty" jsonschema:"title=Process Name,description=the name of the process as pgrep does,example=nginx"`
+	PIDFile     string `yaml:"pidfile,omitempty" json:"pidfile,omitempty" jsonschema:"title=PID File,description=the pid file of the process,example=/var/run/nginx.pid"`
+	Cmdline     string `yaml:"cmdline,omitempty" json:"cmdline,omitempty" jsonschema:"title=Command Line,description=the regular expression of the process command line,example=java .*kafka"`
+	Systemd     string `yaml:"systemd,omitempty" json:"systemd,omitempty" jsonschema:"title=Systemd Unit,description=the systemd unit to check,example=nginx.service"`
+
+	// the thresholds of the processes
+	MinCount int     `yaml:"min_count,omitempty" json:"min_count,omitempty" jsonschema:"title=Min Count,description=the minimum number of the processes,default=1"`
+	MaxCount int     `yaml:"max_count,omitempty" json:"max_count,omitempty" jsonschema:"title=Max Count,description=the maximum number of the processes (0 means no limit)"`
+	MaxRSS   float64 `yaml:"max_rss,omitempty" json:"max_rss,omitempty" jsonschema:"title=Max RSS,description=the maximum total RSS (MB) of the processes"`
+	MaxCPU   float64 `yaml:"max_cpu,omitempty" json:"max_cpu,omitempty" jsonschema:"title=Max CPU,description=the maximum total CPU usage (percentage) of the processes"`
+
+	// the expected states of the systemd unit
+	ActiveState string `yaml:"active_state,omitempty" json:"active_state,omitempty" jsonschema:"title=Active State,description=the expected ActiveState of the systemd unit,default=active"`
+	SubState    string `yaml:"sub_state,omitempty" json:"sub_state,omitempty" jsonschema:"title=Sub State,description=the expected SubState of the systemd unit,example=running"`
+
+	cmdlineRegex *regexp.Regexp `yaml:"-" json:"-"`
+	count        int            `yaml:"-" json:"-"`
+	rss          float64        `yaml:"-" json:"-"`
+	cpu          float64        `yaml:"-" json:"-"`
+
+	// the CPU time (seconds) of the matched processes in the last probe
+	lastCPUTime map[string]float64 `yaml:"-" json:"-"`
+	lastTime    time.Time          `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// Config Process Config Object
+func (p *Process) Config(gConf global.ProbeSettings) error {
+	kind := "process"
+	tag := ""
+	name := p.ProbeName
+
+	if len(p.Host) <= 0 {
+		p.Host = ssh.LocalHost
+	}
+	p.Command = p.command()
+	endpoint := p.Host + " - " + p.target()
+
+	var err error
+	if p.IsLocal() {
+		err = p.DefaultProbe.Config(gConf, kind, tag, name, endpoint, p.DoProbe)
+	} else {
+		err = p.Configure(gConf, kind, tag, name, endpoint, &ssh.BastionMap, p.DoProbe)
+	}
+	if err != nil {
+		log.Errorf("[%s / %s] %v", p.ProbeKind, p.ProbeName, err)
+		return err
+	}
+
+	if err := p.check(); err != nil {
+		log.Errorf("[%s / %s] %v", p.ProbeKind, p.ProbeName, err)
+		return err
+	}
+
+	p.metrics = newMetrics(kind, tag, p.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", p.ProbeKind, p.ProbeName, *p)
+	return nil
+}
+
+// check the configuration and set the default values
+func (p *Process) check() error {
+	n := 0
+	for _, s := range []string{p.ProcessName, p.PIDFile, p.Cmdline, p.Systemd} {
+		if len(s) > 0 {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("one and only one of the process_name, pidfile, cmdline and systemd must be configured")
+	}
+
+	if len(p.Cmdline) > 0 {
+		r, err := regexp.Compile(p.Cmdline)
+		if err != nil {
+			return fmt.Errorf("invalid cmdline regular expression: %v", err)
+		}
+		p.cmdlineRegex = r
+	}
+
+	if len(p.Systemd) > 0 && len(p.ActiveState) <= 0 {
+		p.ActiveState = "active"
+	}
+
+	if p.MinCount <= 0 {
+		p.MinCount = 1
+	}
+	if p.MaxCount > 0 && p.MaxCount < p.MinCount {
+		return fmt.Errorf("the max_count %d is less than the min_count %d", p.MaxCount, p.MinCount)
+	}
+	return nil
+}
+
+// target returns what the probe checks
+func (p *Process) target() string {
+	switch {
+	case len(p.Systemd) > 0:
+		return "systemd: " + p.Systemd
+	case len(p.PIDFile) > 0:
+		return "pidfile: " + p.PIDFile
+	case len(p.Cmdline) > 0:
+		return "cmdline: " + p.Cmdline
+	}
+	return "process: " + p.ProcessName
+}
+
+// quote the string for the shell
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// command returns the shell command to collect the processes or the systemd unit.
+// The processes of the command itself are excluded by the shell pid.
+func (p *Process) command() string {
+	if len(p.Systemd) > 0 {
+		return "systemctl show " + quote(p.Systemd) + " --property=LoadState,ActiveState,SubState,MainPID"
+	}
+	cmd := []string{
+		"echo $$",
+		"echo " + separator,
+		"ps -eo pid=,ppid=,rss=,pcpu=,time=,comm=",
+		"echo " + separator,
+		"ps -eo pid=,args=",
+	}
+	if len(p.PIDFile) > 0 {
+		cmd = append(cmd, "echo "+separator, "cat "+quote(p.PIDFile)+" 2>/dev/null || true")
+	}
+	return strings.Join(cmd, "\n")
+}
+
+// DoProbe return the checking result
+func (p *Process) DoProbe() (bool, string) {
+	output, err := p.RunCmd()
+	if err != nil {
+		log.Errorf("[%s / %s] %v", p.ProbeKind, p.ProbeName, err)
+		return false, err.Error() + " - " + output
+	}
+	log.Debugf("[%s / %s] - %s", p.ProbeKind, p.ProbeName, probe.CheckEmpty(output))
+
+	if len(p.Systemd) > 0 {
+		return p.checkSystemd(output)
+	}
+	return p.checkProcesses(output)
+}
+
+// proc is a process in the `ps` output
+type proc struct {
+	pid     string
+	ppid    string
+	rss     float64 // KB
+	cpu     float64 // the average CPU usage in the lifetime of the process
+	cputime float64 // the accumulated CPU time (seconds)
+	comm    string
+	args    string
+}
+
+// parseCPUTime parses the CPU time of `ps` in the format of [[DD-]HH:]MM:SS to seconds
+func parseCPUTime(s string) float64 {
+	days := 0.0
+	if d := strings.SplitN(s, "-", 2); len(d) == 2 {
+		days, _ = strconv.ParseFloat(d[0], 64)
+		s = d[1]
+	}
+	seconds := 0.0
+	for _, f := range strings.Split(s, ":") {
+		v, _ := strconv.ParseFloat(f, 64)
+		seconds = seconds*60 + v
+	}
+	return days*24*3600 + seconds
+}
+
+// parseProcesses parse the output sections of the command: the shell pid, the processes, the command lines and the pid file
+func parseProcesses(output string) (self string, procs []*proc, pidfile string, err error) {
+	sections := strings.Split(output, separator+"\n")
+	if len(sections) < 3 {
+		return "", nil, "", fmt.Errorf("invalid process output")
+	}
+	self = strings.TrimSpace(sections[0])
+
+	index := map[string]*proc{}
+	for _, line := range strings.Split(sections[1], "\n") {
+		f := strings.Fields(line)
+		if len(f) < 6 {
+			continue
+		}
+		rss, _ := strconv.ParseFloat(f[2], 64)
+		cpu, _ := strconv.ParseFloat(f[3], 64)
+		pr := &proc{pid: f[0], ppid: f[1], rss: rss, cpu: cpu, cputime: parseCPUTime(f[4]), comm: strings.Join(f[5:], " ")}
+		index[pr.pid] = pr
+		procs = append(procs, pr)
+	}
+	if len(procs) <= 0 {
+		return "", nil, "", fmt.Errorf("invalid process output")
+	}
+
+	for _, line := range strings.Split(sections[2], "\n") {
+		f := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(f) < 2 {
+			continue
+		}
+		if pr, ok := index[f[0]]; ok {
+			pr.args = strings.TrimSpace(f[1])
+		}
+	}
+
+	if len(sections) > 3 {
+		pidfile = strings.TrimSpace(sections[3])
+	}
+	return self, procs, pidfile, nil
+}
+
+// match returns true if the process is the one to check
+func (p *Process) match(pr *proc, pidfile string) bool {
+	switch {
+	case len(p.PIDFile) > 0:
+		return pr.pid == pidfile
+	case p.cmdlineRegex != nil:
+		return p.cmdlineRegex.MatchString(pr.args)
+	}
+	name := p.ProcessName
+	if len(name) > maxCommLen {
+		name = name[:maxCommLen]
+	}
+	return pr.comm == name
+}
+
+// checkProcesses checks the count, RSS and CPU of the matched processes
+func (p *Process) checkProcesses(output string) (bool, string) {
+	self, procs, pidfile, err := parseProcesses(output)
+	if err != nil {
+		log.Errorf("[%s / %s] %v", p.ProbeKind, p.ProbeName, err)
+		return false, err.Error()
+	}
+	if len(p.PIDFile) > 0 && len(pidfile) <= 0 {
+		p.count, p.rss, p.cpu = 0, 0, 0
+		p.ExportMetrics()
+		return false, fmt.Sprintf("Error: the pid file [%s] is not found or empty", p.PIDFile)
+	}
+
+	pids := []string{}
+	p.count, p.rss, p.cpu = 0, 0, 0
+	now := time.Now()
+	elapsed := now.Sub(p.lastTime).Seconds()
+	cputimes := map[string]float64{}
+	for _, pr := range procs {
+		// exclude the processes of the command itself
+		if pr.pid == self || pr.ppid == self {
+			continue
+		}
+		if !p.match(pr, pidfile) {
+			continue
+		}
+		p.count++
+		p.rss += pr.rss * 1024
+		p.cpu += p.cpuUsage(pr, elapsed)
+		cputimes[pr.pid] = pr.cputime
+		pids = append(pids, pr.pid)
+	}
+	p.lastCPUTime, p.lastTime = cputimes, now
+	p.ExportMetrics()
+
+	message := fmt.Sprintf("%d processes [%s], RSS %.2fMB, CPU %.2f%%",
+		p.count, strings.Join(pids, ", "), p.rss/1024/1024, p.cpu)
+	if p.count < p.MinCount {
+		return false, fmt.Sprintf("Error: at least %d processes expected - %s", p.MinCount, message)
+	}
+	if p.MaxCount > 0 && p.count > p.MaxCount {
+		return false, fmt.Sprintf("Error: at most %d processes expected - %s", p.MaxCount, message)
+	}
+	if p.MaxRSS > 0 && p.rss/1024/1024 > p.MaxRSS {
+		return false, fmt.Sprintf("Error: RSS exceeds %.2fMB - %s", p.MaxRSS, message)
+	}
+	if p.MaxCPU > 0 && p.cpu > p.MaxCPU {
+		return false, fmt.Sprintf("Error: CPU exceeds %.2f%% - %s", p.MaxCPU, message)
+	}
+	return true, message
+}
+
+// cpuUsage returns the CPU usage (percentage) of the process since the last probe. It is the
+// average usage in the lifetime of the process if the process is not sampled in the last probe,
+// or the last probe is less than one second ago, because the CPU time of `ps` is in seconds.
+func (p *Process) cpuUsage(pr *proc, elapsed float64) float64 {
+	last, ok := p.lastCPUTime[pr.pid]
+	if !ok || elapsed < 1 || pr.cputime < last {
+		return pr.cpu
+	}
+	return (pr.cputime - last) / elapsed * 100
+}
+
+// checkSystemd checks the ActiveState and SubState of the systemd unit
+func (p *Process) checkSystemd(output string) (bool, string) {
+	props := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) == 2 {
+			props[kv[0]] = kv[1]
+		}
+	}
+
+	p.count = 0
+	if pid, _ := strconv.Atoi(props["MainPID"]); pid > 0 {
+		p.count = 1
+	}
+	p.rss, p.cpu = 0, 0
+	p.ExportMetrics()
+
+	if props["LoadState"] == "not-found" {
+		return false, fmt.Sprintf("Error: the unit [%s] is not found", p.Systemd)
+	}
+	if len(props["ActiveState"]) <= 0 {
+		return false, fmt.Sprintf("Error: invalid systemd output - %s", probe.CheckEmpty(output))
+	}
+
+	message := fmt.Sprintf("%s is %s (%s), MainPID %s", p.Systemd, props["ActiveState"], props["SubState"], props["MainPID"])
+	if props["ActiveState"] != p.ActiveState {
+		return false, fmt.Sprintf("Error: the ActiveState [%s] is expected - %s", p.ActiveState, message)
+	}
+	if len(p.SubState) > 0 && props["SubState"] != p.SubState {
+		return false, fmt.Sprintf("Error: the SubState [%s] is expected - %s", p.SubState, message)
+	}
+	return true, message
+}
+
+// ExportMetrics export process metrics
+func (p *Process) ExportMetrics() {
+	p.metrics.Instances.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     p.ProbeName,
+		"endpoint": p.ProbeResult.Endpoint,
+	}, p.Labels)).Set(float64(p.count))
+
+	if len(p.Systemd) > 0 {
+		return
+	}
+
+	p.metrics.RSS.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     p.ProbeName,
+		"endpoint": p.ProbeResult.Endpoint,
+	}, p.Labels)).Set(p.rss)
+
+	p.metrics.CPU.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     p.ProbeName,
+		"endpoint": p.ProbeResult.Endpoint,
+	}, p.Labels)).Set(p.cpu)
+}
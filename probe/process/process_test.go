@@ -0,0 +1,248 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/monkey"
+	"github.com/megaease/easeprobe/probe/base"
+	"github.com/megaease/easeprobe/probe/ssh"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProcess(name string) *Process {
+	return &Process{
+		Server: ssh.Server{
+			DefaultProbe: base.DefaultProbe{ProbeName: name},
+		},
+	}
+}
+
+func TestProcessLocal(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	p := newProcess("cmdline")
+	p.Cmdline = `process\.test`
+	assert.Nil(t, p.Config(global.ProbeSettings{}))
+	assert.Equal(t, "process", p.ProbeKind)
+	assert.Equal(t, ssh.LocalHost, p.Host)
+	assert.Equal(t, 1, p.MinCount)
+	assert.Equal(t, "local - cmdline: "+p.Cmdline, p.ProbeResult.Endpoint)
+	s, m := p.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, fmt.Sprintf("%d", os.Getpid()))
+
+	// the processes of the command itself are excluded
+	p = newProcess("no such process")
+	p.ProcessName = "no-such-process"
+	assert.Nil(t, p.Config(global.ProbeSettings{}))
+	s, m = p.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "0 processes")
+
+	// pid file
+	dir := t.TempDir()
+	pidfile := filepath.Join(dir, "test.pid")
+	assert.Nil(t, os.WriteFile(pidfile, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644))
+	p = newProcess("pidfile")
+	p.PIDFile = pidfile
+	p.MaxCount = 1
+	assert.Nil(t, p.Config(global.ProbeSettings{}))
+	s, m = p.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "1 processes")
+
+	p.PIDFile = filepath.Join(dir, "none.pid")
+	p.Command = p.command()
+	s, m = p.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "is not found or empty")
+}
+
+func TestProcessThreshold(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	output := "100\n---\n" +
+		"  1     0  2048  0.5 00:00:09 systemd\n" +
+		" 10     1 10240 20.0 1-00:00:00 nginx\n" +
+		" 11    10 10240 30.0 01:00:00 nginx\n" +
+		"101   100  1024  0.0 00:00:00 ps\n" +
+		"---\n" +
+		"  1 /sbin/init\n" +
+		" 10 nginx: master process /usr/sbin/nginx\n" +
+		" 11 nginx: worker process\n" +
+		"101 ps -eo pid=,args=\n"
+
+	p := newProcess("nginx")
+	p.ProcessName = "nginx"
+	assert.Nil(t, p.Config(global.ProbeSettings{}))
+	s, m := p.checkProcesses(output)
+	assert.True(t, s, m)
+	assert.Equal(t, "2 processes [10, 11], RSS 20.00MB, CPU 50.00%", m)
+
+	p.MaxCount = 1
+	s, m = p.checkProcesses(output)
+	assert.False(t, s)
+	assert.Contains(t, m, "at most 1 processes expected")
+
+	p.MaxCount = 0
+	p.MinCount = 3
+	s, m = p.checkProcesses(output)
+	assert.False(t, s)
+	assert.Contains(t, m, "at least 3 processes expected")
+
+	p.MinCount = 1
+	p.MaxRSS = 10
+	s, m = p.checkProcesses(output)
+	assert.False(t, s)
+	assert.Contains(t, m, "RSS exceeds 10.00MB")
+
+	p.MaxRSS = 0
+	p.MaxCPU = 40
+	s, m = p.checkProcesses(output)
+	assert.False(t, s)
+	assert.Contains(t, m, "CPU exceeds 40.00%")
+
+	p.MaxCPU = 0
+	p.ProcessName = ""
+	p.cmdlineRegex = nil
+	p.Cmdline = "master process"
+	assert.Nil(t, p.check())
+	s, m = p.checkProcesses(output)
+	assert.True(t, s, m)
+	assert.Contains(t, m, "1 processes [10]")
+
+	// the CPU usage is computed from the CPU time since the last probe
+	p.Cmdline, p.cmdlineRegex, p.ProcessName = "", nil, "nginx"
+	assert.Nil(t, p.check())
+	p.checkProcesses(output)
+	assert.Equal(t, map[string]float64{"10": 86400, "11": 3600}, p.lastCPUTime)
+	p.lastTime = p.lastTime.Add(-10 * time.Second)
+	busy := strings.Replace(output, "1-00:00:00", "1-00:00:08", 1)
+	busy = strings.Replace(busy, "01:00:00", "01:00:01", 1)
+	p.MaxCPU = 80
+	s, m = p.checkProcesses(busy)
+	assert.False(t, s)
+	assert.InDelta(t, 90, p.cpu, 1)
+	assert.Contains(t, m, "CPU exceeds 80.00%")
+
+	// the lifetime average is used for the process which is not sampled in the last probe
+	p.lastTime = p.lastTime.Add(-10 * time.Second)
+	s, m = p.checkProcesses(strings.ReplaceAll(busy, " 11 ", " 12 "))
+	assert.True(t, s, m)
+	assert.InDelta(t, 30, p.cpu, 0.01)
+	p.MaxCPU = 0
+
+	s, m = p.checkProcesses("invalid")
+	assert.False(t, s)
+	assert.Contains(t, m, "invalid process output")
+}
+
+func TestProcessSystemd(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	p := newProcess("nginx unit")
+	p.Host = "server.example.com"
+	p.User = "root"
+	p.Password = "password"
+	p.Systemd = "nginx.service"
+	p.SubState = "running"
+	assert.Nil(t, p.Config(global.ProbeSettings{}))
+	assert.Equal(t, "active", p.ActiveState)
+	assert.Equal(t, "systemctl show 'nginx.service' --property=LoadState,ActiveState,SubState,MainPID", p.Command)
+
+	output := "LoadState=loaded\nActiveState=active\nSubState=running\nMainPID=1234\n"
+	var s *ssh.Server
+	monkey.PatchInstanceMethod(reflect.TypeOf(s), "RunSSHCmd", func(_ *ssh.Server) (string, error) {
+		return output, nil
+	})
+	defer monkey.UnpatchAll()
+
+	status, m := p.DoProbe()
+	assert.True(t, status, m)
+	assert.Equal(t, "nginx.service is active (running), MainPID 1234", m)
+	assert.Equal(t, 1, p.count)
+
+	output = "LoadState=loaded\nActiveState=active\nSubState=exited\nMainPID=0\n"
+	status, m = p.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "the SubState [running] is expected")
+	assert.Equal(t, 0, p.count)
+
+	output = "LoadState=loaded\nActiveState=failed\nSubState=failed\nMainPID=0\n"
+	status, m = p.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "the ActiveState [active] is expected")
+
+	output = "LoadState=not-found\nActiveState=inactive\nSubState=dead\nMainPID=0\n"
+	status, m = p.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "is not found")
+
+	output = ""
+	status, m = p.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "invalid systemd output")
+
+	monkey.PatchInstanceMethod(reflect.TypeOf(s), "RunSSHCmd", func(_ *ssh.Server) (string, error) {
+		return "", fmt.Errorf("ssh error")
+	})
+	status, m = p.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, m, "ssh error")
+}
+
+func TestProcessConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	p := newProcess("none")
+	assert.NotNil(t, p.Config(global.ProbeSettings{}))
+
+	p = newProcess("both")
+	p.ProcessName = "nginx"
+	p.Systemd = "nginx.service"
+	assert.NotNil(t, p.Config(global.ProbeSettings{}))
+
+	p = newProcess("regex")
+	p.Cmdline = "("
+	err := p.Config(global.ProbeSettings{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid cmdline regular expression")
+
+	p = newProcess("count")
+	p.ProcessName = "nginx"
+	p.MinCount = 3
+	p.MaxCount = 2
+	assert.NotNil(t, p.Config(global.ProbeSettings{}))
+
+	// the remote host needs the password or private key
+	p = newProcess("remote")
+	p.Host = "server.example.com"
+	p.ProcessName = "nginx"
+	assert.NotNil(t, p.Config(global.ProbeSettings{}))
+
+	assert.Equal(t, `'it'\''s'`, quote("it's"))
+}
@@ -28,11 +28,14 @@ import (
 
 // Stat is the statistics of probe result
 type Stat struct {
-	Since    time.Time        `json:"since" yaml:"since"`
-	Total    int64            `json:"total" yaml:"total"`
-	Status   map[Status]int64 `json:"status" yaml:"status"`
-	UpTime   time.Duration    `json:"uptime" yaml:"uptime"`
-	DownTime time.Duration    `json:"downtime" yaml:"downtime"`
+	Since     time.Time        `json:"since" yaml:"since"`
+	Total     int64            `json:"total" yaml:"total"`
+	Status    map[Status]int64 `json:"status" yaml:"status"`
+	UpTime    time.Duration    `json:"uptime" yaml:"uptime"`
+	DownTime  time.Duration    `json:"downtime" yaml:"downtime"`
+	MaintTime time.Duration    `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
+	// DegradedTime is the part of the UpTime in the degraded status
+	DegradedTime time.Duration `json:"degraded,omitempty" yaml:"degraded,omitempty"`
 	StatusCounter
 	NotificationStrategyData `json:"alert" yaml:"alert"`
 }
@@ -49,6 +52,7 @@ type Result struct {
 	Message          string        `json:"message" yaml:"message"`
 	LatestDownTime   time.Time     `json:"latestdowntime" yaml:"latestdowntime"`
 	RecoveryDuration time.Duration `json:"recoverytime" yaml:"recoverytime"`
+	Maintenance      string        `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
 	Stat             Stat          `json:"stat" yaml:"stat"`
 }
 
@@ -74,6 +78,8 @@ func NewResult() *Result {
 			},
 			UpTime:        0,
 			DownTime:      0,
+			MaintTime:     0,
+			DegradedTime:  0,
 			StatusCounter: *NewStatusCounter(global.DefaultStatusChangeThresholdSetting),
 			NotificationStrategyData: *NewNotificationStrategyData(
 				global.DefaultNotificationStrategy,
@@ -111,6 +117,7 @@ func (r *Result) Clone() Result {
 	dst.Message = r.Message
 	dst.LatestDownTime = r.LatestDownTime
 	dst.RecoveryDuration = r.RecoveryDuration
+	dst.Maintenance = r.Maintenance
 	dst.Stat = r.Stat.Clone()
 	return dst
 }
@@ -126,6 +133,8 @@ func (s *Stat) Clone() Stat {
 	}
 	dst.UpTime = s.UpTime
 	dst.DownTime = s.DownTime
+	dst.MaintTime = s.MaintTime
+	dst.DegradedTime = s.DegradedTime
 	dst.StatusCounter = s.StatusCounter.Clone()
 	dst.NotificationStrategyData = s.NotificationStrategyData.Clone()
 	return dst
@@ -135,22 +144,48 @@ func (s *Stat) Clone() Stat {
 func (r *Result) DoStat(d time.Duration) {
 	r.Stat.Total++
 	r.Stat.Status[r.Status]++
-	if r.Status == StatusUp {
+	// the degraded service is still available,
+	// and the flapping service is booked by the latest probe result
+	if r.Status.IsAvailable() || (r.Status == StatusFlapping && r.Stat.CurrentStatus) {
 		r.Stat.UpTime += d
+		if r.Status == StatusDegraded {
+			r.Stat.DegradedTime += d
+		}
+	} else if r.IsInMaintenance() {
+		// the failure in the maintenance window is not the downtime
+		r.Stat.MaintTime += d
 	} else {
 		r.Stat.DownTime += d
 	}
 }
 
+// IsInMaintenance returns true if the probe is in a maintenance window
+func (r *Result) IsInMaintenance() bool {
+	return len(r.Maintenance) > 0
+}
+
 // Title return the title for notification
 func (r *Result) Title() string {
 	t := ""
+	recovery := "%s Recovery - ( " + r.RecoveryDuration.Round(time.Second).String() + " Downtime )"
 	if r.PreStatus == StatusInit && r.Status == StatusUp {
 		t = "Monitoring %s"
+	} else if r.Status == StatusDegraded && r.PreStatus == StatusDown {
+		t = recovery + " but Degraded"
+	} else if r.Status == StatusDegraded {
+		t = "%s Degraded"
+	} else if r.Status == StatusUnreachable {
+		t = "%s Unreachable"
+	} else if r.Status == StatusFlapping {
+		t = "%s Flapping"
 	} else if r.Status != StatusUp {
 		t = "%s Failure"
+	} else if r.PreStatus == StatusDegraded {
+		t = "%s Recovery from Degraded"
+	} else if r.PreStatus == StatusFlapping {
+		t = "%s Recovery from Flapping"
 	} else {
-		t = "%s Recovery - ( " + r.RecoveryDuration.Round(time.Second).String() + " Downtime )"
+		t = recovery
 	}
 	return fmt.Sprintf(t, r.Name)
 }
@@ -180,7 +215,7 @@ func (r *Result) SLAPercent() float64 {
 	uptime := r.Stat.UpTime.Seconds()
 	downtime := r.Stat.DownTime.Seconds()
 	if uptime+downtime <= 0 {
-		if r.Status == StatusUp {
+		if r.Status.IsAvailable() {
 			return 100
 		}
 		return 0
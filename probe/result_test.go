@@ -155,6 +155,44 @@ func TestTitle(t *testing.T) {
 	if r.Title() != expected {
 		t.Errorf("%s != %s", r.Title(), expected)
 	}
+
+	r.Status = StatusDegraded
+	expected = "Test Name Recovery - ( 5m0s Downtime ) but Degraded"
+	if r.Title() != expected {
+		t.Errorf("%s != %s", r.Title(), expected)
+	}
+
+	r.PreStatus = StatusUp
+	expected = "Test Name Degraded"
+	if r.Title() != expected {
+		t.Errorf("%s != %s", r.Title(), expected)
+	}
+
+	r.PreStatus = StatusDegraded
+	r.Status = StatusUp
+	expected = "Test Name Recovery from Degraded"
+	if r.Title() != expected {
+		t.Errorf("%s != %s", r.Title(), expected)
+	}
+	r.PreStatus = StatusUp
+	r.Status = StatusUnreachable
+	expected = "Test Name Unreachable"
+	if r.Title() != expected {
+		t.Errorf("%s != %s", r.Title(), expected)
+	}
+
+	r.Status = StatusFlapping
+	expected = "Test Name Flapping"
+	if r.Title() != expected {
+		t.Errorf("%s != %s", r.Title(), expected)
+	}
+
+	r.PreStatus = StatusFlapping
+	r.Status = StatusUp
+	expected = "Test Name Recovery from Flapping"
+	if r.Title() != expected {
+		t.Errorf("%s != %s", r.Title(), expected)
+	}
 }
 
 func TestDebug(t *testing.T) {
@@ -263,3 +301,40 @@ func TestSLAPercent(t *testing.T) {
 	r.Status = StatusUp
 	assert.Equal(t, float64(100), r.SLAPercent())
 }
+
+func TestDoStatInMaintenance(t *testing.T) {
+	r := NewResult()
+	r.Status = StatusDown
+	r.DoStat(time.Minute)
+	assert.Equal(t, time.Minute, r.Stat.DownTime)
+
+	r.Maintenance = "upgrade"
+	assert.True(t, r.IsInMaintenance())
+	r.DoStat(time.Minute)
+	assert.Equal(t, time.Minute, r.Stat.DownTime)
+	assert.Equal(t, time.Minute, r.Stat.MaintTime)
+
+	// the up time is still booked as up time
+	r.Status = StatusUp
+	r.DoStat(time.Minute)
+	assert.Equal(t, time.Minute, r.Stat.UpTime)
+	assert.Equal(t, time.Minute, r.Stat.MaintTime)
+	assert.Equal(t, float64(50), r.SLAPercent())
+
+	c := r.Clone()
+	assert.Equal(t, "upgrade", c.Maintenance)
+	assert.Equal(t, time.Minute, c.Stat.MaintTime)
+}
+
+func TestDoStatFlapping(t *testing.T) {
+	r := NewResult()
+	r.Status = StatusFlapping
+	r.Stat.CurrentStatus = true
+	r.DoStat(time.Minute)
+	assert.Equal(t, time.Minute, r.Stat.UpTime)
+
+	r.Stat.CurrentStatus = false
+	r.DoStat(time.Minute)
+	assert.Equal(t, time.Minute, r.Stat.DownTime)
+	assert.Equal(t, int64(2), r.Stat.Status[StatusFlapping])
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssh
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// bastionHost is the configuration of a bastion host, which could be reached through another bastion host
+type bastionHost struct {
+	Endpoint  `yaml:",inline"`
+	BastionID string `yaml:"bastion,omitempty"`
+}
+
+// UnmarshalYAML supports the `bastion` key of the bastion host, it references the previous hop of the bastion host.
+// (The `bastion` key cannot be the field of the Endpoint, because the Server has its own `bastion` key)
+func (bm *BastionMapType) UnmarshalYAML(value *yaml.Node) error {
+	hosts := map[string]bastionHost{}
+	if err := value.Decode(&hosts); err != nil {
+		return err
+	}
+	*bm = make(BastionMapType, len(hosts))
+	for k, v := range hosts {
+		v.Endpoint.bastionID = v.BastionID
+		(*bm)[k] = v.Endpoint
+	}
+	return nil
+}
+
+// MarshalYAML marshals the bastion hosts with their previous hops
+func (bm BastionMapType) MarshalYAML() (interface{}, error) {
+	hosts := map[string]bastionHost{}
+	for k, v := range bm {
+		hosts[k] = bastionHost{Endpoint: v, BastionID: v.bastionID}
+	}
+	return hosts, nil
+}
+
+// Chain returns the copy of the bastion host and its previous hops, so every probe has its own connections.
+// e.g. the chain of `office → region` returns the `region` bastion host, whose previous hop is `office`.
+func (bm *BastionMapType) Chain(id string) (*Endpoint, error) {
+	var first, last *Endpoint
+	path := []string{}
+	visited := map[string]bool{}
+	for len(id) > 0 {
+		path = append(path, id)
+		if visited[id] {
+			return nil, fmt.Errorf("bastion cycle [%s]", strings.Join(path, " → "))
+		}
+		visited[id] = true
+
+		e, ok := (*bm)[id]
+		if !ok {
+			return nil, fmt.Errorf("bastion [%s] is not found", id)
+		}
+		hop := e
+		hop.jump = nil
+		hop.client = nil
+		hop.agentConn = nil
+		if first == nil {
+			first = &hop
+		} else {
+			last.jump = &hop
+		}
+		last = &hop
+		id = e.bastionID
+	}
+	return first, nil
+}
+
+// checkChains removes the bastion hosts which are in a cycle or reference an unknown bastion host
+func (bm *BastionMapType) checkChains() {
+	for removed := true; removed; {
+		removed = false
+		keys := make([]string, 0, len(*bm))
+		for k := range *bm {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, err := bm.Chain(k); err != nil {
+				log.Errorf("Bastion Host error: [%s / %s] - %v", k, (*bm)[k].Host, err)
+				delete(*bm, k)
+				removed = true
+			}
+		}
+	}
+}
+
+// dial connects to the endpoint through its previous hops
+func (e *Endpoint) dial(kind, name string, timeout time.Duration) (*ssh.Client, error) {
+	e.client = nil
+
+	config, err := e.SSHConfig(kind, name, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] %w", e.Host, err)
+	}
+
+	if e.jump == nil {
+		client, err := ssh.Dial("tcp", e.Host, config)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] %w", e.Host, err)
+		}
+		e.client = client
+		return client, nil
+	}
+
+	jClient, err := e.jump.dial(kind, name, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := jClient.Dial("tcp", e.Host)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] %w", e.Host, err)
+	}
+	ncc, chans, reqs, err := ssh.NewClientConn(conn, e.Host, config)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] %w", e.Host, err)
+	}
+	e.client = ssh.NewClient(ncc, chans, reqs)
+	return e.client, nil
+}
+
+// closeChain closes the clients and the ssh-agent connections of the endpoint and its previous hops
+func (e *Endpoint) closeChain() {
+	for hop := e; hop != nil; hop = hop.jump {
+		if hop.client != nil {
+			hop.client.Close()
+			hop.client = nil
+		}
+		hop.CloseAgent()
+	}
+}
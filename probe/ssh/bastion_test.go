@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssh
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/monkey"
+	"github.com/megaease/easeprobe/probe/base"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+const bastionYAML = `
+bastion:
+  office:
+    host: ubuntu@office.example.com
+    password: office
+  region:
+    host: ubuntu@region.example.com:2222
+    password: region
+    bastion: office
+  dc:
+    host: dc.example.com
+    password: dc
+    bastion: region
+  loop1:
+    host: loop1.example.com
+    bastion: loop2
+  loop2:
+    host: loop2.example.com
+    bastion: loop1
+  orphan:
+    host: orphan.example.com
+    bastion: none
+  orphan-child:
+    host: orphan-child.example.com
+    bastion: orphan
+servers:
+  - name: production
+    host: ubuntu@10.0.0.1
+    password: server
+    bastion: dc
+    cmd: uptime
+`
+
+func TestBastionChain(t *testing.T) {
+	BastionMap = make(BastionMapType)
+	conf := SSH{Bastion: &BastionMap}
+	assert.Nil(t, yaml.Unmarshal([]byte(bastionYAML), &conf))
+	assert.Equal(t, 7, len(BastionMap))
+	assert.Equal(t, "office", BastionMap["region"].bastionID)
+	assert.Equal(t, "dc", conf.Servers[0].BastionID)
+
+	// the bastion hosts in the cycle or with the unknown previous hop are removed
+	BastionMap.ParseAllBastionHost()
+	assert.Equal(t, 3, len(BastionMap))
+	for _, k := range []string{"loop1", "loop2", "orphan", "orphan-child"} {
+		_, ok := BastionMap[k]
+		assert.False(t, ok, k)
+	}
+
+	out, err := yaml.Marshal(BastionMap)
+	assert.Nil(t, err)
+	assert.Contains(t, string(out), "bastion: region")
+
+	_, err = (&BastionMapType{
+		"a": Endpoint{bastionID: "b"},
+		"b": Endpoint{bastionID: "c"},
+		"c": Endpoint{bastionID: "a"},
+	}).Chain("a")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "bastion cycle [a → b → c → a]")
+
+	chain, err := BastionMap.Chain("dc")
+	assert.Nil(t, err)
+	hosts := []string{}
+	for hop := chain; hop != nil; hop = hop.jump {
+		hosts = append(hosts, hop.Host)
+	}
+	assert.Equal(t, []string{"dc.example.com:22", "region.example.com:2222", "office.example.com:22"}, hosts)
+	// every chain is a copy
+	another, _ := BastionMap.Chain("dc")
+	assert.NotSame(t, chain.jump, another.jump)
+
+	global.InitEaseProbe("EaseProbeTest", "none")
+	s := &conf.Servers[0]
+	s.DefaultProbe = base.DefaultProbe{ProbeName: "production"}
+	assert.Nil(t, s.Config(global.ProbeSettings{}))
+	assert.Equal(t, "dc.example.com:22", s.bastion.Host)
+	assert.Equal(t, "office.example.com:22", s.bastion.jump.jump.Host)
+
+	// connect through office → region → dc → server,
+	// the client wraps another client, so the patched Close is called by the Conn interface
+	newClient := func() *ssh.Client { return &ssh.Client{Conn: &ssh.Client{}} }
+	dialed := []string{}
+	monkey.Patch(ssh.Dial, func(network, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+		dialed = append(dialed, "ssh:"+addr)
+		return newClient(), nil
+	})
+	monkey.Patch(ssh.NewClient, func(c ssh.Conn, chans <-chan ssh.NewChannel, reqs <-chan *ssh.Request) *ssh.Client {
+		return newClient()
+	})
+	var client *ssh.Client
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "Dial", func(c *ssh.Client, n, a string) (net.Conn, error) {
+		dialed = append(dialed, "tcp:"+a)
+		return &net.TCPConn{}, nil
+	})
+	closed := 0
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "Close", func(c *ssh.Client) error {
+		closed++
+		return nil
+	})
+	monkey.Patch(ssh.NewClientConn, func(c net.Conn, addr string, config *ssh.ClientConfig) (ssh.Conn, <-chan ssh.NewChannel, <-chan *ssh.Request, error) {
+		dialed = append(dialed, "handshake:"+addr+"@"+config.User)
+		return &ssh.Client{}, nil, nil, nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "NewSession", func(c *ssh.Client) (*ssh.Session, error) {
+		return &ssh.Session{}, nil
+	})
+	var ss *ssh.Session
+	monkey.PatchInstanceMethod(reflect.TypeOf(ss), "Run", func(s *ssh.Session, cmd string) error {
+		return nil
+	})
+	monkey.PatchInstanceMethod(reflect.TypeOf(ss), "Close", func(c *ssh.Session) error {
+		return nil
+	})
+	defer monkey.UnpatchAll()
+
+	status, message := s.DoProbe()
+	assert.True(t, status, message)
+	assert.Equal(t, []string{
+		"ssh:office.example.com:22",
+		"tcp:region.example.com:2222", "handshake:region.example.com:2222@ubuntu",
+		"tcp:dc.example.com:22", "handshake:dc.example.com:22@",
+		"tcp:10.0.0.1:22", "handshake:10.0.0.1:22@ubuntu",
+	}, dialed)
+	// the server and all of the hops are closed
+	assert.Equal(t, 4, closed)
+	for hop := s.bastion; hop != nil; hop = hop.jump {
+		assert.Nil(t, hop.client)
+	}
+
+	// the failed hop is reported, and the connected hops are closed
+	closed = 0
+	monkey.PatchInstanceMethod(reflect.TypeOf(client), "Dial", func(c *ssh.Client, n, a string) (net.Conn, error) {
+		if a == "dc.example.com:22" {
+			return nil, net.ErrClosed
+		}
+		return &net.TCPConn{}, nil
+	})
+	status, message = s.DoProbe()
+	assert.False(t, status)
+	assert.Contains(t, message, "Bastion: [dc.example.com:22]")
+	assert.Equal(t, 2, closed)
+}
@@ -18,15 +18,25 @@
 package ssh
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 
 	"strings"
 	"time"
 
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
+// ErrHostKeyMismatch is the error of the host key which is different from the known or pinned one,
+// it could be a man-in-the-middle attack.
+var ErrHostKeyMismatch = errors.New("host key mismatch")
+
 // Endpoint is SSH Endpoint
 type Endpoint struct {
 	PrivateKey string      `yaml:"key" json:"key,omitempty" jsonschema:"title=Private Key,description=the private key file path for ssh login"`
@@ -35,6 +45,19 @@ type Endpoint struct {
 	User       string      `yaml:"username" json:"username,omitempty" jsonschema:"title=User,description=the username for ssh probe"`
 	Password   string      `yaml:"password" json:"password,omitempty" jsonschema:"title=Password,description=the password for ssh probe"`
 	client     *ssh.Client `yaml:"-" json:"-"`
+
+	// the previous hop of the bastion host
+	bastionID string    `yaml:"-" json:"-"`
+	jump      *Endpoint `yaml:"-" json:"-"`
+
+	// SSH Agent and Certificate Authentication
+	Agent       bool     `yaml:"agent,omitempty" json:"agent,omitempty" jsonschema:"title=SSH Agent,description=authenticate by the ssh-agent of the SSH_AUTH_SOCK environment variable,default=false"`
+	Certificate string   `yaml:"certificate,omitempty" json:"certificate,omitempty" jsonschema:"title=Certificate,description=the OpenSSH user certificate file signed by the CA,example=/path/to/id_ed25519-cert.pub"`
+	agentConn   net.Conn `yaml:"-" json:"-"`
+
+	// Host Key Verification
+	KnownHosts   string   `yaml:"known_hosts,omitempty" json:"known_hosts,omitempty" jsonschema:"title=Known Hosts,description=the known_hosts file to verify the host key,example=~/.ssh/known_hosts"`
+	Fingerprints []string `yaml:"fingerprints,omitempty" json:"fingerprints,omitempty" jsonschema:"title=Host Key Fingerprints,description=the pinned fingerprints of the host key,example=SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"`
 }
 
 // ParseHost check the host is configured the port or not
@@ -73,6 +96,7 @@ func (e *Endpoint) SSHConfig(kind, name string, timeout time.Duration) (*ssh.Cli
 		Auth = append(Auth, ssh.Password(e.Password))
 	}
 
+	var signers []ssh.Signer
 	if len(e.PrivateKey) > 0 {
 		key, err := os.ReadFile(e.PrivateKey)
 		if err != nil {
@@ -92,15 +116,241 @@ func (e *Endpoint) SSHConfig(kind, name string, timeout time.Duration) (*ssh.Cli
 			return nil, err
 		}
 
-		Auth = append(Auth, ssh.PublicKeys(signer))
+		signers = append(signers, signer)
+	}
+
+	if e.Agent {
+		agentSigners, err := e.agentSigners()
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, agentSigners...)
+	}
+
+	if len(e.Certificate) > 0 {
+		var err error
+		if signers, err = e.certSigners(signers); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(signers) > 0 {
+		Auth = append(Auth, ssh.PublicKeys(signers...))
+	}
+
+	hostKeyCallback, err := e.HostKeyCallback()
+	if err != nil {
+		return nil, err
 	}
 
 	config := &ssh.ClientConfig{
-		User:            e.User,
-		Auth:            Auth,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         timeout,
+		User:              e.User,
+		Auth:              Auth,
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: e.HostKeyAlgorithms(),
+		Timeout:           timeout,
 	}
 
 	return config, nil
 }
+
+// agentSigners returns the signers of the ssh-agent, the agent connection is kept until CloseAgent is called,
+// because the agent signs the authentication during the handshake.
+func (e *Endpoint) agentSigners() ([]ssh.Signer, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if len(socket) <= 0 {
+		return nil, fmt.Errorf("ssh agent: SSH_AUTH_SOCK is not set")
+	}
+	e.CloseAgent()
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("ssh agent: %w", err)
+	}
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh agent: %w", err)
+	}
+	e.agentConn = conn
+	return signers, nil
+}
+
+// CloseAgent closes the connection of the ssh-agent
+func (e *Endpoint) CloseAgent() {
+	if e.agentConn != nil {
+		e.agentConn.Close()
+		e.agentConn = nil
+	}
+}
+
+// certSigners loads the user certificate, and puts the certificate signer of the matched private key in front of the signers.
+// The certificate file is read every time, so the renewed short-lived certificate is used.
+func (e *Endpoint) certSigners(signers []ssh.Signer) ([]ssh.Signer, error) {
+	data, err := os.ReadFile(e.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("certificate: %w", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("certificate: %s is not a certificate", e.Certificate)
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("certificate: %s is not a user certificate", e.Certificate)
+	}
+
+	now := uint64(time.Now().Unix())
+	if now < cert.ValidAfter {
+		return nil, fmt.Errorf("certificate: %s is not valid before %s",
+			e.Certificate, time.Unix(int64(cert.ValidAfter), 0).UTC().Format(time.RFC3339))
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity && now >= cert.ValidBefore {
+		return nil, fmt.Errorf("certificate: %s is expired at %s",
+			e.Certificate, time.Unix(int64(cert.ValidBefore), 0).UTC().Format(time.RFC3339))
+	}
+
+	for _, s := range signers {
+		if !bytes.Equal(s.PublicKey().Marshal(), cert.Key.Marshal()) {
+			continue
+		}
+		certSigner, err := ssh.NewCertSigner(cert, s)
+		if err != nil {
+			return nil, fmt.Errorf("certificate: %w", err)
+		}
+		return append([]ssh.Signer{certSigner}, signers...), nil
+	}
+	return nil, fmt.Errorf("certificate: no private key matches %s", e.Certificate)
+}
+
+// HostKeyCallback returns the callback to verify the host key by the known_hosts file and the pinned fingerprints.
+// The host key is not verified if neither of them is configured.
+func (e *Endpoint) HostKeyCallback() (ssh.HostKeyCallback, error) {
+	if len(e.KnownHosts) <= 0 && len(e.Fingerprints) <= 0 {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	var known ssh.HostKeyCallback
+	if len(e.KnownHosts) > 0 {
+		cb, err := e.knownHosts()
+		if err != nil {
+			return nil, err
+		}
+		known = cb
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		fingerprint := ssh.FingerprintSHA256(key)
+		if len(e.Fingerprints) > 0 && !e.pinned(key) {
+			return fmt.Errorf("%w: %s presented the %s key %s, which is not pinned",
+				ErrHostKeyMismatch, hostname, key.Type(), fingerprint)
+		}
+		if known == nil {
+			return nil
+		}
+
+		err := known(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		// the host is known, but the key of the same type is different
+		types := []string{}
+		for _, w := range keyErr.Want {
+			if w.Key.Type() == key.Type() {
+				return fmt.Errorf("%w: %s presented the %s key %s, which is different from %s:%d",
+					ErrHostKeyMismatch, hostname, key.Type(), fingerprint, w.Filename, w.Line)
+			}
+			types = append(types, w.Key.Type())
+		}
+		if len(types) > 0 {
+			return fmt.Errorf("%s has no %s key in the known hosts, it presented the %s key %s, the known key types are %v",
+				hostname, key.Type(), key.Type(), fingerprint, types)
+		}
+		return fmt.Errorf("%s is not in the known hosts, it presented the %s key %s", hostname, key.Type(), fingerprint)
+	}, nil
+}
+
+// knownHosts returns the callback of the known_hosts file
+func (e *Endpoint) knownHosts() (ssh.HostKeyCallback, error) {
+	cb, err := knownhosts.New(expandHome(e.KnownHosts))
+	if err != nil {
+		return nil, fmt.Errorf("known_hosts: %w", err)
+	}
+	return cb, nil
+}
+
+// noKey is the public key which matches nothing, it is used to list the known keys of the host
+type noKey struct{}
+
+func (noKey) Type() string                            { return "" }
+func (noKey) Marshal() []byte                         { return nil }
+func (noKey) Verify(_ []byte, _ *ssh.Signature) error { return fmt.Errorf("no key") }
+
+// HostKeyAlgorithms returns the host key algorithms of the keys recorded in the known_hosts file for the host,
+// so the server presents the key which could be verified, as OpenSSH does.
+// It returns nil (the default algorithms) if the host is not in the known_hosts file.
+func (e *Endpoint) HostKeyAlgorithms() []string {
+	if len(e.KnownHosts) <= 0 {
+		return nil
+	}
+	known, err := e.knownHosts()
+	if err != nil {
+		return nil
+	}
+	var keyErr *knownhosts.KeyError
+	if !errors.As(known(e.Host, &net.TCPAddr{IP: net.IPv4zero}, noKey{}), &keyErr) {
+		return nil
+	}
+
+	algorithms := []string{}
+	seen := map[string]bool{}
+	for _, w := range keyErr.Want {
+		algos := []string{w.Key.Type()}
+		// the RSA key could be signed by the SHA-2 algorithms
+		if w.Key.Type() == ssh.KeyAlgoRSA {
+			algos = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+		}
+		for _, a := range algos {
+			if !seen[a] {
+				seen[a] = true
+				algorithms = append(algorithms, a)
+			}
+		}
+	}
+	if len(algorithms) <= 0 {
+		return nil
+	}
+	return algorithms
+}
+
+// pinned returns true if the key matches one of the pinned fingerprints,
+// the fingerprint could be the SHA256 format (SHA256:...) or the legacy MD5 format (MD5:xx:xx:... or xx:xx:...)
+func (e *Endpoint) pinned(key ssh.PublicKey) bool {
+	sha256 := ssh.FingerprintSHA256(key)
+	md5 := ssh.FingerprintLegacyMD5(key)
+	for _, f := range e.Fingerprints {
+		f = strings.TrimSpace(f)
+		if strings.HasPrefix(f, "SHA256:") && strings.TrimRight(f, "=") == sha256 {
+			return true
+		}
+		if strings.EqualFold(strings.TrimPrefix(f, "MD5:"), md5) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandHome replaces the leading ~/ with the home directory of the user
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
@@ -18,12 +18,25 @@
 package ssh
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/megaease/easeprobe/monkey"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 func check(t *testing.T, fname string, err error, result, expected string) {
@@ -119,3 +132,276 @@ DX4y9QvXoaUiQ5vB63voiqRTBT4hTYBDVi2G7NEjIczNs9S8JQM5Mg52mZsdH77g6ChUPp
 	assert.Nil(t, config)
 	assert.NotNil(t, err)
 }
+
+func newHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	key, err := ssh.NewPublicKey(pub)
+	assert.Nil(t, err)
+	return key
+}
+
+func TestHostKeyAlgorithms(t *testing.T) {
+	ed25519Key := newHostKey(t)
+	ecdsaPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	ecdsaKey, err := ssh.NewPublicKey(&ecdsaPriv.PublicKey)
+	assert.Nil(t, err)
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+	rsaKey, err := ssh.NewPublicKey(&rsaPriv.PublicKey)
+	assert.Nil(t, err)
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+
+	// the known_hosts only holds the ecdsa and rsa keys of the host
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	lines := knownhosts.Line([]string{"example.com"}, ecdsaKey) + "\n" +
+		knownhosts.Line([]string{"example.com"}, rsaKey) + "\n" +
+		knownhosts.Line([]string{"other.com"}, ed25519Key) + "\n"
+	assert.Nil(t, os.WriteFile(file, []byte(lines), 0600))
+
+	e := Endpoint{Host: "example.com:22", KnownHosts: file}
+	assert.Equal(t, []string{ssh.KeyAlgoECDSA256, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA},
+		e.HostKeyAlgorithms())
+	config, err := e.SSHConfig("ssh", "test", time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, e.HostKeyAlgorithms(), config.HostKeyAlgorithms)
+
+	// the server presents a key type which is not known, it's not a mismatch
+	cb, err := e.HostKeyCallback()
+	assert.Nil(t, err)
+	err = cb("example.com:22", addr, ed25519Key)
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrHostKeyMismatch))
+	assert.Contains(t, err.Error(), "example.com:22 has no ssh-ed25519 key in the known hosts")
+	assert.Nil(t, cb("example.com:22", addr, ecdsaKey))
+	assert.Nil(t, cb("example.com:22", addr, rsaKey))
+
+	// the key of the same type is different
+	otherPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	otherKey, err := ssh.NewPublicKey(&otherPriv.PublicKey)
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(cb("example.com:22", addr, otherKey), ErrHostKeyMismatch))
+
+	// the default algorithms are used if the host is unknown
+	e = Endpoint{Host: "unknown.com:22", KnownHosts: file}
+	assert.Nil(t, e.HostKeyAlgorithms())
+	e = Endpoint{Host: "other.com:22", KnownHosts: file}
+	assert.Equal(t, []string{ssh.KeyAlgoED25519}, e.HostKeyAlgorithms())
+	e = Endpoint{Host: "example.com:22"}
+	assert.Nil(t, e.HostKeyAlgorithms())
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	key := newHostKey(t)
+	other := newHostKey(t)
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+
+	// no verification
+	e := Endpoint{Host: "example.com:22"}
+	cb, err := e.HostKeyCallback()
+	assert.Nil(t, err)
+	assert.Nil(t, cb("example.com:22", addr, other))
+
+	// pinned fingerprints
+	e.Fingerprints = []string{ssh.FingerprintSHA256(key)}
+	cb, err = e.HostKeyCallback()
+	assert.Nil(t, err)
+	assert.Nil(t, cb("example.com:22", addr, key))
+	err = cb("example.com:22", addr, other)
+	assert.True(t, errors.Is(err, ErrHostKeyMismatch))
+	assert.Contains(t, err.Error(), "which is not pinned")
+
+	e.Fingerprints = []string{"SHA256:unknown", strings.ToUpper(ssh.FingerprintLegacyMD5(key))}
+	cb, err = e.HostKeyCallback()
+	assert.Nil(t, err)
+	assert.Nil(t, cb("example.com:22", addr, key))
+	e.Fingerprints = []string{"MD5:" + ssh.FingerprintLegacyMD5(key)}
+	cb, _ = e.HostKeyCallback()
+	assert.Nil(t, cb("example.com:22", addr, key))
+
+	// known_hosts
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{"example.com", "[bastion.com]:2222"}, key)
+	assert.Nil(t, os.WriteFile(file, []byte(line+"\n"), 0600))
+
+	e = Endpoint{Host: "example.com:22", KnownHosts: file}
+	cb, err = e.HostKeyCallback()
+	assert.Nil(t, err)
+	assert.Nil(t, cb("example.com:22", addr, key))
+	assert.Nil(t, cb("bastion.com:2222", addr, key))
+
+	err = cb("example.com:22", addr, other)
+	assert.True(t, errors.Is(err, ErrHostKeyMismatch))
+	assert.Contains(t, err.Error(), "which is different from "+file+":1")
+
+	err = cb("unknown.com:22", addr, key)
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrHostKeyMismatch))
+	assert.Contains(t, err.Error(), "is not in the known hosts")
+
+	// both of them must be passed
+	e.Fingerprints = []string{ssh.FingerprintSHA256(other)}
+	cb, err = e.HostKeyCallback()
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(cb("example.com:22", addr, key), ErrHostKeyMismatch))
+
+	// the known_hosts file doesn't exist
+	e = Endpoint{Host: "example.com:22", KnownHosts: filepath.Join(t.TempDir(), "none")}
+	_, err = e.HostKeyCallback()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "known_hosts")
+	config, err := e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.Nil(t, config)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "/etc/ssh/known_hosts", expandHome("/etc/ssh/known_hosts"))
+	home, _ := os.UserHomeDir()
+	assert.Equal(t, filepath.Join(home, ".ssh", "known_hosts"), expandHome("~/.ssh/known_hosts"))
+}
+
+// writeCert signs the user certificate of the key by the CA, and writes it to the file
+func writeCert(t *testing.T, file string, key ssh.PublicKey, certType uint32, after, before time.Time) {
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	ca, err := ssh.NewSignerFromKey(caKey)
+	assert.Nil(t, err)
+
+	cert := &ssh.Certificate{
+		Key:             key,
+		CertType:        certType,
+		KeyId:           "easeprobe",
+		ValidPrincipals: []string{"ubuntu"},
+		ValidAfter:      uint64(after.Unix()),
+		ValidBefore:     uint64(before.Unix()),
+	}
+	assert.Nil(t, cert.SignCert(rand.Reader, ca))
+	assert.Nil(t, os.WriteFile(file, ssh.MarshalAuthorizedKey(cert), 0600))
+}
+
+func TestCertificate(t *testing.T) {
+	monkey.UnpatchAll()
+	dir := t.TempDir()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	assert.Nil(t, err)
+	keyFile := filepath.Join(dir, "id_ed25519")
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600))
+	signer, err := ssh.NewSignerFromKey(priv)
+	assert.Nil(t, err)
+
+	certFile := filepath.Join(dir, "id_ed25519-cert.pub")
+	now := time.Now()
+	writeCert(t, certFile, signer.PublicKey(), ssh.UserCert, now.Add(-time.Minute), now.Add(time.Hour))
+
+	e := Endpoint{Host: "example.com:22", User: "ubuntu", PrivateKey: keyFile, Certificate: certFile}
+	config, err := e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(config.Auth))
+
+	signers, err := e.certSigners([]ssh.Signer{signer})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(signers))
+	_, ok := signers[0].PublicKey().(*ssh.Certificate)
+	assert.True(t, ok)
+
+	// no private key matches the certificate
+	other := newHostKey(t)
+	writeCert(t, certFile, other, ssh.UserCert, now.Add(-time.Minute), now.Add(time.Hour))
+	_, err = e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no private key matches")
+
+	// expired
+	writeCert(t, certFile, signer.PublicKey(), ssh.UserCert, now.Add(-time.Hour), now.Add(-time.Minute))
+	_, err = e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is expired at")
+
+	// not valid yet
+	writeCert(t, certFile, signer.PublicKey(), ssh.UserCert, now.Add(time.Hour), now.Add(2*time.Hour))
+	_, err = e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is not valid before")
+
+	// host certificate
+	writeCert(t, certFile, signer.PublicKey(), ssh.HostCert, now.Add(-time.Minute), now.Add(time.Hour))
+	_, err = e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is not a user certificate")
+
+	// not a certificate
+	assert.Nil(t, os.WriteFile(certFile, ssh.MarshalAuthorizedKey(signer.PublicKey()), 0600))
+	_, err = e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is not a certificate")
+
+	e.Certificate = filepath.Join(dir, "none")
+	_, err = e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.NotNil(t, err)
+}
+
+func TestAgent(t *testing.T) {
+	monkey.UnpatchAll()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	keyring := agent.NewKeyring()
+	assert.Nil(t, keyring.Add(agent.AddedKey{PrivateKey: priv}))
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", socket)
+	assert.Nil(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				agent.ServeAgent(keyring, c)
+			}()
+		}
+	}()
+
+	e := Endpoint{Host: "example.com:22", User: "ubuntu", Agent: true}
+
+	t.Setenv("SSH_AUTH_SOCK", "")
+	_, err = e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "SSH_AUTH_SOCK is not set")
+
+	t.Setenv("SSH_AUTH_SOCK", filepath.Join(t.TempDir(), "none.sock"))
+	_, err = e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "ssh agent")
+
+	t.Setenv("SSH_AUTH_SOCK", socket)
+	config, err := e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(config.Auth))
+	assert.NotNil(t, e.agentConn)
+
+	signers, err := e.agentSigners()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(signers))
+	sig, err := signers[0].Sign(rand.Reader, []byte("data"))
+	assert.Nil(t, err)
+	assert.Nil(t, signers[0].PublicKey().Verify([]byte("data"), sig))
+
+	// the certificate of the agent key
+	certFile := filepath.Join(t.TempDir(), "cert.pub")
+	now := time.Now()
+	writeCert(t, certFile, signers[0].PublicKey(), ssh.UserCert, now.Add(-time.Minute), now.Add(time.Hour))
+	e.Certificate = certFile
+	_, err = e.SSHConfig("ssh", "test", 30*time.Second)
+	assert.Nil(t, err)
+
+	e.CloseAgent()
+	assert.Nil(t, e.agentConn)
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/megaease/easeprobe/global"
+)
+
+// LocalHost is the host name which means the command runs on the machine EaseProbe runs on, no SSH is needed.
+const LocalHost = "local"
+
+// IsLocal returns true if the host is the local host
+func (s *Server) IsLocal() bool {
+	return s.Host == LocalHost
+}
+
+// RunLocalCmd run the command on the local host by the shell
+func (s *Server) RunLocalCmd() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", global.CommandLine(s.Command, s.Args))
+	cmd.Env = append(os.Environ(), s.Env...)
+	var stdoutBuf bytes.Buffer
+	cmd.Stdout = &stdoutBuf
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Sprintf("timeout after %s", s.Timeout()), ctx.Err()
+	}
+	return stdoutBuf.String(), err
+}
+
+// RunCmd run the command on the local host or the remote host by SSH
+func (s *Server) RunCmd() (string, error) {
+	if s.IsLocal() {
+		return s.RunLocalCmd()
+	}
+	return s.RunSSHCmd()
+}
@@ -21,6 +21,7 @@ package ssh
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 
@@ -81,6 +82,7 @@ func (bm *BastionMapType) ParseAllBastionHost() {
 		}
 		(*bm)[k] = v
 	}
+	bm.checkChains()
 }
 
 // Config SSH Config Object
@@ -104,16 +106,16 @@ func (s *Server) Configure(gConf global.ProbeSettings,
 
 	s.DefaultProbe.Config(gConf, kind, tag, name, endpoint, fn)
 
-	if len(s.Password) <= 0 && len(s.PrivateKey) <= 0 {
-		return fmt.Errorf("password or private key is required")
+	if len(s.Password) <= 0 && len(s.PrivateKey) <= 0 && !s.Agent {
+		return fmt.Errorf("password, private key or ssh agent is required")
 	}
 
 	if len(s.BastionID) > 0 {
-		if bastion, ok := (*bastionMap)[s.BastionID]; ok {
+		if bastion, err := bastionMap.Chain(s.BastionID); err == nil {
 			log.Debugf("[%s / %s] - has the bastion [%s]", s.ProbeKind, s.ProbeName, bastion.Host)
-			s.bastion = &bastion
+			s.bastion = bastion
 		} else {
-			log.Warnf("[%s / %s] - wrong bastion [%s]", s.ProbeKind, s.ProbeName, s.BastionID)
+			log.Warnf("[%s / %s] - wrong bastion [%s] - %v", s.ProbeKind, s.ProbeName, s.BastionID, err)
 		}
 	}
 
@@ -150,6 +152,9 @@ func (s *Server) DoProbe() (bool, string) {
 		log.Errorf("[%s / %s] %v", s.ProbeKind, s.ProbeName, err)
 		status = false
 		message = err.Error() + " - " + output
+		if errors.Is(err, ErrHostKeyMismatch) {
+			message = "Error: Host Key Mismatch! " + err.Error()
+		}
 	} else {
 		log.Debugf("[%s / %s] - %s", s.ProbeKind, s.ProbeName, s.TextChecker.String())
 		if err := s.Check(string(output)); err != nil {
@@ -192,28 +197,22 @@ func (s *Server) GetSSHClient() error {
 	return nil
 }
 
-// GetSSHClientFromBastion returns a ssh.Client via bastion server
+// GetSSHClientFromBastion returns a ssh.Client via bastion server, the bastion server could be reached through other bastion servers
 func (s *Server) GetSSHClientFromBastion() error {
-	bConfig, err := s.bastion.SSHConfig(s.ProbeKind, s.ProbeName, s.Timeout())
+	bClient, err := s.bastion.dial(s.ProbeKind, s.ProbeName, s.Timeout())
 	if err != nil {
-		return fmt.Errorf("Bastion: %s", err)
+		return fmt.Errorf("Bastion: %w", err)
 	}
 
-	bClient, err := ssh.Dial("tcp", s.bastion.Host, bConfig)
-	if err != nil {
-		return fmt.Errorf("Bastion: %s", err)
-	}
-	s.bastion.client = bClient
-
 	config, err := s.Endpoint.SSHConfig(s.ProbeKind, s.ProbeName, s.Timeout())
 	if err != nil {
-		return fmt.Errorf("Server: %s", err)
+		return fmt.Errorf("Server: %w", err)
 	}
 
 	// Connect to the remote server and perform the SSH handshake.
 	conn, err := bClient.Dial("tcp", s.Host)
 	if err != nil {
-		return fmt.Errorf("Server: %s", err)
+		return fmt.Errorf("Server: %w", err)
 	}
 
 	if tcpConn, ok := conn.(*net.TCPConn); ok && !s.NoLinger {
@@ -222,7 +221,7 @@ func (s *Server) GetSSHClientFromBastion() error {
 
 	ncc, chans, reqs, err := ssh.NewClientConn(conn, s.Host, config)
 	if err != nil {
-		return fmt.Errorf("Server: %s", err)
+		return fmt.Errorf("Server: %w", err)
 	}
 
 	s.client = ssh.NewClient(ncc, chans, reqs)
@@ -232,11 +231,14 @@ func (s *Server) GetSSHClientFromBastion() error {
 // RunSSHCmd run ssh command
 func (s *Server) RunSSHCmd() (string, error) {
 
+	// the ssh-agent connection is only needed during the handshake
+	defer s.CloseAgent()
+
 	if s.bastion != nil && len(s.bastion.Host) > 0 {
+		defer s.bastion.closeChain()
 		if err := s.GetSSHClientFromBastion(); err != nil {
 			return "", err
 		}
-		defer s.bastion.client.Close()
 	} else {
 		if err := s.GetSSHClient(); err != nil {
 			return "", err
@@ -19,6 +19,7 @@ package ssh
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"os"
 	"reflect"
@@ -287,6 +288,12 @@ YWwBAg==
 	})
 	checkServer(false, "ssh Dial failed")
 
+	// host key mismatch is reported as its own failure reason
+	monkey.Patch(ssh.Dial, func(network, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+		return nil, fmt.Errorf("ssh: handshake failed: %w", ErrHostKeyMismatch)
+	})
+	checkServer(false, "Error: Host Key Mismatch!")
+
 	var s *Server
 	monkey.PatchInstanceMethod(reflect.TypeOf(s), "RunSSHCmd", func(s *Server) (string, error) {
 		if s.bastion != nil {
@@ -33,35 +33,57 @@ const (
 	StatusDown
 	StatusUnknown
 	StatusBad
+	StatusDegraded
+	StatusUnreachable
+	StatusFlapping
 )
 
 var (
 	toTitle = map[Status]string{
-		StatusInit:    "Initialization",
-		StatusUp:      "Success",
-		StatusDown:    "Error",
-		StatusUnknown: "Unknown",
-		StatusBad:     "Bad",
+		StatusInit:        "Initialization",
+		StatusUp:          "Success",
+		StatusDown:        "Error",
+		StatusUnknown:     "Unknown",
+		StatusBad:         "Bad",
+		StatusDegraded:    "Degraded",
+		StatusUnreachable: "Unreachable",
+		StatusFlapping:    "Flapping",
 	}
 	toString = map[Status]string{
-		StatusInit:    "init",
-		StatusUp:      "up",
-		StatusDown:    "down",
-		StatusUnknown: "unknown",
-		StatusBad:     "bad",
+		StatusInit:        "init",
+		StatusUp:          "up",
+		StatusDown:        "down",
+		StatusUnknown:     "unknown",
+		StatusBad:         "bad",
+		StatusDegraded:    "degraded",
+		StatusUnreachable: "unreachable",
+		StatusFlapping:    "flapping",
 	}
 
 	toStatus = global.ReverseMap(toString)
 
 	toEmoji = map[Status]string{
-		StatusInit:    "🔎",
-		StatusUp:      "✅",
-		StatusDown:    "❌",
-		StatusUnknown: "⛔️",
-		StatusBad:     "🚫",
+		StatusInit:        "🔎",
+		StatusUp:          "✅",
+		StatusDown:        "❌",
+		StatusUnknown:     "⛔️",
+		StatusBad:         "🚫",
+		StatusDegraded:    "⚠️",
+		StatusUnreachable: "🔌",
+		StatusFlapping:    "🔀",
 	}
 )
 
+// IsAvailable returns true if the service is available (up or degraded)
+func (s Status) IsAvailable() bool {
+	return s == StatusUp || s == StatusDegraded
+}
+
+// IsDown returns true if the service is not working (down, unknown or unreachable)
+func (s Status) IsDown() bool {
+	return s == StatusDown || s == StatusUnknown || s == StatusUnreachable
+}
+
 // Title convert the Status to title
 func (s Status) Title() string {
 	if val, ok := toTitle[s]; ok {
@@ -66,6 +66,21 @@ func (s *StatusCounter) AppendStatus(status bool, message string) {
 	}
 }
 
+// StatusChanges returns the number of the status changes in the latest n status history
+func (s *StatusCounter) StatusChanges(n int) int {
+	h := s.StatusHistory
+	if n > 0 && len(h) > n {
+		h = h[len(h)-n:]
+	}
+	changes := 0
+	for i := 1; i < len(h); i++ {
+		if h[i].Status != h[i-1].Status {
+			changes++
+		}
+	}
+	return changes
+}
+
 // SetMaxLen sets the max length of the status history
 func (s *StatusCounter) SetMaxLen(maxLen int) {
 	s.MaxLen = maxLen
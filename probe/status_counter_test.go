@@ -57,3 +57,20 @@ func TestNewStatusCounter(t *testing.T) {
 	assert.Equal(t, 2, s1.MaxLen)
 	assert.Equal(t, 2, len(s1.StatusHistory))
 }
+
+func TestStatusChanges(t *testing.T) {
+	s := NewStatusCounter(6)
+	assert.Equal(t, 0, s.StatusChanges(6))
+
+	for _, status := range []bool{true, false, true, true, false, true} {
+		s.AppendStatus(status, "")
+	}
+	assert.Equal(t, 4, s.StatusChanges(6))
+	assert.Equal(t, 4, s.StatusChanges(0))
+	assert.Equal(t, 4, s.StatusChanges(10))
+	assert.Equal(t, 2, s.StatusChanges(3))
+	assert.Equal(t, 0, s.StatusChanges(1))
+
+	s.AppendStatus(true, "")
+	assert.Equal(t, 3, s.StatusChanges(6))
+}
@@ -66,6 +66,9 @@ func TestYamlJSON(t *testing.T) {
 	testYamlJSON(t, "down", StatusDown, true)
 	testYamlJSON(t, "unknown", StatusUnknown, true)
 	testYamlJSON(t, "bad", StatusBad, true)
+	testYamlJSON(t, "degraded", StatusDegraded, true)
+	testYamlJSON(t, "unreachable", StatusUnreachable, true)
+	testYamlJSON(t, "flapping", StatusFlapping, true)
 
 	testYamlJSON(t, "xxx", 10, false)
 
@@ -123,6 +126,38 @@ func TestStatusTitle(t *testing.T) {
 	s = StatusBad
 	assert.Equal(t, "Bad", s.Title())
 
+	s = StatusDegraded
+	assert.Equal(t, "Degraded", s.Title())
+	assert.Equal(t, "⚠️", s.Emoji())
+
+	s = StatusUnreachable
+	assert.Equal(t, "Unreachable", s.Title())
+	assert.Equal(t, "🔌", s.Emoji())
+
+	s = StatusFlapping
+	assert.Equal(t, "Flapping", s.Title())
+	assert.Equal(t, "🔀", s.Emoji())
+
 	s = -1
 	assert.Equal(t, "Unknown", s.Title())
 }
+
+func TestStatusIsAvailable(t *testing.T) {
+	assert.True(t, StatusUp.IsAvailable())
+	assert.True(t, StatusDegraded.IsAvailable())
+	assert.False(t, StatusInit.IsAvailable())
+	assert.False(t, StatusDown.IsAvailable())
+	assert.False(t, StatusUnknown.IsAvailable())
+	assert.False(t, StatusBad.IsAvailable())
+	assert.False(t, StatusUnreachable.IsAvailable())
+}
+
+func TestStatusIsDown(t *testing.T) {
+	assert.True(t, StatusDown.IsDown())
+	assert.True(t, StatusUnknown.IsDown())
+	assert.True(t, StatusUnreachable.IsDown())
+	assert.False(t, StatusInit.IsDown())
+	assert.False(t, StatusUp.IsDown())
+	assert.False(t, StatusDegraded.IsDown())
+	assert.False(t, StatusBad.IsDown())
+}
@@ -19,20 +19,48 @@
 package tcp
 
 import (
+	"bytes"
 	"fmt"
 	"net"
+	"os"
+	"time"
 
 	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/probe"
 	"github.com/megaease/easeprobe/probe/base"
 	log "github.com/sirupsen/logrus"
 )
 
+// Step is a send/expect step of the TCP conversation
+type Step struct {
+	Send    string        `yaml:"send,omitempty" json:"send,omitempty" jsonschema:"title=Send,description=The text to send"`
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty" jsonschema:"type=string,format=duration,title=Timeout,description=The timeout of the step (default is the probe timeout)"`
+
+	// Expected Text Checker
+	probe.TextChecker `yaml:",inline"`
+}
+
+// expect returns true if the step needs to read the reply
+func (s *Step) expect() bool {
+	return len(s.Contain) > 0 || len(s.NotContain) > 0
+}
+
+// consume returns the rest of the reply after the match of the step
+func (s *Step) consume(reply []byte) []byte {
+	end := s.MatchEnd(string(reply))
+	if end < 0 {
+		end = len(reply)
+	}
+	return reply[end:]
+}
+
 // TCP implements a config for TCP
 type TCP struct {
 	base.DefaultProbe `yaml:",inline"`
 	Host              string `yaml:"host" json:"host" jsonschema:"required,format=hostname,title=Host,description=The host to probe"`
 	Proxy             string `yaml:"proxy" json:"proxy,omitempty" jsonschema:"format=hostname,title=Proxy,description=The proxy to use"`
 	NoLinger          bool   `yaml:"nolinger" json:"nolinger" jsonschema:"format=nolinger,title=Disable SO_LINGER,description=Disable SO_LINGER TCP flag, default=false"`
+	Steps             []Step `yaml:"steps,omitempty" json:"steps,omitempty" jsonschema:"title=Steps,description=The send/expect steps after the connection is established"`
 }
 
 // Config HTTP Config Object
@@ -42,6 +70,13 @@ func (t *TCP) Config(gConf global.ProbeSettings) error {
 	name := t.ProbeName
 	t.DefaultProbe.Config(gConf, kind, tag, name, t.Host, t.DoProbe)
 
+	for i := range t.Steps {
+		if err := t.Steps[i].TextChecker.Config(); err != nil {
+			log.Errorf("[%s / %s] step #%d - %v", t.ProbeKind, t.ProbeName, i+1, err)
+			return fmt.Errorf("step #%d - %v", i+1, err)
+		}
+	}
+
 	log.Debugf("[%s / %s] configuration: %+v", t.ProbeKind, t.ProbeName, *t)
 	return nil
 }
@@ -61,6 +96,68 @@ func (t *TCP) DoProbe() (bool, string) {
 			tcpCon.SetLinger(0)
 		}
 		defer conn.Close()
+
+		// the received data which is not consumed by the previous steps
+		var pending []byte
+		for i := range t.Steps {
+			if pending, err = t.DoStep(conn, &t.Steps[i], pending); err != nil {
+				message = fmt.Sprintf("Error: step #%d - %v", i+1, err)
+				log.Errorf("[%s / %s] step #%d error: %v", t.ProbeKind, t.ProbeName, i+1, err)
+				return false, message
+			}
+		}
+		if len(t.Steps) > 0 {
+			message = fmt.Sprintf("TCP Connection Established and %d Step(s) Passed Successfully!", len(t.Steps))
+		}
 	}
 	return status, message
 }
+
+// DoStep sends the text of the step, then reads the reply until
+// it passes the checker, or the step times out, or the connection is closed.
+// The pending is the data received by the previous steps, it's checked before reading unless it's blank,
+// and the data after the match is returned for the next step.
+func (t *TCP) DoStep(conn net.Conn, step *Step, pending []byte) ([]byte, error) {
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = t.Timeout()
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	if len(step.Send) > 0 {
+		if _, err := conn.Write([]byte(step.Send)); err != nil {
+			return nil, err
+		}
+	}
+	if !step.expect() {
+		return pending, nil
+	}
+
+	reply := pending
+	if len(bytes.TrimSpace(reply)) > 0 && step.Check(string(reply)) == nil {
+		return step.consume(reply), nil
+	}
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		reply = append(reply, buf[:n]...)
+		if n > 0 {
+			log.Debugf("[%s / %s] - reply: %s", t.ProbeKind, t.ProbeName, string(reply))
+		}
+		checkErr := step.Check(string(reply))
+		if n > 0 && checkErr == nil {
+			return step.consume(reply), nil
+		}
+		if err != nil {
+			if len(bytes.TrimSpace(reply)) > 0 && checkErr != nil {
+				return nil, checkErr
+			}
+			if os.IsTimeout(err) {
+				return nil, fmt.Errorf("no reply in %s", timeout)
+			}
+			return nil, err
+		}
+	}
+}
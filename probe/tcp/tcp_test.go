@@ -21,11 +21,13 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/megaease/easeprobe/global"
 	"github.com/megaease/easeprobe/monkey"
+	"github.com/megaease/easeprobe/probe"
 	"github.com/megaease/easeprobe/probe/base"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/proxy"
@@ -96,3 +98,124 @@ func TestTCPProxy(t *testing.T) {
 
 	monkey.UnpatchAll()
 }
+
+// startSMTPServer starts a fake SMTP server which replies the banner and the EHLO command
+func startSMTPServer(t *testing.T) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				// the banner is sent in two segments
+				c.Write([]byte("220 smtp.example.com "))
+				time.Sleep(50 * time.Millisecond)
+				c.Write([]byte("ESMTP ready\r\n"))
+				buf := make([]byte, 1024)
+				for {
+					n, err := c.Read(buf)
+					if err != nil {
+						return
+					}
+					switch {
+					case strings.HasPrefix(string(buf[:n]), "EHLO"):
+						c.Write([]byte("250-smtp.example.com\r\n250 SIZE 10240000\r\n"))
+					case strings.HasPrefix(string(buf[:n]), "QUIT"):
+						c.Write([]byte("221 Bye\r\n"))
+						return
+					case strings.HasPrefix(string(buf[:n]), "PIPE"):
+						// the replies of two steps in one write
+						c.Write([]byte("250 first\r\n250 second\r\n"))
+					case strings.HasPrefix(string(buf[:n]), "HANG"):
+						// never reply
+					default:
+						c.Write([]byte("502 Command not implemented\r\n"))
+					}
+				}
+			}(conn)
+		}
+	}()
+	return l.Addr().String(), func() { l.Close() }
+}
+
+func TestTCPSteps(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, stop := startSMTPServer(t)
+	defer stop()
+
+	tcp := TCP{
+		DefaultProbe: base.DefaultProbe{ProbeName: "dummy tcp", ProbeTimeout: time.Second},
+		Host:         addr,
+		Steps: []Step{
+			{TextChecker: probe.TextChecker{Contain: "ESMTP ready"}},
+			{Send: "EHLO easeprobe\r\n", TextChecker: probe.TextChecker{Contain: `^250 .*\r\n$`, RegExp: true}},
+			{Send: "QUIT\r\n", TextChecker: probe.TextChecker{Contain: "221"}},
+		},
+	}
+	assert.Nil(t, tcp.Config(global.ProbeSettings{}))
+	s, m := tcp.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "step #2")
+
+	tcp.Steps[1].Contain = `(?m)^250 `
+	assert.Nil(t, tcp.Config(global.ProbeSettings{}))
+	s, m = tcp.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "3 Step(s) Passed Successfully")
+
+	// the step only sends
+	tcp.Steps = []Step{
+		{TextChecker: probe.TextChecker{Contain: "ready"}},
+		{Send: "NOOP\r\n"},
+		{Send: "QUIT\r\n", TextChecker: probe.TextChecker{NotContain: "502"}},
+	}
+	assert.Nil(t, tcp.Config(global.ProbeSettings{}))
+	s, m = tcp.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error: step #3 - the output contains [502]")
+
+	// the data after the match is kept for the next step
+	tcp.Steps = []Step{
+		{TextChecker: probe.TextChecker{Contain: "ready"}},
+		{Send: "PIPE\r\n", TextChecker: probe.TextChecker{Contain: "250 first\r\n"}},
+		{Timeout: 100 * time.Millisecond, TextChecker: probe.TextChecker{Contain: `^250 second\r\n$`, RegExp: true}},
+		{Timeout: 100 * time.Millisecond, TextChecker: probe.TextChecker{Contain: "second"}},
+	}
+	assert.Nil(t, tcp.Config(global.ProbeSettings{}))
+	s, m = tcp.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error: step #4 - no reply in 100ms")
+
+	tcp.Steps = tcp.Steps[:3]
+	s, m = tcp.DoProbe()
+	assert.True(t, s, m)
+	assert.Contains(t, m, "3 Step(s) Passed Successfully")
+
+	// the step times out
+	tcp.Steps = []Step{
+		{TextChecker: probe.TextChecker{Contain: "ready"}},
+		{Send: "HANG\r\n", Timeout: 100 * time.Millisecond, TextChecker: probe.TextChecker{Contain: "250"}},
+	}
+	assert.Nil(t, tcp.Config(global.ProbeSettings{}))
+	s, m = tcp.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error: step #2 - no reply in 100ms")
+
+	// the connection is closed by the server
+	tcp.Steps = []Step{
+		{Send: "QUIT\r\n", TextChecker: probe.TextChecker{Contain: "221 Bye\r\n"}},
+		{TextChecker: probe.TextChecker{Contain: "250"}},
+	}
+	assert.Nil(t, tcp.Config(global.ProbeSettings{}))
+	s, m = tcp.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error: step #2 - EOF")
+
+	// invalid regex
+	tcp.Steps = []Step{{TextChecker: probe.TextChecker{Contain: "[", RegExp: true}}}
+	assert.NotNil(t, tcp.Config(global.ProbeSettings{}))
+}
@@ -50,6 +50,8 @@ type TLS struct {
 
 	ExpireSkipVerify  bool          `yaml:"expire_skip_verify" json:"expire_skip_verify,omitempty" jsonschema:"title=Expire Skip Verify,description=Whether to skip verifying the certificate expire time"`
 	AlertExpireBefore time.Duration `yaml:"alert_expire_before" json:"alert_expire_before,omitempty" jsonschema:"title=Alert Expire Before,description=The alert expire before time"`
+	// the probe is degraded if the certificate expires within the window
+	DegradedExpireBefore time.Duration `yaml:"degraded_expire_before,omitempty" json:"degraded_expire_before,omitempty" jsonschema:"title=Degraded Expire Before,description=The probe is degraded if the certificate expires within this time"`
 
 	metrics *metrics
 }
@@ -135,6 +137,14 @@ func (t *TLS) DoProbe() (bool, string) {
 				}
 			}
 		}
+
+		if t.DegradedExpireBefore > 0 {
+			state := tconn.ConnectionState()
+			durLeft := time.Until(getEarliestCertExpiry(&state))
+			if durLeft < t.DegradedExpireBefore {
+				t.Degrade(fmt.Sprintf("certificate is expiring in %v", durLeft.Round(time.Second)))
+			}
+		}
 	}
 
 	state := tconn.ConnectionState()
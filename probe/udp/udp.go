@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package udp is the udp probe package
+package udp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/metric"
+	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/base"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// The payload formats
+const (
+	FormatText = "text"
+	FormatHex  = "hex"
+)
+
+// maxDatagramSize is the max size of a UDP datagram
+const maxDatagramSize = 65535
+
+// UDP implements a config for UDP
+type UDP struct {
+	base.DefaultProbe `yaml:",inline"`
+	Host              string `yaml:"host" json:"host" jsonschema:"required,format=hostname,title=Host,description=The host to probe,example=127.0.0.1:514"`
+	Payload           string `yaml:"payload,omitempty" json:"payload,omitempty" jsonschema:"title=Payload,description=The payload to send"`
+	Format            string `yaml:"format,omitempty" json:"format,omitempty" jsonschema:"enum=text,enum=hex,title=Format,description=The format of the payload and the reply (text or hex),default=text"`
+
+	// Reply Text Checker
+	probe.TextChecker `yaml:",inline"`
+
+	payload []byte `yaml:"-" json:"-"`
+
+	metrics *metrics `yaml:"-" json:"-"`
+}
+
+// Config UDP Config Object
+func (u *UDP) Config(gConf global.ProbeSettings) error {
+	kind := "udp"
+	tag := ""
+	name := u.ProbeName
+	u.DefaultProbe.Config(gConf, kind, tag, name, u.Host, u.DoProbe)
+
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		log.Errorf("[%s / %s] Invalid Host: %s - %v", u.ProbeKind, u.ProbeName, u.Host, err)
+		return fmt.Errorf("Invalid Host: %s. %v", u.Host, err)
+	}
+
+	u.Format = strings.ToLower(strings.TrimSpace(u.Format))
+	switch u.Format {
+	case "", FormatText:
+		u.Format = FormatText
+		u.payload = []byte(u.Payload)
+	case FormatHex:
+		payload, err := hex.DecodeString(strings.Join(strings.Fields(u.Payload), ""))
+		if err != nil {
+			log.Errorf("[%s / %s] Invalid hex payload: %s - %v", u.ProbeKind, u.ProbeName, u.Payload, err)
+			return fmt.Errorf("Invalid hex payload: %s. %v", u.Payload, err)
+		}
+		u.payload = payload
+	default:
+		log.Errorf("[%s / %s] Invalid format: %s", u.ProbeKind, u.ProbeName, u.Format)
+		return fmt.Errorf("Invalid format: %s, only support [%s, %s]", u.Format, FormatText, FormatHex)
+	}
+
+	if err := u.TextChecker.Config(); err != nil {
+		return err
+	}
+
+	u.metrics = newMetrics(kind, tag, u.Labels)
+
+	log.Debugf("[%s / %s] configuration: %+v", u.ProbeKind, u.ProbeName, *u)
+	return nil
+}
+
+// DoProbe return the checking result
+func (u *UDP) DoProbe() (bool, string) {
+	conn, err := net.DialTimeout("udp", u.Host, u.Timeout())
+	if err != nil {
+		log.Errorf("[%s / %s] error: %v", u.ProbeKind, u.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(u.Timeout()))
+
+	start := time.Now()
+	if _, err := conn.Write(u.payload); err != nil {
+		log.Errorf("[%s / %s] write error: %v", u.ProbeKind, u.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+
+	buf := make([]byte, maxDatagramSize)
+	n, err := conn.Read(buf)
+	rtt := time.Since(start)
+	if err != nil {
+		log.Errorf("[%s / %s] read error: %v", u.ProbeKind, u.ProbeName, err)
+		return false, fmt.Sprintf("Error: no reply - %v", err)
+	}
+	u.ExportMetrics(rtt, n)
+
+	reply := string(buf[:n])
+	if u.Format == FormatHex {
+		reply = hex.EncodeToString(buf[:n])
+	}
+	log.Debugf("[%s / %s] - reply: %s", u.ProbeKind, u.ProbeName, probe.CheckEmpty(reply))
+
+	log.Debugf("[%s / %s] - %s", u.ProbeKind, u.ProbeName, u.TextChecker.String())
+	if err := u.Check(reply); err != nil {
+		log.Errorf("[%s / %s] - %v", u.ProbeKind, u.ProbeName, err)
+		return false, fmt.Sprintf("Error: %v", err)
+	}
+
+	return true, fmt.Sprintf("UDP Reply Received Successfully! (%d bytes in %s)", n, rtt.Round(time.Microsecond))
+}
+
+// ExportMetrics export UDP metrics
+func (u *UDP) ExportMetrics(rtt time.Duration, size int) {
+	u.metrics.RTT.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     u.ProbeName,
+		"endpoint": u.ProbeResult.Endpoint,
+	}, u.Labels)).Set(float64(rtt.Milliseconds()))
+
+	u.metrics.ReplySize.With(metric.AddConstLabels(prometheus.Labels{
+		"name":     u.ProbeName,
+		"endpoint": u.ProbeResult.Endpoint,
+	}, u.Labels)).Set(float64(size))
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2022, MegaEase
+ * All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package udp
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/megaease/easeprobe/global"
+	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/base"
+	"github.com/stretchr/testify/assert"
+)
+
+// startEchoServer replies "echo: <payload>", and ignores the "silent" payload
+func startEchoServer(t *testing.T) (string, func()) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(string(buf[:n]), "silent") {
+				continue
+			}
+			pc.WriteTo(append([]byte("echo: "), buf[:n]...), addr)
+		}
+	}()
+	return pc.LocalAddr().String(), func() { pc.Close() }
+}
+
+func newUDP(host, payload string) *UDP {
+	return &UDP{
+		DefaultProbe: base.DefaultProbe{ProbeName: "dummy udp", ProbeTimeout: 500 * time.Millisecond},
+		Host:         host,
+		Payload:      payload,
+	}
+}
+
+func TestUDP(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+	addr, stop := startEchoServer(t)
+	defer stop()
+
+	u := newUDP(addr, "ping")
+	assert.Nil(t, u.Config(global.ProbeSettings{}))
+	assert.Equal(t, "udp", u.ProbeKind)
+	assert.Equal(t, FormatText, u.Format)
+
+	s, m := u.DoProbe()
+	assert.True(t, s)
+	assert.Contains(t, m, "10 bytes")
+
+	u.TextChecker = probe.TextChecker{Contain: "echo: ping"}
+	s, m = u.DoProbe()
+	assert.True(t, s)
+
+	u.TextChecker = probe.TextChecker{NotContain: "ping"}
+	s, m = u.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "the output contains [ping]")
+
+	u.TextChecker = probe.TextChecker{Contain: `^echo: p.*g$`, RegExp: true}
+	assert.Nil(t, u.Config(global.ProbeSettings{}))
+	s, m = u.DoProbe()
+	assert.True(t, s)
+
+	// hex payload and reply
+	u = newUDP(addr, "de ad BE EF")
+	u.Format = "HEX"
+	u.TextChecker = probe.TextChecker{Contain: "6563686f3a20deadbeef"}
+	assert.Nil(t, u.Config(global.ProbeSettings{}))
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, u.payload)
+	s, m = u.DoProbe()
+	assert.True(t, s, m)
+
+	// no reply
+	u = newUDP(addr, "silent")
+	assert.Nil(t, u.Config(global.ProbeSettings{}))
+	s, m = u.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "no reply")
+}
+
+func TestUDPConfig(t *testing.T) {
+	global.InitEaseProbe("easeprobe", "http://icon")
+
+	u := newUDP("localhost", "ping")
+	assert.NotNil(t, u.Config(global.ProbeSettings{}))
+	assert.NotNil(t, u.Result())
+
+	u = newUDP("localhost:514", "xyz")
+	u.Format = FormatHex
+	assert.NotNil(t, u.Config(global.ProbeSettings{}))
+
+	u = newUDP("localhost:514", "xyz")
+	u.Format = "base64"
+	assert.NotNil(t, u.Config(global.ProbeSettings{}))
+
+	u = newUDP("localhost:514", "xyz")
+	u.TextChecker = probe.TextChecker{Contain: "[", RegExp: true}
+	assert.NotNil(t, u.Config(global.ProbeSettings{}))
+
+	u = newUDP("invalid.host.local:514", "ping")
+	assert.Nil(t, u.Config(global.ProbeSettings{}))
+	s, m := u.DoProbe()
+	assert.False(t, s)
+	assert.Contains(t, m, "Error")
+}
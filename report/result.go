@@ -246,6 +246,8 @@ func ToLark(r probe.Result) string {
 		headerColor = "green"
 	case probe.StatusDown:
 		headerColor = "red"
+	case probe.StatusDegraded, probe.StatusFlapping:
+		headerColor = "orange"
 	case probe.StatusUnknown:
 		headerColor = "gray"
 	case probe.StatusInit:
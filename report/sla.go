@@ -34,23 +34,26 @@ import (
 
 // Availability is the Availability JSON structure
 type Availability struct {
-	UpTime   time.Duration `json:"up"`
-	DownTime time.Duration `json:"down"`
-	SLA      float64       `json:"sla"`
+	UpTime      time.Duration `json:"up"`
+	DownTime    time.Duration `json:"down"`
+	Maintenance time.Duration `json:"maintenance"`
+	SLA         float64       `json:"sla"`
 }
 
 // Summary is the Summary JSON structure
 type Summary struct {
-	Total int64 `json:"total"`
-	Up    int64 `json:"up"`
-	Down  int64 `json:"down"`
+	Total    int64 `json:"total"`
+	Up       int64 `json:"up"`
+	Degraded int64 `json:"degraded"`
+	Down     int64 `json:"down"`
 }
 
 // LatestProbe is the LatestProbe JSON structure
 type LatestProbe struct {
-	Time    time.Time    `json:"time"`
-	Status  probe.Status `json:"status"`
-	Message string       `json:"message"`
+	Time        time.Time    `json:"time"`
+	Status      probe.Status `json:"status"`
+	Message     string       `json:"message"`
+	Maintenance string       `json:"maintenance,omitempty"`
 }
 
 // SLA is the SLA JSON structure
@@ -68,19 +71,22 @@ func SLAObject(r *probe.Result) SLA {
 		Name:     r.Name,
 		Endpoint: r.Endpoint,
 		Availability: Availability{
-			UpTime:   r.Stat.UpTime,
-			DownTime: r.Stat.DownTime,
-			SLA:      r.SLAPercent(),
+			UpTime:      r.Stat.UpTime,
+			DownTime:    r.Stat.DownTime,
+			Maintenance: r.Stat.MaintTime,
+			SLA:         r.SLAPercent(),
 		},
 		ProbeTimes: Summary{
-			Total: r.Stat.Total,
-			Up:    r.Stat.Status[probe.StatusUp],
-			Down:  r.Stat.Status[probe.StatusDown] + r.Stat.Status[probe.StatusUnknown],
+			Total:    r.Stat.Total,
+			Up:       r.Stat.Status[probe.StatusUp],
+			Degraded: r.Stat.Status[probe.StatusDegraded],
+			Down:     r.Stat.Status[probe.StatusDown] + r.Stat.Status[probe.StatusUnknown] + r.Stat.Status[probe.StatusUnreachable],
 		},
 		LatestProbe: LatestProbe{
-			Time:    r.StartTime,
-			Status:  r.Status,
-			Message: r.Message,
+			Time:        r.StartTime,
+			Status:      r.Status,
+			Message:     r.Message,
+			Maintenance: r.Maintenance,
 		},
 	}
 
@@ -202,10 +208,10 @@ func SLAHTMLSection(r *probe.Result) string {
 
 	html := `
 	<tr>
-		<td class="head" colspan="3"><b>%s</b> - %s<td>
+		<td class="head" colspan="3"><b>%s</b> - %s%s<td>
 	</tr>
 	<tr>
-		<td class="data"><b>Availability</b><br><b>Uptime: </b>%s,  <b>Downtime: </b>%s  </td>
+		<td class="data"><b>Availability</b><br><b>Uptime: </b>%s,  <b>Downtime: </b>%s%s  </td>
 		<td class="data"><b>SLA<b><br>%.2f%%</td>
 		<td class="data"><b>Probe-Times</b><br><b>Total</b>: %d ( %s )</td>
 	</tr>
@@ -213,8 +219,16 @@ func SLAHTMLSection(r *probe.Result) string {
 		<td  class="data" colspan="3"><b>Latest Probe</b>: %s - %s<br>%s<td>
 	</tr>
 	`
-	return fmt.Sprintf(html, r.Name, r.Endpoint,
-		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime),
+	maintenance := ""
+	if r.IsInMaintenance() {
+		maintenance = fmt.Sprintf(" - 🔧 <b>In Maintenance</b> ( %s )", JSONEscape(r.Maintenance))
+	}
+	maintTime := ""
+	if r.Stat.MaintTime > 0 {
+		maintTime = ",  <b>Maintenance: </b>" + DurationStr(r.Stat.MaintTime)
+	}
+	return fmt.Sprintf(html, r.Name, r.Endpoint, maintenance,
+		DurationStr(r.Stat.UpTime), DurationStr(r.Stat.DownTime), maintTime,
 		r.SLAPercent(),
 		r.Stat.Total, SLAStatusText(r.Stat, HTML),
 		FormatTime(r.StartTime),
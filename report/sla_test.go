@@ -198,3 +198,22 @@ func TestSLAFilter(t *testing.T) {
 	assert.NotContains(t, html, probes[3].Name())
 
 }
+
+func TestSLAMaintenance(t *testing.T) {
+	global.InitEaseProbe("DummyProbe", "icon")
+	probes := getProbers()
+	probes[0].Result().Maintenance = "upgrade"
+	probes[0].Result().Stat.MaintTime = 1800000000000
+	probes[1].Result().Maintenance = ""
+	setResultData(probes)
+
+	html := SLAHTMLSection(probes[0].Result())
+	assert.Contains(t, html, "In Maintenance</b> ( upgrade )")
+	assert.Contains(t, html, "<b>Maintenance: </b>30m")
+	html = SLAHTMLSection(probes[1].Result())
+	assert.NotContains(t, html, "In Maintenance")
+
+	sla := SLAObject(probes[0].Result())
+	assert.Equal(t, "upgrade", sla.LatestProbe.Maintenance)
+	assert.Equal(t, probes[0].Result().Stat.MaintTime, sla.Availability.Maintenance)
+}
@@ -26,6 +26,7 @@ import (
 	"html"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -33,6 +34,7 @@ import (
 	"github.com/megaease/easeprobe/conf"
 	"github.com/megaease/easeprobe/global"
 	"github.com/megaease/easeprobe/probe"
+	"github.com/megaease/easeprobe/probe/heartbeat"
 	"github.com/megaease/easeprobe/report"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
@@ -139,6 +141,29 @@ func slaJSON(w http.ResponseWriter, req *http.Request) {
 	w.Write([]byte(report.SLAJSON(_probers)))
 }
 
+// heartbeatPing receives the heartbeat of the heartbeat probe,
+// the token is carried by the `Authorization: Bearer <token>` header or the `token` query parameter.
+func heartbeatPing(w http.ResponseWriter, req *http.Request) {
+	name, err := url.PathUnescape(chi.URLParam(req, "name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+	if len(token) <= 0 {
+		token = req.URL.Query().Get("token")
+	}
+
+	switch err := heartbeat.Ping(name, token, req.RemoteAddr); {
+	case errors.Is(err, heartbeat.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, heartbeat.ErrUnauthorized):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		w.Write([]byte("OK"))
+	}
+}
+
 // SetProbers set the probers
 func SetProbers(p []probe.Prober) {
 	probers = &p
@@ -191,6 +216,7 @@ func Server() {
 
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Get("/sla", slaJSON)
+		r.Post("/heartbeat/{name}", heartbeatPing)
 	})
 
 	r.NotFound(slaHTML)